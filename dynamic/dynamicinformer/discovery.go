@@ -0,0 +1,263 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicinformer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+)
+
+// defaultDiscoveryPollInterval is how often discovery is polled if
+// DiscoveryInformerOptions.PollInterval is not set.
+const defaultDiscoveryPollInterval = 30 * time.Second
+
+// DiscoveryInformerOptions configures which resources a
+// DiscoveryInformerFactory runs informers for and how it reacts to
+// resources appearing and disappearing.
+type DiscoveryInformerOptions struct {
+	// Groups limits the informers to resources in one of these API
+	// groups. The core group is "". All groups are selected if empty.
+	Groups []string
+
+	// Categories limits the informers to resources in at least one of
+	// these categories, for example "all". All resources are selected
+	// if empty.
+	Categories []string
+
+	// CategoryExpander is used to resolve Categories. If nil, an
+	// expander based on the discovery client is used.
+	CategoryExpander restmapper.CategoryExpander
+
+	// Verbs which a resource must support in addition to list and watch.
+	Verbs []string
+
+	// PollInterval is how often discovery is polled for changes. If the
+	// discovery client is a discovery.CachedDiscoveryInterface, it gets
+	// invalidated before each poll. Defaults to 30 seconds.
+	PollInterval time.Duration
+
+	// OnAdd is called after the informer for a newly discovered resource
+	// has been created and before it gets started. It is the place to add
+	// event handlers.
+	OnAdd func(gvr schema.GroupVersionResource, informer informers.GenericInformer)
+
+	// OnRemove is called when a resource has disappeared from discovery,
+	// for example because its CustomResourceDefinition was deleted, right
+	// before its informer gets stopped.
+	OnRemove func(gvr schema.GroupVersionResource, informer informers.GenericInformer)
+
+	// FactoryOptions are passed on to the underlying DynamicSharedInformerFactory.
+	FactoryOptions []DynamicSharedInformerOption
+}
+
+// DiscoveryInformerFactory runs dynamic informers for all resources which
+// are served by the API server and match its options. It follows changes
+// in discovery, so informers for new CustomResourceDefinitions are started
+// and informers for removed ones are stopped.
+type DiscoveryInformerFactory interface {
+	// Run polls discovery and starts and stops informers accordingly
+	// until the context is canceled. All informers are shut down before
+	// Run returns.
+	Run(ctx context.Context)
+
+	// Resources returns the resources for which informers are running.
+	Resources() []schema.GroupVersionResource
+
+	// WaitForCacheSync blocks until all running informers' caches were synced
+	// or the stop channel gets closed.
+	WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool
+}
+
+// NewDiscoveryInformerFactory constructs a DiscoveryInformerFactory which uses
+// discoveryClient to find resources and client to list and watch them.
+func NewDiscoveryInformerFactory(client dynamic.Interface, discoveryClient discovery.DiscoveryInterface, defaultResync time.Duration, options DiscoveryInformerOptions) DiscoveryInformerFactory {
+	if options.CategoryExpander == nil && len(options.Categories) > 0 {
+		options.CategoryExpander = restmapper.NewDiscoveryCategoryExpander(discoveryClient)
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultDiscoveryPollInterval
+	}
+	return &discoveryInformerFactory{
+		factory:         NewDynamicSharedInformerFactoryWithOptions(client, defaultResync, options.FactoryOptions...).(*dynamicSharedInformerFactory),
+		discoveryClient: discoveryClient,
+		options:         options,
+		resources:       map[schema.GroupVersionResource]informers.GenericInformer{},
+	}
+}
+
+type discoveryInformerFactory struct {
+	factory         *dynamicSharedInformerFactory
+	discoveryClient discovery.DiscoveryInterface
+	options         DiscoveryInformerOptions
+
+	lock      sync.Mutex
+	resources map[schema.GroupVersionResource]informers.GenericInformer
+}
+
+var _ DiscoveryInformerFactory = &discoveryInformerFactory{}
+
+func (f *discoveryInformerFactory) Run(ctx context.Context) {
+	defer f.factory.Shutdown()
+	wait.UntilWithContext(ctx, f.sync, f.options.PollInterval)
+}
+
+func (f *discoveryInformerFactory) Resources() []schema.GroupVersionResource {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	resources := make([]schema.GroupVersionResource, 0, len(f.resources))
+	for gvr := range f.resources {
+		resources = append(resources, gvr)
+	}
+	return resources
+}
+
+func (f *discoveryInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	return f.factory.WaitForCacheSync(stopCh)
+}
+
+// sync reconciles the running informers with the resources in discovery.
+func (f *discoveryInformerFactory) sync(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+
+	desired, failedGroups, err := f.discoverResources()
+	if err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Failed to discover resources")
+		return
+	}
+
+	removed, added := f.updateResources(desired, failedGroups)
+
+	// The callbacks are called without holding the lock, so that they can use the factory.
+	for gvr, informer := range removed {
+		logger.V(2).Info("Stopping informer for resource which is no longer served", "resource", gvr)
+		if f.options.OnRemove != nil {
+			f.options.OnRemove(gvr, informer)
+		}
+		f.factory.StopInformer(gvr)
+	}
+	for gvr, informer := range added {
+		logger.V(2).Info("Starting informer for discovered resource", "resource", gvr)
+		if f.options.OnAdd != nil {
+			f.options.OnAdd(gvr, informer)
+		}
+	}
+	f.factory.Start(ctx.Done())
+}
+
+// updateResources updates the resources for which informers are running to the
+// desired ones, and returns the informers of the removed and of the added resources.
+func (f *discoveryInformerFactory) updateResources(desired sets.Set[schema.GroupVersionResource], failedGroups sets.Set[schema.GroupVersion]) (removed, added map[schema.GroupVersionResource]informers.GenericInformer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	removed = map[schema.GroupVersionResource]informers.GenericInformer{}
+	for gvr, informer := range f.resources {
+		// Keep informers for groups which could not be discovered this time.
+		if desired.Has(gvr) || failedGroups.Has(gvr.GroupVersion()) {
+			continue
+		}
+		removed[gvr] = informer
+		delete(f.resources, gvr)
+	}
+
+	added = map[schema.GroupVersionResource]informers.GenericInformer{}
+	for gvr := range desired {
+		if _, exists := f.resources[gvr]; exists {
+			continue
+		}
+		informer := f.factory.ForResource(gvr)
+		added[gvr] = informer
+		f.resources[gvr] = informer
+	}
+	return removed, added
+}
+
+// discoverResources returns the resources which match the options, together with
+// the group versions which could not be discovered.
+func (f *discoveryInformerFactory) discoverResources() (sets.Set[schema.GroupVersionResource], sets.Set[schema.GroupVersion], error) {
+	if cached, ok := f.discoveryClient.(discovery.CachedDiscoveryInterface); ok {
+		cached.Invalidate()
+	}
+
+	failedGroups := sets.New[schema.GroupVersion]()
+	lists, err := f.discoveryClient.ServerPreferredResources()
+	if err != nil {
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) {
+			return nil, nil, err
+		}
+		for gv := range groupErr.Groups {
+			failedGroups.Insert(gv)
+		}
+	}
+
+	var categoryResources sets.Set[schema.GroupResource]
+	if len(f.options.Categories) > 0 {
+		categoryResources = sets.New[schema.GroupResource]()
+		for _, category := range f.options.Categories {
+			if groupResources, ok := f.options.CategoryExpander.Expand(category); ok {
+				categoryResources.Insert(groupResources...)
+			}
+		}
+	}
+
+	groups := sets.New(f.options.Groups...)
+	verbs := discovery.SupportsAllVerbs{Verbs: append([]string{"list", "watch"}, f.options.Verbs...)}
+	desired := sets.New[schema.GroupVersionResource]()
+	for _, list := range discovery.FilteredBy(verbs, lists) {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		if groups.Len() > 0 && !groups.Has(gv.Group) {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if !f.selected(gv, resource, categoryResources) {
+				continue
+			}
+			desired.Insert(gv.WithResource(resource.Name))
+		}
+	}
+	return desired, failedGroups, nil
+}
+
+func (f *discoveryInformerFactory) selected(gv schema.GroupVersion, resource metav1.APIResource, categoryResources sets.Set[schema.GroupResource]) bool {
+	// Subresources cannot be watched on their own.
+	if strings.Contains(resource.Name, "/") {
+		return false
+	}
+	if categoryResources != nil && !categoryResources.Has(gv.WithResource(resource.Name).GroupResource()) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicinformer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// preferredResourcesDiscovery serves ServerPreferredResources from the
+// resources of the fake discovery client, which can be replaced while
+// the test runs.
+type preferredResourcesDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	lock sync.Mutex
+}
+
+func (d *preferredResourcesDiscovery) setResources(resources ...*metav1.APIResourceList) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.Resources = resources
+}
+
+func (d *preferredResourcesDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return discovery.ServerPreferredResources(d.FakeDiscovery)
+}
+
+func TestDiscoveryInformerFactory(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	gadgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "gadgets"}
+	listWatch := metav1.Verbs{"get", "list", "watch"}
+
+	appsResources := &metav1.APIResourceList{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: listWatch, Categories: []string{"all"}},
+			{Name: "deployments/status", Namespaced: true, Kind: "Deployment", Verbs: listWatch},
+		},
+	}
+	exampleResources := &metav1.APIResourceList{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "widgets", Namespaced: true, Kind: "Widget", Verbs: listWatch, Categories: []string{"all"}},
+			{Name: "gadgets", Namespaced: true, Kind: "Gadget", Verbs: listWatch},
+			{Name: "tokens", Namespaced: true, Kind: "Token", Verbs: metav1.Verbs{"create"}},
+		},
+	}
+
+	scenarios := []struct {
+		name     string
+		options  dynamicinformer.DiscoveryInformerOptions
+		expected []schema.GroupVersionResource
+		// expectedAfterRemoval is the result after the example.com group was removed.
+		expectedAfterRemoval []schema.GroupVersionResource
+	}{
+		{
+			name:                 "all resources",
+			expected:             []schema.GroupVersionResource{deployments, widgets, gadgets},
+			expectedAfterRemoval: []schema.GroupVersionResource{deployments},
+		},
+		{
+			name:                 "by group",
+			options:              dynamicinformer.DiscoveryInformerOptions{Groups: []string{"example.com"}},
+			expected:             []schema.GroupVersionResource{widgets, gadgets},
+			expectedAfterRemoval: []schema.GroupVersionResource{},
+		},
+		{
+			name: "by category",
+			options: dynamicinformer.DiscoveryInformerOptions{
+				Categories: []string{"all"},
+				CategoryExpander: restmapper.SimpleCategoryExpander{Expansions: map[string][]schema.GroupResource{
+					"all": {deployments.GroupResource(), widgets.GroupResource()},
+				}},
+			},
+			expected:             []schema.GroupVersionResource{deployments, widgets},
+			expectedAfterRemoval: []schema.GroupVersionResource{deployments},
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), wait.ForeverTestTimeout)
+			defer cancel()
+
+			discoveryClient := &preferredResourcesDiscovery{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}}
+			discoveryClient.setResources(appsResources, exampleResources)
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				deployments: "DeploymentList",
+				widgets:     "WidgetList",
+				gadgets:     "GadgetList",
+			})
+
+			var lock sync.Mutex
+			var removed []schema.GroupVersionResource
+			var target dynamicinformer.DiscoveryInformerFactory
+			options := ts.options
+			options.PollInterval = 10 * time.Millisecond
+			// The callbacks may use the factory.
+			options.OnAdd = func(gvr schema.GroupVersionResource, _ informers.GenericInformer) {
+				target.Resources()
+			}
+			options.OnRemove = func(gvr schema.GroupVersionResource, _ informers.GenericInformer) {
+				target.Resources()
+				lock.Lock()
+				defer lock.Unlock()
+				removed = append(removed, gvr)
+			}
+			target = dynamicinformer.NewDiscoveryInformerFactory(client, discoveryClient, 0, options)
+
+			var wg wait.Group
+			wg.StartWithContext(ctx, target.Run)
+			defer wg.Wait()
+			defer cancel()
+
+			waitForResources(ctx, t, target, ts.expected)
+			for gvr, synced := range target.WaitForCacheSync(ctx.Done()) {
+				if !synced {
+					t.Errorf("informer for %s hasn't synced", gvr)
+				}
+			}
+
+			discoveryClient.setResources(appsResources)
+			waitForResources(ctx, t, target, ts.expectedAfterRemoval)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if diff := cmp.Diff(len(ts.expected)-len(ts.expectedAfterRemoval), len(removed)); diff != "" {
+				t.Errorf("unexpected number of removed resources (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func waitForResources(ctx context.Context, t *testing.T, target dynamicinformer.DiscoveryInformerFactory, expected []schema.GroupVersionResource) {
+	t.Helper()
+	sortGVRs := cmpopts.SortSlices(func(a, b schema.GroupVersionResource) bool { return a.String() < b.String() })
+	err := wait.PollUntilContextCancel(ctx, 10*time.Millisecond, true, func(context.Context) (bool, error) {
+		return cmp.Equal(expected, target.Resources(), sortGVRs, cmpopts.EquateEmpty()), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected resources (-want +got):\n%s", cmp.Diff(expected, target.Resources(), sortGVRs, cmpopts.EquateEmpty()))
+	}
+}
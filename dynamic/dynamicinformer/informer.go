@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamiclister"
@@ -40,14 +41,51 @@ func NewDynamicSharedInformerFactory(client dynamic.Interface, defaultResync tim
 // NewFilteredDynamicSharedInformerFactory constructs a new instance of dynamicSharedInformerFactory.
 // Listers obtained via this factory will be subject to the same filters as specified here.
 func NewFilteredDynamicSharedInformerFactory(client dynamic.Interface, defaultResync time.Duration, namespace string, tweakListOptions TweakListOptionsFunc) DynamicSharedInformerFactory {
-	return &dynamicSharedInformerFactory{
+	return NewDynamicSharedInformerFactoryWithOptions(client, defaultResync, WithNamespace(namespace), WithTweakListOptions(tweakListOptions))
+}
+
+// DynamicSharedInformerOption defines the functional option type for DynamicSharedInformerFactory.
+type DynamicSharedInformerOption func(*dynamicSharedInformerFactory)
+
+// WithNamespace limits the DynamicSharedInformerFactory to the specified namespace.
+func WithNamespace(namespace string) DynamicSharedInformerOption {
+	return func(factory *dynamicSharedInformerFactory) {
+		factory.namespace = namespace
+	}
+}
+
+// WithTweakListOptions sets a custom filter on all listers of the configured DynamicSharedInformerFactory.
+func WithTweakListOptions(tweakListOptions TweakListOptionsFunc) DynamicSharedInformerOption {
+	return func(factory *dynamicSharedInformerFactory) {
+		factory.tweakListOptions = tweakListOptions
+	}
+}
+
+// WithReferenceCounting makes the DynamicSharedInformerFactory stop an informer and drop
+// it, including its cache, once the last event handler has been removed from it with
+// RemoveEventHandler. A later call to ForResource creates a new informer which gets
+// started by the next call to Start.
+func WithReferenceCounting() DynamicSharedInformerOption {
+	return func(factory *dynamicSharedInformerFactory) {
+		factory.referenceCounting = true
+	}
+}
+
+// NewDynamicSharedInformerFactoryWithOptions constructs a new instance of dynamicSharedInformerFactory
+// with additional options.
+func NewDynamicSharedInformerFactoryWithOptions(client dynamic.Interface, defaultResync time.Duration, options ...DynamicSharedInformerOption) DynamicSharedInformerFactory {
+	factory := &dynamicSharedInformerFactory{
 		client:           client,
 		defaultResync:    defaultResync,
-		namespace:        namespace,
+		namespace:        metav1.NamespaceAll,
 		informers:        map[schema.GroupVersionResource]informers.GenericInformer{},
 		startedInformers: make(map[schema.GroupVersionResource]bool),
-		tweakListOptions: tweakListOptions,
+		stopInformers:    make(map[schema.GroupVersionResource]context.CancelFunc),
 	}
+	for _, opt := range options {
+		opt(factory)
+	}
+	return factory
 }
 
 type dynamicSharedInformerFactory struct {
@@ -60,7 +98,12 @@ type dynamicSharedInformerFactory struct {
 	// startedInformers is used for tracking which informers have been started.
 	// This allows Start() to be called multiple times safely.
 	startedInformers map[schema.GroupVersionResource]bool
+	// stopInformers holds the functions which stop the started informers.
+	stopInformers    map[schema.GroupVersionResource]context.CancelFunc
 	tweakListOptions TweakListOptionsFunc
+	// referenceCounting is true if informers get stopped once their last
+	// event handler is removed.
+	referenceCounting bool
 
 	// wg tracks how many goroutines were started.
 	wg sync.WaitGroup
@@ -70,6 +113,7 @@ type dynamicSharedInformerFactory struct {
 }
 
 var _ DynamicSharedInformerFactory = &dynamicSharedInformerFactory{}
+var _ InformerStopper = &dynamicSharedInformerFactory{}
 
 func (f *dynamicSharedInformerFactory) ForResource(gvr schema.GroupVersionResource) informers.GenericInformer {
 	f.lock.Lock()
@@ -82,11 +126,41 @@ func (f *dynamicSharedInformerFactory) ForResource(gvr schema.GroupVersionResour
 	}
 
 	informer = NewFilteredDynamicInformer(f.client, gvr, f.namespace, f.defaultResync, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+	if f.referenceCounting {
+		counted := &dynamicInformer{gvr: gvr}
+		counted.informer = cache.NewReferenceCountedInformer(informer.Informer(), func() {
+			f.lock.Lock()
+			defer f.lock.Unlock()
+
+			// The informer may already have been replaced after an explicit StopInformer.
+			if f.informers[key] == counted {
+				f.stopInformerLocked(key)
+			}
+		})
+		informer = counted
+	}
 	f.informers[key] = informer
 
 	return informer
 }
 
+// StopInformer stops the informer for the given resource and removes it from the factory.
+func (f *dynamicSharedInformerFactory) StopInformer(gvr schema.GroupVersionResource) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.stopInformerLocked(gvr)
+}
+
+func (f *dynamicSharedInformerFactory) stopInformerLocked(gvr schema.GroupVersionResource) {
+	if stop, ok := f.stopInformers[gvr]; ok {
+		stop()
+	}
+	delete(f.informers, gvr)
+	delete(f.startedInformers, gvr)
+	delete(f.stopInformers, gvr)
+}
+
 // Start initializes all requested informers.
 func (f *dynamicSharedInformerFactory) Start(stopCh <-chan struct{}) {
 	f.lock.Lock()
@@ -103,11 +177,13 @@ func (f *dynamicSharedInformerFactory) Start(stopCh <-chan struct{}) {
 			// otherwise the goroutine would use the loop variable
 			// and that keeps changing.
 			informer := informer.Informer()
+			ctx, cancel := context.WithCancel(wait.ContextForChannel(stopCh))
 			go func() {
 				defer f.wg.Done()
-				informer.Run(stopCh)
+				informer.RunWithContext(ctx)
 			}()
 			f.startedInformers[informerType] = true
+			f.stopInformers[informerType] = cancel
 		}
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/tools/cache"
@@ -295,6 +296,72 @@ func TestDynamicSharedInformerFactory(t *testing.T) {
 	}
 }
 
+func TestDynamicSharedInformerFactoryStopInformer(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "deployments"}
+	gvrToListKind := map[schema.GroupVersionResource]string{gvr: "DeploymentList"}
+
+	scenarios := []struct {
+		name string
+		stop func(target dynamicinformer.DynamicSharedInformerFactory, informer cache.SharedIndexInformer, handle cache.ResourceEventHandlerRegistration)
+	}{
+		{
+			name: "explicit StopInformer",
+			stop: func(target dynamicinformer.DynamicSharedInformerFactory, _ cache.SharedIndexInformer, _ cache.ResourceEventHandlerRegistration) {
+				target.(dynamicinformer.InformerStopper).StopInformer(gvr)
+			},
+		},
+		{
+			name: "removing the last handler",
+			stop: func(_ dynamicinformer.DynamicSharedInformerFactory, informer cache.SharedIndexInformer, handle cache.ResourceEventHandlerRegistration) {
+				if err := informer.RemoveEventHandler(handle); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			fakeClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind)
+			target := dynamicinformer.NewDynamicSharedInformerFactoryWithOptions(fakeClient, 0, dynamicinformer.WithReferenceCounting())
+			defer target.Shutdown()
+			defer cancel()
+
+			informer := target.ForResource(gvr).Informer()
+			handle, err := informer.AddEventHandler(&cache.ResourceEventHandlerFuncs{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			target.Start(ctx.Done())
+			if synced := target.WaitForCacheSync(ctx.Done()); !synced[gvr] {
+				t.Fatalf("informer for %s hasn't synced", gvr)
+			}
+
+			ts.stop(target, informer, handle)
+			if err := wait.PollUntilContextCancel(ctx, 10*time.Millisecond, true, func(context.Context) (bool, error) {
+				return informer.IsStopped(), nil
+			}); err != nil {
+				t.Fatalf("informer for %s wasn't stopped: %v", gvr, err)
+			}
+			if synced := target.WaitForCacheSync(ctx.Done()); len(synced) != 0 {
+				t.Errorf("expected no started informers, got %v", synced)
+			}
+
+			// Requesting the resource again yields a new informer.
+			newInformer := target.ForResource(gvr).Informer()
+			if newInformer == informer {
+				t.Fatal("expected a new informer after stopping the old one")
+			}
+			target.Start(ctx.Done())
+			if synced := target.WaitForCacheSync(ctx.Done()); !synced[gvr] {
+				t.Errorf("new informer for %s hasn't synced", gvr)
+			}
+		})
+	}
+}
+
 func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
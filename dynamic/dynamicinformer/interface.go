@@ -48,6 +48,18 @@ type DynamicSharedInformerFactory interface {
 	Shutdown()
 }
 
+// InformerStopper is implemented by the factories returned by
+// NewDynamicSharedInformerFactoryWithOptions and the other constructors of
+// this package. It is not part of DynamicSharedInformerFactory, so callers
+// have to check for it with a type assertion.
+type InformerStopper interface {
+	// StopInformer stops the informer for the given resource and removes it, including
+	// its cache, from the factory. A later call to ForResource creates a new informer
+	// which gets started by the next call to Start. It does nothing if the informer was
+	// never requested.
+	StopInformer(gvr schema.GroupVersionResource)
+}
+
 // TweakListOptionsFunc defines the signature of a helper function
 // that wants to provide more listing options to API
 type TweakListOptionsFunc func(*metav1.ListOptions)
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Note: the example only works with the code within the same release/branch.
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/homedir"
+	"k8s.io/klog/v2"
+)
+
+func main() {
+	klog.InitFlags(nil)
+
+	var kubeconfig string
+	if home := homedir.HomeDir(); home != "" {
+		flag.StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+	var serve, namespace, pod, container, helper, localAddress, remoteAddress string
+	flag.StringVar(&serve, "serve", "", "run as the helper in the pod and listen on this address")
+	flag.StringVar(&namespace, "namespace", "default", "the namespace of the pod")
+	flag.StringVar(&pod, "pod", "", "the pod to expose the local address in")
+	flag.StringVar(&container, "container", "", "(optional) the container which runs the helper")
+	flag.StringVar(&helper, "helper", "/reverse-port-forward", "the path of this program in the container")
+	flag.StringVar(&localAddress, "local-address", "localhost:8080", "the local address to expose in the pod")
+	flag.StringVar(&remoteAddress, "remote-address", "localhost:8080", "the address to listen on in the pod")
+	flag.Parse()
+
+	if serve != "" {
+		// The helper side, run in the pod through exec. It tunnels the
+		// connections to the listener through its standard input and
+		// output, until the client closes its standard input.
+		listener, err := net.Listen("tcp", serve)
+		if err != nil {
+			klog.Fatal(err)
+		}
+		if err := portforward.ServeReverse(listener, os.Stdin, os.Stdout); err != nil {
+			klog.Fatal(err)
+		}
+		return
+	}
+
+	if pod == "" {
+		klog.Fatal("unable to get the pod name (missing pod flag).")
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	// The client side runs the helper like
+	// "kubectl exec -i <pod> -- /reverse-port-forward -serve <address>"
+	// and connects its connections to the local address.
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container,
+			Command:   []string{helper, "-serve", remoteAddress},
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	stopChan := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stopChan)
+	}()
+
+	forwarder, err := portforward.NewReverse(executor, localAddress, stopChan, nil, os.Stdout, os.Stderr)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	if err := forwarder.ForwardPorts(); err != nil {
+		klog.Fatal(err)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newPod(namespace, name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func newShardedClient() *fake.Clientset {
+	return fake.NewClientset(
+		newPod("a", "pod-a"),
+		newPod("b", "pod-b"),
+		newPod("c", "pod-c"),
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}},
+	)
+}
+
+func podInformer(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+	return factory.Core().V1().Pods().Informer()
+}
+
+func podKeys(t *testing.T, informer cache.SharedIndexInformer) []string {
+	t.Helper()
+	pods, err := corev1listers.NewPodLister(informer.GetIndexer()).List(labels.Everything())
+	require.NoError(t, err)
+	var keys []string
+	for _, pod := range pods {
+		keys = append(keys, pod.Namespace+"/"+pod.Name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestMultiNamespaceInformerFactory(t *testing.T) {
+	factory := informers.NewMultiNamespaceInformerFactory(newShardedClient(), 0, []string{"a", "b"})
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	informer := factory.InformerFor(&v1.Pod{}, podInformer)
+	assert.Same(t, informer, factory.InformerFor(&v1.Pod{}, podInformer))
+	assert.Equal(t, []string{"a", "b"}, informer.Namespaces())
+
+	factory.Start(ctx.Done())
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		require.True(t, synced, "%v not synced", informerType)
+	}
+	assert.Equal(t, []string{"a/pod-a", "b/pod-b"}, podKeys(t, informer))
+}
+
+func TestMultiNamespaceInformerFactoryAddRemoveNamespace(t *testing.T) {
+	factory := informers.NewMultiNamespaceInformerFactory(newShardedClient(), 0, []string{"a"})
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	var added, deleted []string
+	informer := factory.InformerFor(&v1.Pod{}, podInformer)
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			added = append(added, obj.(*v1.Pod).Name)
+		},
+		DeleteFunc: func(obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			deleted = append(deleted, obj.(*v1.Pod).Name)
+		},
+	})
+	require.NoError(t, err)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	assert.Equal(t, []string{"a/pod-a"}, podKeys(t, informer))
+
+	// running informers start watching added namespaces
+	require.NoError(t, factory.AddNamespace("c"))
+	assert.Equal(t, []string{"a", "c"}, factory.Namespaces())
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(added) == 2
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Equal(t, []string{"a/pod-a", "c/pod-c"}, podKeys(t, informer))
+
+	// and drop the objects of removed namespaces
+	require.NoError(t, factory.RemoveNamespace("a"))
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(deleted) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Equal(t, []string{"pod-a"}, deleted)
+	assert.Equal(t, []string{"c/pod-c"}, podKeys(t, informer))
+
+	// a namespace can be watched again after it was removed
+	require.NoError(t, factory.AddNamespace("a"))
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), informer.HasSynced))
+	assert.Equal(t, []string{"a/pod-a", "c/pod-c"}, podKeys(t, informer))
+}
+
+func TestMultiNamespaceInformerFactoryAddNamespaceBeforeInformer(t *testing.T) {
+	factory := informers.NewMultiNamespaceInformerFactory(newShardedClient(), 0, []string{"a"})
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// informers created later use the current namespaces
+	require.NoError(t, factory.AddNamespace("b"))
+	informer := factory.InformerFor(&v1.Pod{}, podInformer)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	assert.Equal(t, []string{"a/pod-a", "b/pod-b"}, podKeys(t, informer))
+}
+
+func TestMultiNamespaceInformerFactoryRemoveNamespaceFromHandler(t *testing.T) {
+	factory := informers.NewMultiNamespaceInformerFactory(newShardedClient(), 0, []string{"a", "b"})
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handlers may use the factory, even to remove the namespace of the
+	// object which they are handling.
+	informer := factory.InformerFor(&v1.Pod{}, podInformer)
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod := obj.(*v1.Pod); pod.Namespace == "a" {
+				assert.NoError(t, factory.RemoveNamespace(pod.Namespace))
+				factory.InformerFor(&v1.Pod{}, podInformer)
+			}
+		},
+	})
+	require.NoError(t, err)
+	factory.Start(ctx.Done())
+
+	assert.Eventually(t, func() bool {
+		return informer.HasSynced() && len(factory.Namespaces()) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Equal(t, []string{"b/pod-b"}, podKeys(t, informer))
+}
+
+func TestReferenceCountingSharedInformerFactoryStopInformer(t *testing.T) {
+	factory := informers.NewReferenceCountingSharedInformerFactory(newShardedClient(), 0)
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopper, ok := factory.(informers.InformerStopper)
+	require.True(t, ok, "the factory must implement InformerStopper")
+	pods := v1.SchemeGroupVersion.WithResource("pods")
+	nodes := v1.SchemeGroupVersion.WithResource("nodes")
+	podInformer := factory.Core().V1().Pods().Informer()
+	factory.Start(ctx.Done())
+	require.Len(t, factory.WaitForCacheSync(ctx.Done()), 1)
+
+	// stopping informers which were never requested doesn't create them
+	stopper.StopInformer(nodes)
+	stopper.StopInformer(schema.GroupVersionResource{Group: "unknown", Version: "v1", Resource: "things"})
+	assert.Len(t, factory.WaitForCacheSync(ctx.Done()), 1)
+
+	stopper.StopInformer(pods)
+	assert.Eventually(t, podInformer.IsStopped, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Empty(t, factory.WaitForCacheSync(ctx.Done()))
+
+	// requesting the informer again creates a new one, also through ForResource
+	generic, err := factory.ForResource(pods)
+	require.NoError(t, err)
+	assert.NotSame(t, podInformer, generic.Informer())
+	assert.Same(t, generic.Informer(), factory.Core().V1().Pods().Informer())
+	factory.Start(ctx.Done())
+	require.Len(t, factory.WaitForCacheSync(ctx.Done()), 1)
+	pod, err := generic.Lister().ByNamespace("a").Get("pod-a")
+	require.NoError(t, err)
+	assert.Equal(t, "pod-a", pod.(*v1.Pod).Name)
+}
+
+func TestReferenceCountingSharedInformerFactoryRemoveEventHandler(t *testing.T) {
+	factory := informers.NewReferenceCountingSharedInformerFactory(newShardedClient(), 0)
+	defer factory.Shutdown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodeInformer := factory.Core().V1().Nodes().Informer()
+	handle, err := nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{})
+	require.NoError(t, err)
+	factory.Start(ctx.Done())
+	require.Len(t, factory.WaitForCacheSync(ctx.Done()), 1)
+
+	// removing the last handler stops the informer
+	require.NoError(t, nodeInformer.RemoveEventHandler(handle))
+	assert.Eventually(t, nodeInformer.IsStopped, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Empty(t, factory.WaitForCacheSync(ctx.Done()))
+	assert.NotSame(t, nodeInformer, factory.Core().V1().Nodes().Informer())
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NamespacedInformerFunc returns the informer of a namespaced resource from
+// a SharedInformerFactory which is limited to a single namespace, for example
+//
+//	func(factory SharedInformerFactory) cache.SharedIndexInformer {
+//		return factory.Core().V1().Pods().Informer()
+//	}
+type NamespacedInformerFunc func(factory SharedInformerFactory) cache.SharedIndexInformer
+
+// MultiNamespaceInformerFactory provides shared informers which watch a set
+// of namespaces, for clients which may not watch all namespaces. Each
+// informer is a cache.MultiNamespaceInformer with one watch per namespace,
+// which presents a single merged Indexer and stream of events. A lister for
+// the merged Indexer can be created with the lister constructor of the
+// resource, for example listers.NewPodLister(informer.GetIndexer()).
+//
+// The informer for a namespace comes from a SharedInformerFactory which is
+// created with WithNamespace for that namespace, so nothing is ever watched
+// outside of the set of namespaces. Only namespaced resources are supported,
+// because the informers of cluster-scoped resources ignore the namespace.
+type MultiNamespaceInformerFactory interface {
+	// Start initializes all requested informers. They are handled in goroutines
+	// which run until the stop channel gets closed.
+	Start(stopCh <-chan struct{})
+
+	// Shutdown marks the factory as shutting down and blocks until all
+	// goroutines have terminated, like SharedInformerFactory.Shutdown.
+	Shutdown()
+
+	// WaitForCacheSync blocks until all started informers' caches were synced
+	// for all namespaces or the stop channel gets closed.
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+
+	// InformerFor returns the informer for obj, using newInformer to get the
+	// informer for each namespace.
+	InformerFor(obj runtime.Object, newInformer NamespacedInformerFunc) cache.MultiNamespaceInformer
+
+	// AddNamespace adds a namespace to the set of watched namespaces. Informers
+	// which are already running start watching it right away.
+	AddNamespace(namespace string) error
+
+	// RemoveNamespace removes a namespace from the set of watched namespaces.
+	// Its objects are dropped from the caches and event handlers receive a
+	// delete notification for each of them.
+	RemoveNamespace(namespace string) error
+
+	// Namespaces returns the sorted list of watched namespaces.
+	Namespaces() []string
+}
+
+// NewMultiNamespaceInformerFactory constructs a MultiNamespaceInformerFactory
+// for the given namespaces. The options are applied to the SharedInformerFactory
+// of each namespace, except that WithNamespace is overridden.
+func NewMultiNamespaceInformerFactory(client kubernetes.Interface, defaultResync time.Duration, namespaces []string, options ...SharedInformerOption) MultiNamespaceInformerFactory {
+	return &multiNamespaceInformerFactory{
+		client:           client,
+		defaultResync:    defaultResync,
+		options:          options,
+		namespaces:       sets.New(namespaces...),
+		factories:        map[string]SharedInformerFactory{},
+		informers:        map[reflect.Type]cache.MultiNamespaceInformer{},
+		startedInformers: map[reflect.Type]bool{},
+	}
+}
+
+type multiNamespaceInformerFactory struct {
+	client        kubernetes.Interface
+	defaultResync time.Duration
+	options       []SharedInformerOption
+
+	// namespaceLock serializes AddNamespace and RemoveNamespace, so that all
+	// informers see the changes in the same order. Those update the informers
+	// without holding lock, because event handlers may use the factory.
+	namespaceLock sync.Mutex
+
+	lock       sync.Mutex
+	namespaces sets.Set[string]
+	informers  map[reflect.Type]cache.MultiNamespaceInformer
+	// startedInformers is used for tracking which informers have been started.
+	// This allows Start() to be called multiple times safely.
+	startedInformers map[reflect.Type]bool
+	// wg tracks how many goroutines were started.
+	wg sync.WaitGroup
+	// shuttingDown is true when Shutdown has been called. It may still be running
+	// because it needs to wait for goroutines.
+	shuttingDown bool
+
+	// factoriesLock protects factories. It is separate from lock because the
+	// factories are needed while the informers add namespaces.
+	factoriesLock sync.Mutex
+	// factories holds the factory of each watched namespace. It is dropped
+	// when the namespace is removed, because the informers which it created
+	// have been stopped and cannot be started again.
+	factories map[string]SharedInformerFactory
+}
+
+func (f *multiNamespaceInformerFactory) factoryFor(namespace string) SharedInformerFactory {
+	f.factoriesLock.Lock()
+	defer f.factoriesLock.Unlock()
+
+	factory, exists := f.factories[namespace]
+	if !exists {
+		options := append(append([]SharedInformerOption{}, f.options...), WithNamespace(namespace))
+		factory = NewSharedInformerFactoryWithOptions(f.client, f.defaultResync, options...)
+		f.factories[namespace] = factory
+	}
+	return factory
+}
+
+func (f *multiNamespaceInformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.shuttingDown {
+		return
+	}
+
+	for informerType, informer := range f.informers {
+		if !f.startedInformers[informerType] {
+			f.wg.Add(1)
+			informer := informer
+			go func() {
+				defer f.wg.Done()
+				informer.Run(stopCh)
+			}()
+			f.startedInformers[informerType] = true
+		}
+	}
+}
+
+func (f *multiNamespaceInformerFactory) Shutdown() {
+	f.lock.Lock()
+	f.shuttingDown = true
+	f.lock.Unlock()
+
+	// Will return immediately if there is nothing to wait for.
+	f.wg.Wait()
+}
+
+func (f *multiNamespaceInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	informers := func() map[reflect.Type]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[reflect.Type]cache.SharedIndexInformer{}
+		for informerType, informer := range f.informers {
+			if f.startedInformers[informerType] {
+				informers[informerType] = informer
+			}
+		}
+		return informers
+	}()
+
+	res := map[reflect.Type]bool{}
+	for informType, informer := range informers {
+		res[informType] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+func (f *multiNamespaceInformerFactory) InformerFor(obj runtime.Object, newInformer NamespacedInformerFunc) cache.MultiNamespaceInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	informerType := reflect.TypeOf(obj)
+	informer, exists := f.informers[informerType]
+	if exists {
+		return informer
+	}
+
+	informer = cache.NewMultiNamespaceInformer(func(namespace string) cache.SharedIndexInformer {
+		return newInformer(f.factoryFor(namespace))
+	}, sets.List(f.namespaces)...)
+	f.informers[informerType] = informer
+	return informer
+}
+
+func (f *multiNamespaceInformerFactory) AddNamespace(namespace string) error {
+	f.namespaceLock.Lock()
+	defer f.namespaceLock.Unlock()
+
+	informers := func() []cache.MultiNamespaceInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		f.namespaces.Insert(namespace)
+		return f.informersLocked()
+	}()
+
+	var errs []error
+	for _, informer := range informers {
+		errs = append(errs, informer.AddNamespace(namespace))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (f *multiNamespaceInformerFactory) RemoveNamespace(namespace string) error {
+	f.namespaceLock.Lock()
+	defer f.namespaceLock.Unlock()
+
+	informers := func() []cache.MultiNamespaceInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		f.namespaces.Delete(namespace)
+		return f.informersLocked()
+	}()
+
+	// The informers of the namespace are stopped by the informers which run
+	// them, so the factory must not hand them out again.
+	func() {
+		f.factoriesLock.Lock()
+		defer f.factoriesLock.Unlock()
+		delete(f.factories, namespace)
+	}()
+
+	var errs []error
+	for _, informer := range informers {
+		errs = append(errs, informer.RemoveNamespace(namespace))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (f *multiNamespaceInformerFactory) informersLocked() []cache.MultiNamespaceInformer {
+	informers := make([]cache.MultiNamespaceInformer, 0, len(f.informers))
+	for _, informer := range f.informers {
+		informers = append(informers, informer)
+	}
+	return informers
+}
+
+func (f *multiNamespaceInformerFactory) Namespaces() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return sets.List(f.namespaces)
+}
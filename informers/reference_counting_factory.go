@@ -0,0 +1,349 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers/admissionregistration"
+	"k8s.io/client-go/informers/apiserverinternal"
+	"k8s.io/client-go/informers/apps"
+	"k8s.io/client-go/informers/autoscaling"
+	"k8s.io/client-go/informers/batch"
+	"k8s.io/client-go/informers/certificates"
+	"k8s.io/client-go/informers/coordination"
+	"k8s.io/client-go/informers/core"
+	"k8s.io/client-go/informers/discovery"
+	"k8s.io/client-go/informers/events"
+	"k8s.io/client-go/informers/extensions"
+	"k8s.io/client-go/informers/flowcontrol"
+	"k8s.io/client-go/informers/internalinterfaces"
+	"k8s.io/client-go/informers/networking"
+	"k8s.io/client-go/informers/node"
+	"k8s.io/client-go/informers/policy"
+	"k8s.io/client-go/informers/rbac"
+	"k8s.io/client-go/informers/resource"
+	"k8s.io/client-go/informers/scheduling"
+	"k8s.io/client-go/informers/storage"
+	"k8s.io/client-go/informers/storagemigration"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerStopper is implemented by informer factories which can stop
+// individual informers, like the one returned by
+// NewReferenceCountingSharedInformerFactory. It is not part of
+// SharedInformerFactory, so callers have to check for it with a type
+// assertion.
+type InformerStopper interface {
+	// StopInformer stops the informer for the given resource and removes it, including
+	// its cache, from the factory. Requesting the informer again creates a new one which
+	// gets started by the next call to Start. It does nothing if the informer was never
+	// requested or the resource is not known to the factory.
+	StopInformer(resource schema.GroupVersionResource)
+}
+
+// NewReferenceCountingSharedInformerFactory constructs a SharedInformerFactory which
+// stops an informer and drops it, including its cache, once the last event handler
+// has been removed from it with RemoveEventHandler. A later request for the same
+// informer creates a new one which gets started by the next call to Start. The
+// factory also implements InformerStopper.
+func NewReferenceCountingSharedInformerFactory(client kubernetes.Interface, defaultResync time.Duration, options ...SharedInformerOption) SharedInformerFactory {
+	return &referenceCountingInformerFactory{
+		resources:        NewSharedInformerFactoryWithOptions(client, defaultResync, options...).(*sharedInformerFactory),
+		informers:        make(map[reflect.Type]cache.SharedIndexInformer),
+		startedInformers: make(map[reflect.Type]bool),
+		stopInformers:    make(map[reflect.Type]context.CancelFunc),
+		informerTypes:    make(map[schema.GroupVersionResource]reflect.Type),
+	}
+}
+
+type referenceCountingInformerFactory struct {
+	// resources holds the options of the factory. It is never started and
+	// only used to create the informers for ForResource.
+	resources *sharedInformerFactory
+
+	lock      sync.Mutex
+	informers map[reflect.Type]cache.SharedIndexInformer
+	// startedInformers is used for tracking which informers have been started.
+	// This allows Start() to be called multiple times safely.
+	startedInformers map[reflect.Type]bool
+	// stopInformers holds the functions which stop the started informers.
+	stopInformers map[reflect.Type]context.CancelFunc
+	// informerTypes is the index of the informer types of the resources
+	// which have been looked up.
+	informerTypes map[schema.GroupVersionResource]reflect.Type
+	// wg tracks how many goroutines were started.
+	wg sync.WaitGroup
+	// shuttingDown is true when Shutdown has been called. It may still be running
+	// because it needs to wait for goroutines.
+	shuttingDown bool
+}
+
+var _ SharedInformerFactory = &referenceCountingInformerFactory{}
+var _ InformerStopper = &referenceCountingInformerFactory{}
+
+func (f *referenceCountingInformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.shuttingDown {
+		return
+	}
+
+	for informerType, informer := range f.informers {
+		if !f.startedInformers[informerType] {
+			f.wg.Add(1)
+			// We need a new variable in each loop iteration,
+			// otherwise the goroutine would use the loop variable
+			// and that keeps changing.
+			informer := informer
+			ctx, cancel := context.WithCancel(wait.ContextForChannel(stopCh))
+			go func() {
+				defer f.wg.Done()
+				informer.RunWithContext(ctx)
+			}()
+			f.startedInformers[informerType] = true
+			f.stopInformers[informerType] = cancel
+		}
+	}
+}
+
+func (f *referenceCountingInformerFactory) Shutdown() {
+	f.lock.Lock()
+	f.shuttingDown = true
+	f.lock.Unlock()
+
+	// Will return immediately if there is nothing to wait for.
+	f.wg.Wait()
+}
+
+func (f *referenceCountingInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	informers := func() map[reflect.Type]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[reflect.Type]cache.SharedIndexInformer{}
+		for informerType, informer := range f.informers {
+			if f.startedInformers[informerType] {
+				informers[informerType] = informer
+			}
+		}
+		return informers
+	}()
+
+	res := map[reflect.Type]bool{}
+	for informType, informer := range informers {
+		res[informType] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+func (f *referenceCountingInformerFactory) InformerFor(obj runtime.Object, newFunc internalinterfaces.NewInformerFunc) cache.SharedIndexInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	informerType := reflect.TypeOf(obj)
+	informer, exists := f.informers[informerType]
+	if exists {
+		return informer
+	}
+
+	resyncPeriod, exists := f.resources.customResync[informerType]
+	if !exists {
+		resyncPeriod = f.resources.defaultResync
+	}
+
+	informer = newFunc(f.resources.client, resyncPeriod)
+	informer.SetTransform(f.resources.transform)
+	informer = f.referenceCountedLocked(informerType, informer)
+	f.informers[informerType] = informer
+
+	return informer
+}
+
+// referenceCountedLocked wraps informer such that it gets stopped once its last
+// event handler is removed.
+func (f *referenceCountingInformerFactory) referenceCountedLocked(informerType reflect.Type, informer cache.SharedIndexInformer) cache.SharedIndexInformer {
+	var wrapped cache.SharedIndexInformer
+	wrapped = cache.NewReferenceCountedInformer(informer, func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		// The informer may already have been replaced after an explicit StopInformer.
+		if f.informers[informerType] == wrapped {
+			f.stopInformerLocked(informerType)
+		}
+	})
+	return wrapped
+}
+
+func (f *referenceCountingInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if informerType, exists := f.informerTypes[resource]; exists {
+		if informer, exists := f.informers[informerType]; exists {
+			return &genericInformer{resource: resource.GroupResource(), informer: informer}, nil
+		}
+	}
+
+	informerType, informer, err := f.lookupLocked(resource)
+	if err != nil {
+		return nil, err
+	}
+	if existing, exists := f.informers[informerType]; exists {
+		informer = existing
+	} else {
+		informer = f.referenceCountedLocked(informerType, informer)
+		f.informers[informerType] = informer
+	}
+	return &genericInformer{resource: resource.GroupResource(), informer: informer}, nil
+}
+
+// lookupLocked creates an informer for the resource with the generated ForResource
+// of f.resources and records its type in the index. The informer is removed from
+// f.resources again, so that the next lookup creates a new one.
+func (f *referenceCountingInformerFactory) lookupLocked(resource schema.GroupVersionResource) (reflect.Type, cache.SharedIndexInformer, error) {
+	generic, err := f.resources.ForResource(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	informer := generic.Informer()
+
+	f.resources.lock.Lock()
+	defer f.resources.lock.Unlock()
+
+	for informerType, candidate := range f.resources.informers {
+		if candidate == informer {
+			delete(f.resources.informers, informerType)
+			f.informerTypes[resource] = informerType
+			return informerType, informer, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no informer type found for resource %v", resource)
+}
+
+func (f *referenceCountingInformerFactory) StopInformer(resource schema.GroupVersionResource) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	informerType, exists := f.informerTypes[resource]
+	if !exists {
+		var err error
+		if informerType, _, err = f.lookupLocked(resource); err != nil {
+			return
+		}
+	}
+	f.stopInformerLocked(informerType)
+}
+
+func (f *referenceCountingInformerFactory) stopInformerLocked(informerType reflect.Type) {
+	if stop, ok := f.stopInformers[informerType]; ok {
+		stop()
+	}
+	delete(f.informers, informerType)
+	delete(f.startedInformers, informerType)
+	delete(f.stopInformers, informerType)
+}
+
+func (f *referenceCountingInformerFactory) Admissionregistration() admissionregistration.Interface {
+	return admissionregistration.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Internal() apiserverinternal.Interface {
+	return apiserverinternal.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Apps() apps.Interface {
+	return apps.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Autoscaling() autoscaling.Interface {
+	return autoscaling.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Batch() batch.Interface {
+	return batch.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Certificates() certificates.Interface {
+	return certificates.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Coordination() coordination.Interface {
+	return coordination.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Core() core.Interface {
+	return core.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Discovery() discovery.Interface {
+	return discovery.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Events() events.Interface {
+	return events.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Extensions() extensions.Interface {
+	return extensions.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Flowcontrol() flowcontrol.Interface {
+	return flowcontrol.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Networking() networking.Interface {
+	return networking.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Node() node.Interface {
+	return node.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Policy() policy.Interface {
+	return policy.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Rbac() rbac.Interface {
+	return rbac.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Resource() resource.Interface {
+	return resource.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Scheduling() scheduling.Interface {
+	return scheduling.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Storage() storage.Interface {
+	return storage.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
+
+func (f *referenceCountingInformerFactory) Storagemigration() storagemigration.Interface {
+	return storagemigration.New(f, f.resources.namespace, f.resources.tweakListOptions)
+}
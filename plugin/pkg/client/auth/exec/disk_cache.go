@@ -0,0 +1,174 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+
+	"k8s.io/client-go/util/homedir"
+	"k8s.io/klog/v2"
+)
+
+// credentialCacheDir is the directory of the disk cache of the credentials
+// of exec plugins with CacheCredentials set.
+var credentialCacheDir = filepath.Join(homedir.HomeDir(), ".kube", "cache", "exec-credentials")
+
+// diskCache caches the output of an exec plugin in a file which is only
+// accessible by the user, so that processes with the same exec config and
+// cluster share the credentials until they expire. A lock file serializes the
+// processes, so that only one of them executes the plugin when the
+// credentials expired.
+type diskCache struct {
+	path string
+}
+
+// newDiskCache returns the disk cache in dir for the exec config and cluster
+// identified by key. The key is hashed, it contains the env of the plugin.
+func newDiskCache(dir, key string) *diskCache {
+	sum := sha256.Sum256([]byte(key))
+	return &diskCache{path: filepath.Join(dir, hex.EncodeToString(sum[:]))}
+}
+
+// lock creates the cache directory if needed and locks the cache entry
+// against other processes. The returned function unlocks it.
+func (d *diskCache) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil {
+		return nil, fmt.Errorf("creating credential cache directory: %w", err)
+	}
+	f, err := os.OpenFile(d.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening credential cache lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("locking credential cache: %w", err)
+	}
+	return func() {
+		if err := unlockFile(f); err != nil {
+			klog.V(2).Infof("unlocking credential cache: %v", err)
+		}
+		_ = f.Close()
+	}, nil
+}
+
+// read returns the cached output of the plugin, or nil if there is none. An
+// entry which other users can access is an error, it may have been tampered
+// with.
+func (d *diskCache) read() ([]byte, error) {
+	info, err := os.Lstat(d.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("credential cache %s is not a regular file", d.path)
+	}
+	// Windows doesn't have Unix permission bits, the files are protected by
+	// the ACLs of the user's home directory.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("credential cache %s is accessible by other users, mode %v", d.path, info.Mode().Perm())
+	}
+	return os.ReadFile(d.path)
+}
+
+// write replaces the cache entry with data. The entry is written to a
+// temporary file first, so that processes which don't lock the entry never
+// read a partial one.
+func (d *diskCache) write(data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(d.path), filepath.Base(d.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// CreateTemp creates the file with mode 0600
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// remove removes the cache entry, if there is one.
+func (d *diskCache) remove() error {
+	if err := os.Remove(d.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// refreshCredsWithDiskCacheLocked reads the credentials from the disk cache,
+// and executes the plugin only if they expired or were rejected. It must be
+// called while holding the Authenticator's mutex.
+//
+// Errors of the disk cache aren't fatal, the plugin is executed instead.
+func (a *Authenticator) refreshCredsWithDiskCacheLocked() error {
+	unlock, err := a.diskCache.lock()
+	if err != nil {
+		klog.V(2).Infof("exec plugin: not using the credential cache: %v", err)
+		_, _, err := a.execCredsLocked()
+		return err
+	}
+	defer unlock()
+
+	data, err := a.diskCache.read()
+	if err != nil {
+		klog.V(2).Infof("exec plugin: ignoring the credential cache: %v", err)
+	} else if data != nil {
+		creds, exp, err := a.decodeCreds(data)
+		switch {
+		case err != nil:
+			klog.V(2).Infof("exec plugin: ignoring the credential cache: %v", err)
+		case exp.IsZero() || a.now().After(exp):
+			// expired, or written by something else than this cache
+		case a.cachedCreds != nil && reflect.DeepEqual(creds, a.cachedCreds):
+			// the credentials were rejected, e.g. with a 401 response
+		default:
+			a.setCredsLocked(creds, exp)
+			return nil
+		}
+	}
+
+	data, exp, err := a.execCredsLocked()
+	if err != nil {
+		return err
+	}
+
+	// credentials which don't expire are never refreshed by other processes,
+	// so they aren't cached
+	if exp.IsZero() {
+		err = a.diskCache.remove()
+	} else {
+		err = a.diskCache.write(data)
+	}
+	if err != nil {
+		klog.V(2).Infof("exec plugin: updating the credential cache: %v", err)
+	}
+	return nil
+}
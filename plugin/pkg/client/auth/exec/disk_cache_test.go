@@ -0,0 +1,248 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/pkg/apis/clientauthentication"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// newDiskCacheAuthenticator returns an authenticator which caches the output
+// of the test plugin in dir. The plugin fails if output is empty. Each
+// authenticator stands for another process sharing the cache.
+func newDiskCacheAuthenticator(t *testing.T, dir string, now time.Time, output string) *Authenticator {
+	t.Helper()
+	return newDiskCacheAuthenticatorForCluster(t, dir, &clientauthentication.Cluster{Server: "https://cluster.example.com"}, now, output)
+}
+
+// newDiskCacheAuthenticatorForCluster is like newDiskCacheAuthenticator for
+// the given cluster, which is not passed to the plugin.
+func newDiskCacheAuthenticatorForCluster(t *testing.T, dir string, cluster *clientauthentication.Cluster, now time.Time, output string) *Authenticator {
+	t.Helper()
+	a, err := newAuthenticator(newCache(), func(_ int) bool { return false }, &api.ExecConfig{
+		Command:          "./testdata/test-plugin.sh",
+		APIVersion:       "client.authentication.k8s.io/v1",
+		InteractiveMode:  api.NeverExecInteractiveMode,
+		CacheCredentials: true,
+	}, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.diskCache == nil {
+		t.Fatal("expected the disk cache to be enabled")
+	}
+	a.diskCache.path = filepath.Join(dir, filepath.Base(a.diskCache.path))
+	a.environ = func() []string {
+		if output == "" {
+			return []string{"TEST_EXIT_CODE=1"}
+		}
+		return []string{"TEST_OUTPUT=" + output}
+	}
+	a.now = func() time.Time { return now }
+	a.stderr = io.Discard
+	return a
+}
+
+func tokenOutput(token string, exp time.Time) string {
+	if exp.IsZero() {
+		return fmt.Sprintf(`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","status":{"token":%q}}`, token)
+	}
+	return fmt.Sprintf(`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","status":{"token":%q,"expirationTimestamp":%q}}`,
+		token, exp.UTC().Format(time.RFC3339))
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	a := newDiskCacheAuthenticator(t, dir, now, tokenOutput("token1", now.Add(time.Hour)))
+	creds, err := a.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token1" {
+		t.Errorf("got token %q, want token1", creds.token)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(a.diskCache.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got cache entry mode %v, want 0600", info.Mode().Perm())
+		}
+	}
+
+	// another process reads the credentials from the disk without executing
+	// the plugin, which would fail
+	b := newDiskCacheAuthenticator(t, dir, now.Add(30*time.Minute), "")
+	creds, err = b.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token1" {
+		t.Errorf("got token %q, want token1", creds.token)
+	}
+	if !b.exp.Equal(now.Add(time.Hour)) {
+		t.Errorf("got expiry %v, want %v", b.exp, now.Add(time.Hour))
+	}
+
+	// once they expire, the plugin is executed and its output cached again
+	c := newDiskCacheAuthenticator(t, dir, now.Add(2*time.Hour), tokenOutput("token2", now.Add(3*time.Hour)))
+	creds, err = c.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token2" {
+		t.Errorf("got token %q, want token2", creds.token)
+	}
+	d := newDiskCacheAuthenticator(t, dir, now.Add(2*time.Hour), "")
+	creds, err = d.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token2" {
+		t.Errorf("got token %q, want token2", creds.token)
+	}
+}
+
+func TestDiskCacheRejectedCredentials(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	a := newDiskCacheAuthenticator(t, dir, now, tokenOutput("token1", now.Add(time.Hour)))
+	if _, err := a.getCreds(); err != nil {
+		t.Fatal(err)
+	}
+
+	// after a 401 response, the cached credentials are skipped
+	b := newDiskCacheAuthenticator(t, dir, now, tokenOutput("token2", now.Add(time.Hour)))
+	creds, err := b.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token1" {
+		t.Errorf("got token %q, want token1", creds.token)
+	}
+	if err := b.maybeRefreshCreds(creds); err != nil {
+		t.Fatal(err)
+	}
+	if b.cachedCreds.token != "token2" {
+		t.Errorf("got token %q, want token2", b.cachedCreds.token)
+	}
+
+	// and the rotated ones are shared
+	c := newDiskCacheAuthenticator(t, dir, now, "")
+	creds, err = c.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token2" {
+		t.Errorf("got token %q, want token2", creds.token)
+	}
+}
+
+func TestDiskCacheWithoutExpiry(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	a := newDiskCacheAuthenticator(t, dir, now, tokenOutput("token1", time.Time{}))
+	if _, err := a.getCreds(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(a.diskCache.path); !os.IsNotExist(err) {
+		t.Errorf("expected credentials without expiry not to be cached, got %v", err)
+	}
+}
+
+func TestDiskCacheInsecureEntry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't checked on windows")
+	}
+	dir := t.TempDir()
+	now := time.Now()
+
+	a := newDiskCacheAuthenticator(t, dir, now, tokenOutput("token2", now.Add(time.Hour)))
+	if err := os.WriteFile(a.diskCache.path, []byte(tokenOutput("token1", now.Add(time.Hour))), 0644); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := a.getCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.token != "token2" {
+		t.Errorf("got token %q, want token2 from the plugin", creds.token)
+	}
+	info, err := os.Stat(a.diskCache.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("got cache entry mode %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestDiskCacheClusters(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	cluster1 := &clientauthentication.Cluster{Server: "https://cluster1.example.com", CertificateAuthorityData: []byte("ca1")}
+	cluster2 := &clientauthentication.Cluster{Server: "https://cluster2.example.com", CertificateAuthorityData: []byte("ca2")}
+	otherCA := &clientauthentication.Cluster{Server: "https://cluster1.example.com", CertificateAuthorityData: []byte("ca2")}
+
+	a := newDiskCacheAuthenticatorForCluster(t, dir, cluster1, now, tokenOutput("token1", now.Add(time.Hour)))
+	if _, err := a.getCreds(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the plugin doesn't get the cluster, but the credentials of other
+	// clusters are cached apart all the same
+	for _, cluster := range []*clientauthentication.Cluster{cluster2, otherCA} {
+		b := newDiskCacheAuthenticatorForCluster(t, dir, cluster, now, tokenOutput("token2", now.Add(time.Hour)))
+		if b.diskCache.path == a.diskCache.path {
+			t.Errorf("cluster %s with CA %q shares the credential cache of cluster %s with CA %q",
+				cluster.Server, cluster.CertificateAuthorityData, cluster1.Server, cluster1.CertificateAuthorityData)
+		}
+		creds, err := b.getCreds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.token != "token2" {
+			t.Errorf("got token %q, want token2", creds.token)
+		}
+	}
+
+	// without the cluster, the credentials aren't cached on disk
+	c, err := newAuthenticator(newCache(), func(_ int) bool { return false }, &api.ExecConfig{
+		Command:          "./testdata/test-plugin.sh",
+		APIVersion:       "client.authentication.k8s.io/v1",
+		CacheCredentials: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.diskCache != nil {
+		t.Error("expected the disk cache to be disabled without the cluster")
+	}
+}
@@ -156,6 +156,9 @@ func (s *sometimes) Do(f func()) {
 }
 
 // GetAuthenticator returns an exec-based plugin for providing client credentials.
+// cluster is passed to the plugin if config.ProvideClusterInfo is set. If
+// config.CacheCredentials is set, it also identifies the credentials cached on
+// disk, which are not cached without it.
 func GetAuthenticator(config *api.ExecConfig, cluster *clientauthentication.Cluster) (*Authenticator, error) {
 	return newAuthenticator(globalCache, term.IsTerminal, config, cluster)
 }
@@ -204,6 +207,15 @@ func newAuthenticator(c *cache, isTerminalFunc func(int) bool, config *api.ExecC
 		a.env = append(a.env, env.Name+"="+env.Value)
 	}
 
+	if config.CacheCredentials {
+		if cluster != nil {
+			a.diskCache = newDiskCache(credentialCacheDir, key)
+		} else {
+			// The credentials of different clusters could not be told apart.
+			klog.V(2).Infof("exec plugin: not caching the credentials of %s without the cluster", config.Command)
+		}
+	}
+
 	// these functions are made comparable and stored in the cache so that repeated clientset
 	// construction with the same rest.Config results in a single TLS cache and Authenticator
 	a.getCert = &transport.GetCertHolder{GetCert: a.cert}
@@ -266,6 +278,10 @@ type Authenticator struct {
 	// connTracker tracks all connections opened that we need to close when rotating a client certificate
 	connTracker *connrotation.ConnectionTracker
 
+	// diskCache shares the credentials with other processes, if the config
+	// enables it.
+	diskCache *diskCache
+
 	// Cached results.
 	//
 	// The mutex also guards calling the plugin. Since the plugin could be
@@ -409,11 +425,38 @@ func (a *Authenticator) maybeRefreshCreds(creds *credentials) error {
 }
 
 // refreshCredsLocked executes the plugin and reads the credentials from
-// stdout. It must be called while holding the Authenticator's mutex.
+// stdout, or from the disk cache if another process executed it already. It
+// must be called while holding the Authenticator's mutex.
 func (a *Authenticator) refreshCredsLocked() error {
+	if a.diskCache != nil {
+		return a.refreshCredsWithDiskCacheLocked()
+	}
+	_, _, err := a.execCredsLocked()
+	return err
+}
+
+// execCredsLocked executes the plugin and caches the credentials it returned.
+// It returns the output of the plugin and the expiry of the credentials. It
+// must be called while holding the Authenticator's mutex.
+func (a *Authenticator) execCredsLocked() ([]byte, time.Time, error) {
+	data, err := a.execPluginLocked()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	creds, exp, err := a.decodeCreds(data)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	a.setCredsLocked(creds, exp)
+	return data, exp, nil
+}
+
+// execPluginLocked executes the plugin and returns its stdout. It must be
+// called while holding the Authenticator's mutex.
+func (a *Authenticator) execPluginLocked() ([]byte, error) {
 	interactive, err := a.interactiveFunc()
 	if err != nil {
-		return fmt.Errorf("exec plugin cannot support interactive mode: %w", err)
+		return nil, fmt.Errorf("exec plugin cannot support interactive mode: %w", err)
 	}
 
 	cred := &clientauthentication.ExecCredential{
@@ -428,7 +471,7 @@ func (a *Authenticator) refreshCredsLocked() error {
 	env := append(a.environ(), a.env...)
 	data, err := runtime.Encode(codecs.LegacyCodec(a.group), cred)
 	if err != nil {
-		return fmt.Errorf("encode ExecCredentials: %v", err)
+		return nil, fmt.Errorf("encode ExecCredentials: %v", err)
 	}
 	env = append(env, fmt.Sprintf("%s=%s", execInfoEnv, data))
 
@@ -444,32 +487,37 @@ func (a *Authenticator) refreshCredsLocked() error {
 	err = cmd.Run()
 	incrementCallsMetric(err)
 	if err != nil {
-		return a.wrapCmdRunErrorLocked(err)
+		return nil, a.wrapCmdRunErrorLocked(err)
 	}
+	return stdout.Bytes(), nil
+}
 
-	_, gvk, err := codecs.UniversalDecoder(a.group).Decode(stdout.Bytes(), nil, cred)
+// decodeCreds decodes the credentials and their expiry from the output of
+// the plugin. The expiry is zero if the credentials don't expire.
+func (a *Authenticator) decodeCreds(data []byte) (*credentials, time.Time, error) {
+	cred := &clientauthentication.ExecCredential{}
+	_, gvk, err := codecs.UniversalDecoder(a.group).Decode(data, nil, cred)
 	if err != nil {
-		return fmt.Errorf("decoding stdout: %v", err)
+		return nil, time.Time{}, fmt.Errorf("decoding stdout: %v", err)
 	}
 	if gvk.Group != a.group.Group || gvk.Version != a.group.Version {
-		return fmt.Errorf("exec plugin is configured to use API version %s, plugin returned version %s",
+		return nil, time.Time{}, fmt.Errorf("exec plugin is configured to use API version %s, plugin returned version %s",
 			a.group, schema.GroupVersion{Group: gvk.Group, Version: gvk.Version})
 	}
 
 	if cred.Status == nil {
-		return fmt.Errorf("exec plugin didn't return a status field")
+		return nil, time.Time{}, fmt.Errorf("exec plugin didn't return a status field")
 	}
 	if cred.Status.Token == "" && cred.Status.ClientCertificateData == "" && cred.Status.ClientKeyData == "" {
-		return fmt.Errorf("exec plugin didn't return a token or cert/key pair")
+		return nil, time.Time{}, fmt.Errorf("exec plugin didn't return a token or cert/key pair")
 	}
 	if (cred.Status.ClientCertificateData == "") != (cred.Status.ClientKeyData == "") {
-		return fmt.Errorf("exec plugin returned only certificate or key, not both")
+		return nil, time.Time{}, fmt.Errorf("exec plugin returned only certificate or key, not both")
 	}
 
+	var exp time.Time
 	if cred.Status.ExpirationTimestamp != nil {
-		a.exp = cred.Status.ExpirationTimestamp.Time
-	} else {
-		a.exp = time.Time{}
+		exp = cred.Status.ExpirationTimestamp.Time
 	}
 
 	newCreds := &credentials{
@@ -478,7 +526,7 @@ func (a *Authenticator) refreshCredsLocked() error {
 	if cred.Status.ClientKeyData != "" && cred.Status.ClientCertificateData != "" {
 		cert, err := tls.X509KeyPair([]byte(cred.Status.ClientCertificateData), []byte(cred.Status.ClientKeyData))
 		if err != nil {
-			return fmt.Errorf("failed parsing client key/certificate: %v", err)
+			return nil, time.Time{}, fmt.Errorf("failed parsing client key/certificate: %v", err)
 		}
 
 		// Leaf is initialized to be nil:
@@ -489,11 +537,17 @@ func (a *Authenticator) refreshCredsLocked() error {
 		// certificate values.
 		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
 		if err != nil {
-			return fmt.Errorf("failed parsing client leaf certificate: %v", err)
+			return nil, time.Time{}, fmt.Errorf("failed parsing client leaf certificate: %v", err)
 		}
 		newCreds.cert = &cert
 	}
+	return newCreds, exp, nil
+}
 
+// setCredsLocked makes newCreds the cached credentials. It must be called
+// while holding the Authenticator's mutex.
+func (a *Authenticator) setCredsLocked(newCreds *credentials, exp time.Time) {
+	a.exp = exp
 	oldCreds := a.cachedCreds
 	a.cachedCreds = newCreds
 	// Only close all connections when TLS cert rotates. Token rotation doesn't
@@ -511,7 +565,6 @@ func (a *Authenticator) refreshCredsLocked() error {
 		expiry = a.cachedCreds.cert.Leaf.NotAfter
 	}
 	expirationMetrics.set(a, expiry)
-	return nil
 }
 
 // wrapCmdRunErrorLocked pulls out the code to construct a helpful error message
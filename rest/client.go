@@ -103,6 +103,30 @@ type RESTClient struct {
 	// If not set, defaultWarningHandler is used.
 	warningHandler WarningHandlerWithContext
 
+	// tracer is shared among all requests created by this client.
+	// If not set, requests are not traced.
+	tracer RequestTracer
+
+	// retryBudget is shared among all requests created by this client.
+	// If not set, retries are only limited per request.
+	retryBudget *RetryBudget
+
+	// retryPolicy is shared among all requests created by this client.
+	// If not set, only requests with a Retry-After response are retried.
+	retryPolicy *RetryPolicy
+
+	// maxRequestSize and maxResponseSize are the default size limits of
+	// all requests created by this client. Zero means no limit.
+	maxRequestSize  int64
+	maxResponseSize int64
+
+	// responseCache is shared among all requests created by this client.
+	// If not set, responses are not cached.
+	responseCache *ResponseCache
+	// responseCacheIdentity identifies the credentials of the client, so
+	// that clients with different credentials can share responseCache.
+	responseCacheIdentity string
+
 	// Set specific behavior of the client.  If not set http.DefaultClient will be used.
 	Client *http.Client
 }
@@ -58,6 +58,12 @@ type Config struct {
 	// be appended to all request URIs used to access the apiserver. This allows a frontend
 	// proxy to easily relocate all of the apiserver endpoints.
 	Host string
+	// Endpoints lists further addresses of the same apiserver, in the same format
+	// as Host and with the same path. If set, the transport created for this config
+	// sends requests to whichever of Host and Endpoints is healthy and fails over to
+	// the next address when one cannot be reached. Requests stay with one address as
+	// long as it works, so watches are not moved between apiservers needlessly.
+	Endpoints []string
 	// APIPath is a sub-path that points to an API root.
 	APIPath string
 
@@ -159,6 +165,45 @@ type Config struct {
 	// socks5 proxying does not currently support spdy streaming endpoints.
 	Proxy func(*http.Request) (*url.URL, error)
 
+	// Tracer, if set, creates a span for each attempt of a request sent by
+	// a RESTClient and propagates it to the server with the W3C
+	// traceparent header.
+	Tracer RequestTracer
+
+	// RetryBudget, if set, limits the retries and hedged requests of all
+	// requests sent by a RESTClient to a fraction of its requests.
+	RetryBudget *RetryBudget
+
+	// RetryPolicy, if set, configures which failed requests of the clients
+	// built from this config get retried, and with which backoff.
+	RetryPolicy *RetryPolicy
+
+	// MaxRequestSize, if positive, is the maximum size in bytes of the
+	// body of a request. Larger requests fail without being sent. Bodies
+	// passed as an io.Reader are not checked.
+	MaxRequestSize int64
+
+	// MaxResponseSize, if positive, is the maximum size in bytes of a
+	// response body read by Do, DoRaw or StreamList. Larger responses fail
+	// as soon as the limit is exceeded, instead of being read into memory.
+	// Watch and Stream are not limited.
+	MaxResponseSize int64
+
+	// Audit, if set, records an entry for the requests sent by the clients
+	// built from this config, by default for the mutating ones only.
+	Audit *transport.AuditConfig
+
+	// ConnectionsPerHost, if greater than one, spreads the HTTP/2 requests
+	// to a server over up to that many connections, so that busy clients
+	// don't hit the concurrent stream limit of a single connection.
+	ConnectionsPerHost int
+
+	// ResponseCache, if set, caches the responses to the GET requests sent
+	// with Do or DoRaw by the clients built from this config. It is not
+	// copied by AnonymousClientConfig, because the cached responses depend
+	// on the credentials.
+	ResponseCache *ResponseCache
+
 	// Version forces a specific version to be used (if registered)
 	// Do we need this?
 	// Version string
@@ -395,6 +440,15 @@ func RESTClientForConfigAndClient(config *Config, httpClient *http.Client) (*RES
 
 	restClient, err := NewRESTClient(baseURL, versionedAPIPath, clientContent, rateLimiter, httpClient)
 	maybeSetWarningHandler(restClient, config.WarningHandler, config.WarningHandlerWithContext)
+	if restClient != nil {
+		restClient.tracer = config.Tracer
+		restClient.retryBudget = config.RetryBudget
+		restClient.retryPolicy = config.RetryPolicy
+		restClient.maxRequestSize = config.MaxRequestSize
+		restClient.maxResponseSize = config.MaxResponseSize
+		restClient.responseCache = config.ResponseCache
+		restClient.responseCacheIdentity = responseCacheIdentity(config)
+	}
 	return restClient, err
 }
 
@@ -477,6 +531,15 @@ func UnversionedRESTClientForConfigAndClient(config *Config, httpClient *http.Cl
 
 	restClient, err := NewRESTClient(baseURL, versionedAPIPath, clientContent, rateLimiter, httpClient)
 	maybeSetWarningHandler(restClient, config.WarningHandler, config.WarningHandlerWithContext)
+	if restClient != nil {
+		restClient.tracer = config.Tracer
+		restClient.retryBudget = config.RetryBudget
+		restClient.retryPolicy = config.RetryPolicy
+		restClient.maxRequestSize = config.MaxRequestSize
+		restClient.maxResponseSize = config.MaxResponseSize
+		restClient.responseCache = config.ResponseCache
+		restClient.responseCacheIdentity = responseCacheIdentity(config)
+	}
 	return restClient, err
 }
 
@@ -634,6 +697,7 @@ func AnonymousClientConfig(config *Config) *Config {
 	// copy only known safe fields
 	return &Config{
 		Host:          config.Host,
+		Endpoints:     config.Endpoints,
 		APIPath:       config.APIPath,
 		ContentConfig: config.ContentConfig,
 		TLSClientConfig: TLSClientConfig{
@@ -653,6 +717,13 @@ func AnonymousClientConfig(config *Config) *Config {
 		Timeout:                   config.Timeout,
 		Dial:                      config.Dial,
 		Proxy:                     config.Proxy,
+		Tracer:                    config.Tracer,
+		RetryBudget:               config.RetryBudget,
+		RetryPolicy:               config.RetryPolicy,
+		MaxRequestSize:            config.MaxRequestSize,
+		MaxResponseSize:           config.MaxResponseSize,
+		Audit:                     config.Audit,
+		ConnectionsPerHost:        config.ConnectionsPerHost,
 	}
 }
 
@@ -660,6 +731,7 @@ func AnonymousClientConfig(config *Config) *Config {
 func CopyConfig(config *Config) *Config {
 	c := &Config{
 		Host:            config.Host,
+		Endpoints:       config.Endpoints,
 		APIPath:         config.APIPath,
 		ContentConfig:   config.ContentConfig,
 		Username:        config.Username,
@@ -698,6 +770,14 @@ func CopyConfig(config *Config) *Config {
 		Timeout:                   config.Timeout,
 		Dial:                      config.Dial,
 		Proxy:                     config.Proxy,
+		Tracer:                    config.Tracer,
+		RetryBudget:               config.RetryBudget,
+		RetryPolicy:               config.RetryPolicy,
+		MaxRequestSize:            config.MaxRequestSize,
+		MaxResponseSize:           config.MaxResponseSize,
+		Audit:                     config.Audit,
+		ConnectionsPerHost:        config.ConnectionsPerHost,
+		ResponseCache:             config.ResponseCache,
 	}
 	if config.ExecProvider != nil && config.ExecProvider.Config != nil {
 		c.ExecProvider.Config = config.ExecProvider.Config.DeepCopyObject()
@@ -304,6 +304,16 @@ func fakeProxyFunc(*http.Request) (*url.URL, error) {
 	return nil, errors.New("fakeproxy")
 }
 
+type fakeAuditSink struct{}
+
+func (fakeAuditSink) Audit(context.Context, *transport.AuditEntry) {}
+
+type fakeRequestTracer struct{}
+
+func (fakeRequestTracer) StartAttempt(ctx context.Context, attempt RequestAttempt) (context.Context, RequestSpan) {
+	return ctx, noopRequestSpan{}
+}
+
 type fakeAuthProviderConfigPersister struct{}
 
 func (fakeAuthProviderConfigPersister) Persist(map[string]string) error {
@@ -347,6 +357,18 @@ func TestAnonymousAuthConfig(t *testing.T) {
 		func(h *WarningHandlerWithContext, f randfill.Continue) {
 			*h = &fakeWarningHandlerWithContext{}
 		},
+		func(r *RequestTracer, f randfill.Continue) {
+			*r = &fakeRequestTracer{}
+		},
+		func(r **RetryBudget, f randfill.Continue) {
+			*r = NewRetryBudget(0.1, 10)
+		},
+		func(r **ResponseCache, f randfill.Continue) {
+			*r = NewResponseCache(10, time.Minute)
+		},
+		func(r *transport.AuditSink, f randfill.Continue) {
+			*r = &fakeAuditSink{}
+		},
 		// Authentication does not require fuzzer
 		func(r *AuthProviderConfigPersister, f randfill.Continue) {},
 		func(r *clientcmdapi.AuthProviderConfig, f randfill.Continue) {
@@ -386,6 +408,7 @@ func TestAnonymousAuthConfig(t *testing.T) {
 		expected.TLSClientConfig.KeyFile = ""
 		expected.Transport = nil
 		expected.WrapTransport = nil
+		expected.ResponseCache = nil
 
 		if actual.Dial != nil {
 			_, actualError := actual.Dial(context.Background(), "", "")
@@ -407,6 +430,13 @@ func TestAnonymousAuthConfig(t *testing.T) {
 		actual.Proxy = nil
 		expected.Proxy = nil
 
+		// The retry budget is shared, not copied.
+		if actual.RetryBudget != expected.RetryBudget {
+			t.Fatalf("AnonymousClientConfig dropped the RetryBudget field")
+		}
+		actual.RetryBudget = nil
+		expected.RetryBudget = nil
+
 		if diff := cmp.Diff(*actual, expected); diff != "" {
 			t.Fatalf("AnonymousClientConfig dropped unexpected fields, identify whether they are security related or not (-got, +want): %s", diff)
 		}
@@ -448,6 +478,18 @@ func TestCopyConfig(t *testing.T) {
 		func(h *WarningHandlerWithContext, f randfill.Continue) {
 			*h = &fakeWarningHandlerWithContext{}
 		},
+		func(r *RequestTracer, f randfill.Continue) {
+			*r = &fakeRequestTracer{}
+		},
+		func(r **RetryBudget, f randfill.Continue) {
+			*r = NewRetryBudget(0.1, 10)
+		},
+		func(r **ResponseCache, f randfill.Continue) {
+			*r = NewResponseCache(10, time.Minute)
+		},
+		func(r *transport.AuditSink, f randfill.Continue) {
+			*r = &fakeAuditSink{}
+		},
 		func(r *AuthProviderConfigPersister, f randfill.Continue) {
 			*r = fakeAuthProviderConfigPersister{}
 		},
@@ -510,6 +552,20 @@ func TestCopyConfig(t *testing.T) {
 		actual.Proxy = nil
 		expected.Proxy = nil
 
+		// The retry budget is shared, not copied.
+		if actual.RetryBudget != expected.RetryBudget {
+			t.Fatalf("CopyConfig dropped the RetryBudget field")
+		}
+		actual.RetryBudget = nil
+		expected.RetryBudget = nil
+
+		// The response cache is shared, not copied.
+		if actual.ResponseCache != expected.ResponseCache {
+			t.Fatalf("CopyConfig dropped the ResponseCache field")
+		}
+		actual.ResponseCache = nil
+		expected.ResponseCache = nil
+
 		if diff := cmp.Diff(*actual, expected); diff != "" {
 			t.Fatalf("CopyConfig  dropped unexpected fields, identify whether they are security related or not (-got, +want): %s", diff)
 		}
@@ -652,7 +708,7 @@ func TestConfigSprint(t *testing.T) {
 		Proxy:                     fakeProxyFunc,
 	}
 	want := fmt.Sprintf(
-		`&rest.Config{Host:"localhost:8080", APIPath:"v1", ContentConfig:rest.ContentConfig{AcceptContentTypes:"application/json", ContentType:"application/json", GroupVersion:(*schema.GroupVersion)(nil), NegotiatedSerializer:runtime.NegotiatedSerializer(nil)}, Username:"gopher", Password:"--- REDACTED ---", BearerToken:"--- REDACTED ---", BearerTokenFile:"", Impersonate:rest.ImpersonationConfig{UserName:"gopher2", UID:"uid123", Groups:[]string(nil), Extra:map[string][]string(nil)}, AuthProvider:api.AuthProviderConfig{Name: "gopher", Config: map[string]string{--- REDACTED ---}}, AuthConfigPersister:rest.AuthProviderConfigPersister(--- REDACTED ---), ExecProvider:api.ExecConfig{Command: "sudo", Args: []string{"--- REDACTED ---"}, Env: []ExecEnvVar{--- REDACTED ---}, APIVersion: "", ProvideClusterInfo: true, Config: runtime.Object(--- REDACTED ---), StdinUnavailable: false}, TLSClientConfig:rest.sanitizedTLSClientConfig{Insecure:false, ServerName:"", CertFile:"a.crt", KeyFile:"a.key", CAFile:"", CertData:[]uint8{0x2d, 0x2d, 0x2d, 0x20, 0x54, 0x52, 0x55, 0x4e, 0x43, 0x41, 0x54, 0x45, 0x44, 0x20, 0x2d, 0x2d, 0x2d}, KeyData:[]uint8{0x2d, 0x2d, 0x2d, 0x20, 0x52, 0x45, 0x44, 0x41, 0x43, 0x54, 0x45, 0x44, 0x20, 0x2d, 0x2d, 0x2d}, CAData:[]uint8(nil), NextProtos:[]string{"h2", "http/1.1"}}, UserAgent:"gobot", DisableCompression:false, Transport:(*rest.fakeRoundTripper)(%p), WrapTransport:(transport.WrapperFunc)(%p), QPS:1, Burst:2, RateLimiter:(*rest.fakeLimiter)(%p), WarningHandler:rest.fakeWarningHandler{}, WarningHandlerWithContext:rest.fakeWarningHandlerWithContext{}, Timeout:3000000000, Dial:(func(context.Context, string, string) (net.Conn, error))(%p), Proxy:(func(*http.Request) (*url.URL, error))(%p)}`,
+		`&rest.Config{Host:"localhost:8080", Endpoints:[]string(nil), APIPath:"v1", ContentConfig:rest.ContentConfig{AcceptContentTypes:"application/json", ContentType:"application/json", GroupVersion:(*schema.GroupVersion)(nil), NegotiatedSerializer:runtime.NegotiatedSerializer(nil)}, Username:"gopher", Password:"--- REDACTED ---", BearerToken:"--- REDACTED ---", BearerTokenFile:"", Impersonate:rest.ImpersonationConfig{UserName:"gopher2", UID:"uid123", Groups:[]string(nil), Extra:map[string][]string(nil)}, AuthProvider:api.AuthProviderConfig{Name: "gopher", Config: map[string]string{--- REDACTED ---}}, AuthConfigPersister:rest.AuthProviderConfigPersister(--- REDACTED ---), ExecProvider:api.ExecConfig{Command: "sudo", Args: []string{"--- REDACTED ---"}, Env: []ExecEnvVar{--- REDACTED ---}, APIVersion: "", ProvideClusterInfo: true, Config: runtime.Object(--- REDACTED ---), StdinUnavailable: false}, TLSClientConfig:rest.sanitizedTLSClientConfig{Insecure:false, ServerName:"", CertFile:"a.crt", KeyFile:"a.key", CAFile:"", CertData:[]uint8{0x2d, 0x2d, 0x2d, 0x20, 0x54, 0x52, 0x55, 0x4e, 0x43, 0x41, 0x54, 0x45, 0x44, 0x20, 0x2d, 0x2d, 0x2d}, KeyData:[]uint8{0x2d, 0x2d, 0x2d, 0x20, 0x52, 0x45, 0x44, 0x41, 0x43, 0x54, 0x45, 0x44, 0x20, 0x2d, 0x2d, 0x2d}, CAData:[]uint8(nil), NextProtos:[]string{"h2", "http/1.1"}}, UserAgent:"gobot", DisableCompression:false, Transport:(*rest.fakeRoundTripper)(%p), WrapTransport:(transport.WrapperFunc)(%p), QPS:1, Burst:2, RateLimiter:(*rest.fakeLimiter)(%p), WarningHandler:rest.fakeWarningHandler{}, WarningHandlerWithContext:rest.fakeWarningHandlerWithContext{}, Timeout:3000000000, Dial:(func(context.Context, string, string) (net.Conn, error))(%p), Proxy:(func(*http.Request) (*url.URL, error))(%p), Tracer:rest.RequestTracer(nil), RetryBudget:(*rest.RetryBudget)(nil), RetryPolicy:(*rest.RetryPolicy)(nil), MaxRequestSize:0, MaxResponseSize:0, Audit:(*transport.AuditConfig)(nil), ConnectionsPerHost:0, ResponseCache:(*rest.ResponseCache)(nil)}`,
 		c.Transport, fakeWrapperFunc, c.RateLimiter, fakeDialFunc, fakeProxyFunc,
 	)
 
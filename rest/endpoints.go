@@ -0,0 +1,206 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// endpointBackoffBase and endpointBackoffMax bound for how long an
+	// endpoint is avoided after it failed.
+	endpointBackoffBase = time.Second
+	endpointBackoffMax  = 30 * time.Second
+)
+
+// endpointRoundTripper sends the requests for Config.Host to one of the
+// addresses in Config.Host and Config.Endpoints.
+//
+// Selection is sticky: requests go to the endpoint which sent the most
+// recent response for as long as it is healthy, so that a watch gets
+// resumed against the server which served the preceding list. An endpoint
+// which cannot be reached or which reports being overloaded is backed off
+// in the same way as by URLBackoff. Watches only move away from their
+// endpoint when it cannot be reached at all.
+//
+// Requests which fail because the endpoint cannot be reached are retried
+// right away against the next endpoint, provided that the request body can
+// be sent again.
+type endpointRoundTripper struct {
+	delegate http.RoundTripper
+	// host is the host of Config.Host. Requests for other hosts are
+	// passed through unchanged.
+	host      string
+	endpoints []*url.URL
+	backoff   *URLBackoff
+
+	lock    sync.Mutex
+	current int
+}
+
+var _ utilnet.RoundTripperWrapper = &endpointRoundTripper{}
+
+// wrapEndpoints wraps rt such that it fails over between the endpoints of
+// config. It returns rt unchanged if config has no additional endpoints.
+func wrapEndpoints(config *Config, rt http.RoundTripper) (http.RoundTripper, error) {
+	if len(config.Endpoints) == 0 {
+		return rt, nil
+	}
+	hostURL, _, err := DefaultServerUrlFor(config)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := []*url.URL{hostURL}
+	for _, endpoint := range config.Endpoints {
+		endpointURL, _, err := DefaultServerUrlFor(&Config{Host: endpoint, TLSClientConfig: config.TLSClientConfig})
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+		if endpointURL.Path != hostURL.Path {
+			return nil, fmt.Errorf("endpoint %q must have the same path as host %q", endpoint, config.Host)
+		}
+		endpoints = append(endpoints, endpointURL)
+	}
+	return &endpointRoundTripper{
+		delegate:  rt,
+		host:      hostURL.Host,
+		endpoints: endpoints,
+		backoff:   &URLBackoff{Backoff: flowcontrol.NewBackOff(endpointBackoffBase, endpointBackoffMax)},
+	}, nil
+}
+
+func (rt *endpointRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != rt.host {
+		return rt.delegate.RoundTrip(req)
+	}
+	logger := klog.FromContext(req.Context())
+	watch := req.URL.Query().Get("watch") == "true"
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	tried := make([]bool, len(rt.endpoints))
+	for attempt := 0; ; attempt++ {
+		i := rt.pick(watch, tried)
+		tried[i] = true
+		endpoint := rt.endpoints[i]
+
+		endpointReq := req.Clone(req.Context())
+		endpointReq.URL.Scheme = endpoint.Scheme
+		endpointReq.URL.Host = endpoint.Host
+		if req.Host == req.URL.Host {
+			endpointReq.Host = ""
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			endpointReq.Body = body
+		}
+
+		resp, err := rt.delegate.RoundTrip(endpointReq)
+		if err == nil {
+			rt.responded(i, resp.StatusCode)
+			return resp, nil
+		}
+		if req.Context().Err() != nil || !isEndpointUnreachable(err) {
+			return nil, err
+		}
+		rt.unreachable(i)
+		if !replayable || rt.allTried(tried) {
+			return nil, err
+		}
+		logger.V(2).Info("API server endpoint unreachable, trying the next one", "endpoint", endpoint.Host, "err", err)
+	}
+}
+
+// pick returns the index of the endpoint to try next. At least one of the
+// endpoints must not have been tried yet.
+func (rt *endpointRoundTripper) pick(watch bool, tried []bool) int {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	now := rt.backoff.Backoff.Clock.Now()
+	if !tried[rt.current] && (watch || rt.healthy(rt.current, now)) {
+		return rt.current
+	}
+	// Fall back to the endpoint which becomes healthy first.
+	best := -1
+	for n := 1; n <= len(rt.endpoints); n++ {
+		i := (rt.current + n) % len(rt.endpoints)
+		if tried[i] {
+			continue
+		}
+		if rt.healthy(i, now) {
+			return i
+		}
+		if best == -1 || rt.backoff.CalculateBackoff(rt.endpoints[i]) < rt.backoff.CalculateBackoff(rt.endpoints[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (rt *endpointRoundTripper) healthy(i int, now time.Time) bool {
+	return !rt.backoff.Backoff.IsInBackOffSinceUpdate(rt.backoff.baseUrlKey(rt.endpoints[i]), now)
+}
+
+func (rt *endpointRoundTripper) responded(i int, responseCode int) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	rt.backoff.UpdateBackoff(rt.endpoints[i], nil, responseCode)
+	// An endpoint which fails with a server error must not become the one
+	// which watches stick to.
+	if responseCode < 500 {
+		rt.current = i
+	}
+}
+
+func (rt *endpointRoundTripper) unreachable(i int) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	rt.backoff.Backoff.Next(rt.backoff.baseUrlKey(rt.endpoints[i]), rt.backoff.Backoff.Clock.Now())
+}
+
+func (rt *endpointRoundTripper) allTried(tried []bool) bool {
+	for _, t := range tried {
+		if !t {
+			return false
+		}
+	}
+	return true
+}
+
+func (rt *endpointRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.delegate }
+
+// isEndpointUnreachable returns true for errors which show that a request
+// did not reach the server, so that it is safe to send it elsewhere.
+func isEndpointUnreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return utilnet.IsConnectionRefused(err)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpointServer is an httptest.Server which records the requests it got.
+type endpointServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	requests []string
+	status   int
+}
+
+func newEndpointServer(t *testing.T) *endpointServer {
+	s := &endpointServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.requests = append(s.requests, req.Method+" "+req.URL.RequestURI()+" "+string(body))
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *endpointServer) setStatus(status int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+}
+
+func (s *endpointServer) got() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func doEndpointRequest(t *testing.T, client *http.Client, method, url, body string) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestEndpointFailover(t *testing.T) {
+	live := newEndpointServer(t)
+
+	var lock sync.Mutex
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		lock.Lock()
+		dialed = append(dialed, address)
+		lock.Unlock()
+		if address == "unreachable.example.com:80" {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	}
+
+	client, err := HTTPClientFor(&Config{
+		Host:      "http://unreachable.example.com",
+		Endpoints: []string{live.URL},
+		Dial:      dial,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, doEndpointRequest(t, client, "POST", "http://unreachable.example.com/api/v1/pods", "payload"))
+	assert.Equal(t, http.StatusOK, doEndpointRequest(t, client, "GET", "http://unreachable.example.com/api/v1/pods", ""))
+
+	assert.Equal(t, []string{"POST /api/v1/pods payload", "GET /api/v1/pods "}, live.got())
+	liveAddress := strings.TrimPrefix(live.URL, "http://")
+	lock.Lock()
+	defer lock.Unlock()
+	// The second request goes to the working endpoint right away.
+	assert.Equal(t, []string{"unreachable.example.com:80", liveAddress}, dialed)
+}
+
+func TestEndpointSelection(t *testing.T) {
+	primary := newEndpointServer(t)
+	secondary := newEndpointServer(t)
+
+	client, err := HTTPClientFor(&Config{
+		Host:      primary.URL,
+		Endpoints: []string{secondary.URL},
+	})
+	require.NoError(t, err)
+
+	// An overloaded endpoint is avoided by regular requests, but watches
+	// stay with the endpoint that answered last.
+	primary.setStatus(http.StatusTooManyRequests)
+	assert.Equal(t, http.StatusTooManyRequests, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods", ""))
+	assert.Equal(t, http.StatusTooManyRequests, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods?watch=true", ""))
+	assert.Equal(t, http.StatusOK, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods", ""))
+	// The secondary endpoint is now the sticky one.
+	primary.setStatus(http.StatusOK)
+	assert.Equal(t, http.StatusOK, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods?watch=true", ""))
+
+	assert.Equal(t, []string{"GET /api/v1/pods ", "GET /api/v1/pods?watch=true "}, primary.got())
+	assert.Equal(t, []string{"GET /api/v1/pods ", "GET /api/v1/pods?watch=true "}, secondary.got())
+}
+
+func TestEndpointSelectionServerError(t *testing.T) {
+	primary := newEndpointServer(t)
+	secondary := newEndpointServer(t)
+
+	client, err := HTTPClientFor(&Config{
+		Host:      primary.URL,
+		Endpoints: []string{secondary.URL},
+	})
+	require.NoError(t, err)
+
+	// The secondary endpoint gets the next regular request while the primary
+	// one backs off, but it fails, so watches stay with the primary one.
+	primary.setStatus(http.StatusTooManyRequests)
+	secondary.setStatus(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusTooManyRequests, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods", ""))
+	assert.Equal(t, http.StatusInternalServerError, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods", ""))
+	primary.setStatus(http.StatusOK)
+	assert.Equal(t, http.StatusOK, doEndpointRequest(t, client, "GET", primary.URL+"/api/v1/pods?watch=true", ""))
+
+	assert.Equal(t, []string{"GET /api/v1/pods ", "GET /api/v1/pods?watch=true "}, primary.got())
+	assert.Equal(t, []string{"GET /api/v1/pods "}, secondary.got())
+}
+
+func TestEndpointsValidation(t *testing.T) {
+	_, err := HTTPClientFor(&Config{Host: "https://a.example.com/prefix", Endpoints: []string{"https://b.example.com/other"}})
+	require.Error(t, err)
+
+	client, err := HTTPClientFor(&Config{Host: "https://a.example.com/prefix", Endpoints: []string{"https://b.example.com/prefix"}})
+	require.NoError(t, err)
+	_, ok := client.Transport.(*endpointRoundTripper)
+	assert.True(t, ok, "expected an endpoint round tripper, got %T", client.Transport)
+}
@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -245,6 +246,18 @@ func TestConfigToExecClusterRoundtrip(t *testing.T) {
 		func(h *WarningHandlerWithContext, f randfill.Continue) {
 			*h = &fakeWarningHandlerWithContext{}
 		},
+		func(r *RequestTracer, f randfill.Continue) {
+			*r = &fakeRequestTracer{}
+		},
+		func(r **RetryBudget, f randfill.Continue) {
+			*r = NewRetryBudget(0.1, 10)
+		},
+		func(r **ResponseCache, f randfill.Continue) {
+			*r = NewResponseCache(10, time.Minute)
+		},
+		func(r *transport.AuditSink, f randfill.Continue) {
+			*r = &fakeAuditSink{}
+		},
 		// Authentication does not require fuzzer
 		func(r *AuthProviderConfigPersister, f randfill.Continue) {},
 		func(r *clientcmdapi.AuthProviderConfig, f randfill.Continue) {
@@ -268,6 +281,7 @@ func TestConfigToExecClusterRoundtrip(t *testing.T) {
 
 		// This is the list of known fields that this roundtrip doesn't care about. We should add new
 		// fields to this list if we don't want to roundtrip them on exec cluster conversion.
+		expected.Endpoints = nil
 		expected.APIPath = ""
 		expected.ContentConfig = ContentConfig{}
 		expected.Username = ""
@@ -295,6 +309,14 @@ func TestConfigToExecClusterRoundtrip(t *testing.T) {
 		expected.WarningHandlerWithContext = nil
 		expected.Timeout = 0
 		expected.Dial = nil
+		expected.Tracer = nil
+		expected.RetryBudget = nil
+		expected.RetryPolicy = nil
+		expected.MaxRequestSize = 0
+		expected.MaxResponseSize = 0
+		expected.Audit = nil
+		expected.ConnectionsPerHost = 0
+		expected.ResponseCache = nil
 
 		// Manually set URLs so we don't get an error when parsing these during the roundtrip.
 		if expected.Host != "" {
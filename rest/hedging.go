@@ -0,0 +1,288 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// RetryBudget limits the extra load which a client generates through
+// retries and hedged requests, relative to the number of requests it sends.
+// Every request adds ratio tokens to the budget, up to a maximum, and every
+// retry or hedged request consumes one token. Retries and hedged requests
+// are skipped while the budget is exhausted.
+//
+// A RetryBudget is safe for concurrent use and is meant to be shared by all
+// requests of a client, see [Config.RetryBudget].
+type RetryBudget struct {
+	lock      sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewRetryBudget returns a budget which allows retries and hedged requests
+// for up to ratio (for example 0.1 for 10%) of all requests, on average.
+// maxTokens bounds the number of retries which can be saved up while there
+// are no failures. The budget starts out full.
+func NewRetryBudget(ratio float64, maxTokens int) *RetryBudget {
+	return &RetryBudget{
+		ratio:     ratio,
+		maxTokens: float64(maxTokens),
+		tokens:    float64(maxTokens),
+	}
+}
+
+// deposit adds the tokens for a new request. A nil budget does nothing.
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens = min(b.maxTokens, b.tokens+b.ratio)
+}
+
+// withdraw consumes a token for a retry or hedged request and reports
+// whether there was one. A nil budget is unlimited.
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// HedgingPolicy configures a [Hedger].
+type HedgingPolicy struct {
+	// Percentile of the recently observed latencies, between 0 and 1,
+	// after which a second copy of a request is sent if there has not
+	// been a response yet. For example, 0.95 hedges the slowest 5% of
+	// requests.
+	Percentile float64
+	// MinDelay and MaxDelay bound the delay before a hedged request. A
+	// zero MaxDelay means no upper bound.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+const (
+	// hedgingSamples is the number of recent latencies from which the
+	// hedging delay is computed.
+	hedgingSamples = 256
+	// hedgingMinSamples is the number of latencies which must have been
+	// observed before requests get hedged.
+	hedgingMinSamples = 20
+)
+
+// Hedger sends a second copy of slow GET requests, to cut the tail latency
+// caused by a slow apiserver replica. Whichever copy responds first is used
+// and the other one is canceled. Enable it with [Request.Hedge].
+//
+// The delay before the second copy is derived from the latencies of the
+// requests which used the same Hedger, so one Hedger should be shared by
+// requests with similar latency. Requests are only hedged once enough
+// latencies were observed. Hedged requests count against the
+// [RetryBudget] of the client.
+type Hedger struct {
+	policy HedgingPolicy
+
+	lock      sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// NewHedger returns a Hedger for the given policy.
+func NewHedger(policy HedgingPolicy) *Hedger {
+	return &Hedger{policy: policy}
+}
+
+// observe records the latency of a request.
+func (h *Hedger) observe(latency time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.latencies) < hedgingSamples {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % hedgingSamples
+}
+
+// delay returns how long to wait for a response before hedging, and false
+// if there are not enough latencies yet.
+func (h *Hedger) delay() (time.Duration, bool) {
+	h.lock.Lock()
+	if len(h.latencies) < hedgingMinSamples {
+		h.lock.Unlock()
+		return 0, false
+	}
+	latencies := slices.Clone(h.latencies)
+	h.lock.Unlock()
+
+	slices.Sort(latencies)
+	index := int(h.policy.Percentile * float64(len(latencies)))
+	index = max(0, min(index, len(latencies)-1))
+	delay := max(latencies[index], h.policy.MinDelay)
+	if h.policy.MaxDelay > 0 {
+		delay = min(delay, h.policy.MaxDelay)
+	}
+	return delay, true
+}
+
+// isHedgeable returns true if r may be sent more than once at the same time.
+func (r *Request) isHedgeable() bool {
+	return r.hedger != nil && r.body == nil && (r.verb == http.MethodGet || r.verb == http.MethodHead)
+}
+
+type hedgedResponse struct {
+	resp  *http.Response
+	err   error
+	index int
+}
+
+// doHedged sends req and, if there is no response within the hedging
+// delay and the retry budget allows it, a second copy of req. It returns
+// the first response, or the first error if both copies failed.
+func (r *Request) doHedged(client *http.Client, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	delay, ok := r.hedger.delay()
+	if !ok {
+		resp, err := client.Do(req)
+		if err == nil {
+			r.hedger.observe(time.Since(start))
+		}
+		return resp, err
+	}
+
+	results := make(chan hedgedResponse, 2)
+	var cancels []context.CancelFunc
+	send := func(req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := client.Do(req.WithContext(ctx))
+			results <- hedgedResponse{resp: resp, err: err, index: index}
+		}()
+	}
+	send(req)
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedge := timer.C
+	// The hedge waits for the client rate limiter like any other attempt.
+	// The wait must not hold up the response of the original attempt, so
+	// it happens in the background and gets canceled once that returns.
+	var throttled chan error
+	throttleCtx, cancelThrottle := context.WithCancel(req.Context())
+	defer func() {
+		cancelThrottle()
+		if throttled != nil {
+			<-throttled
+		}
+	}()
+	var firstErr error
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if !r.retryBudget.withdraw() {
+				klog.FromContext(req.Context()).V(4).Info("Not hedging request, retry budget exhausted", "url", req.URL)
+				continue
+			}
+			throttled = make(chan error, 1)
+			go func() {
+				throttled <- r.tryThrottleWithInfo(throttleCtx, "hedge")
+			}()
+		case err := <-throttled:
+			throttled = nil
+			if err != nil {
+				klog.FromContext(req.Context()).V(4).Info("Not hedging request, client-side throttling failed", "url", req.URL, "err", err)
+				continue
+			}
+			hedgeReq, err := r.newHTTPRequest(req.Context())
+			if err != nil {
+				continue
+			}
+			hedgeReq.Header = req.Header
+			send(hedgeReq)
+			pending++
+		case result := <-results:
+			pending--
+			if result.err != nil {
+				cancels[result.index]()
+				if firstErr == nil {
+					firstErr = result.err
+				}
+				if pending > 0 {
+					continue
+				}
+				// An attempt which fails before the hedging delay is
+				// left to the usual retry handling.
+				return nil, firstErr
+			}
+			r.hedger.observe(time.Since(start))
+			for i, cancel := range cancels {
+				if i != result.index {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go discardHedgedResponses(results, pending)
+			}
+			result.resp.Body = &cancelOnClose{ReadCloser: result.resp.Body, cancel: cancels[result.index]}
+			return result.resp, nil
+		}
+	}
+}
+
+// discardHedgedResponses waits for the canceled copies of a request which
+// lost the race and closes their responses.
+func discardHedgedResponses(results <-chan hedgedResponse, pending int) {
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.resp != nil {
+			result.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose cancels the context of a request once its response body
+// got closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestRetryBudget(t *testing.T) {
+	b := NewRetryBudget(0.5, 2)
+	assert.True(t, b.withdraw())
+	assert.True(t, b.withdraw())
+	assert.False(t, b.withdraw())
+	b.deposit()
+	assert.False(t, b.withdraw())
+	b.deposit()
+	assert.True(t, b.withdraw())
+	for i := 0; i < 10; i++ {
+		b.deposit()
+	}
+	assert.True(t, b.withdraw())
+	assert.True(t, b.withdraw())
+	assert.False(t, b.withdraw())
+
+	var unlimited *RetryBudget
+	unlimited.deposit()
+	assert.True(t, unlimited.withdraw())
+}
+
+func TestRetryBudgetLimitsRetries(t *testing.T) {
+	var count atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer testServer.Close()
+
+	c := testRESTClient(t, testServer)
+	c.retryBudget = NewRetryBudget(0, 1)
+	_, err := c.Get().DoRaw(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int32(2), count.Load(), "expected the initial attempt and one retry")
+}
+
+func warmHedger(policy HedgingPolicy, latency time.Duration) *Hedger {
+	h := NewHedger(policy)
+	for i := 0; i < hedgingMinSamples; i++ {
+		h.observe(latency)
+	}
+	return h
+}
+
+func TestHedgerDelay(t *testing.T) {
+	h := NewHedger(HedgingPolicy{Percentile: 0.9})
+	_, ok := h.delay()
+	assert.False(t, ok, "no hedging without latencies")
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	delay, ok := h.delay()
+	require.True(t, ok)
+	assert.Equal(t, 91*time.Millisecond, delay)
+
+	h.policy.MaxDelay = 50 * time.Millisecond
+	delay, _ = h.delay()
+	assert.Equal(t, 50*time.Millisecond, delay)
+	h.policy.MinDelay = time.Second
+	h.policy.MaxDelay = 0
+	delay, _ = h.delay()
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestHedgedRequest(t *testing.T) {
+	var count atomic.Int32
+	canceled := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if count.Add(1) == 1 {
+			// The first copy hangs until the client gives up on it.
+			<-req.Context().Done()
+			close(canceled)
+			return
+		}
+		_, _ = w.Write([]byte("hedged"))
+	}))
+	defer testServer.Close()
+
+	c := testRESTClient(t, testServer)
+	hedger := warmHedger(HedgingPolicy{Percentile: 0.5}, 10*time.Millisecond)
+	body, err := c.Get().Hedge(hedger).DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "hedged", string(body))
+	select {
+	case <-canceled:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the slow copy of the request was not canceled")
+	}
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHedgedRequestRetryBudget(t *testing.T) {
+	var count atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer testServer.Close()
+
+	c := testRESTClient(t, testServer)
+	c.retryBudget = NewRetryBudget(0, 0)
+	hedger := warmHedger(HedgingPolicy{Percentile: 0.5}, 10*time.Millisecond)
+	body, err := c.Get().Hedge(hedger).DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "slow", string(body))
+	assert.Equal(t, int32(1), count.Load(), "the request should not have been hedged")
+
+	// Only GET requests get hedged.
+	c.retryBudget = nil
+	_, err = c.Post().Body([]byte("{}")).Hedge(hedger).DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHedgedRequestThrottled(t *testing.T) {
+	var count atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer testServer.Close()
+
+	c := testRESTClient(t, testServer)
+	// The only token is taken by the original request.
+	c.rateLimiter = flowcontrol.NewTokenBucketRateLimiter(0.001, 1)
+	hedger := warmHedger(HedgingPolicy{Percentile: 0.5}, 10*time.Millisecond)
+	body, err := c.Get().Hedge(hedger).DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "slow", string(body))
+	assert.Equal(t, int32(1), count.Load(), "the hedge should have been throttled")
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrRequestTooLarge is returned for requests whose body exceeds the
+	// limit set with Config.MaxRequestSize or Request.MaxRequestSize.
+	ErrRequestTooLarge = errors.New("request body too large")
+	// ErrResponseTooLarge is returned for responses whose body exceeds the
+	// limit set with Config.MaxResponseSize or Request.MaxResponseSize.
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+type responseTooLargeError struct {
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("%v: exceeds the limit of %d bytes", ErrResponseTooLarge, e.limit)
+}
+
+func (e *responseTooLargeError) Unwrap() error {
+	return ErrResponseTooLarge
+}
+
+// limitedReader counts the bytes read from r and fails once there are more
+// than limit of them. A zero limit means no limit. Bytes beyond the limit
+// are never returned and the error sticks, because decoders like
+// json.Decoder may drop an error returned together with the last bytes of
+// a value.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+	err   error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.limit > 0 && int64(len(p)) > l.limit-l.n+1 {
+		p = p[:l.limit-l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.limit > 0 && l.n > l.limit {
+		l.err = &responseTooLargeError{limit: l.limit}
+		n -= int(l.n - l.limit)
+		l.n = l.limit
+		return n, l.err
+	}
+	return n, err
+}
+
+// responseBodyReader returns a reader for the body of resp which enforces
+// the response size limit of the request. Responses which announce a
+// larger Content-Length fail before anything is read.
+func (r *Request) responseBodyReader(resp *http.Response) (*limitedReader, error) {
+	if r.maxResponseSize > 0 && resp.ContentLength > r.maxResponseSize {
+		return nil, &responseTooLargeError{limit: r.maxResponseSize}
+	}
+	return &limitedReader{r: resp.Body, limit: r.maxResponseSize}, nil
+}
+
+// readResponseBody reads the body of resp into memory, up to the response
+// size limit of the request.
+func (r *Request) readResponseBody(resp *http.Response) ([]byte, error) {
+	body, err := r.responseBodyReader(resp)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(body)
+}
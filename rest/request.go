@@ -128,6 +128,28 @@ type Request struct {
 	bodyBytes []byte
 
 	retryFn requestRetryFunc
+
+	// tracer, if set, creates a span for each attempt.
+	tracer RequestTracer
+	// throttleWait is how long the most recent attempt was delayed by
+	// the rate limiter.
+	throttleWait time.Duration
+
+	// hedger, if set, hedges slow GET requests.
+	hedger *Hedger
+	// retryBudget, if set, limits retries and hedged requests.
+	retryBudget *RetryBudget
+
+	// maxRequestSize and maxResponseSize limit the size of the request
+	// and response bodies. Zero means no limit.
+	maxRequestSize  int64
+	maxResponseSize int64
+
+	// responseCache, if set, caches the responses to GET requests.
+	responseCache *ResponseCache
+	// responseCacheIdentity identifies the credentials of the client in
+	// the keys of responseCache.
+	responseCacheIdentity string
 }
 
 // NewRequest creates a new request helper object for accessing runtime.Objects on a server.
@@ -166,11 +188,24 @@ func NewRequest(c *RESTClient) *Request {
 		maxRetries:     10,
 		retryFn:        defaultRequestRetryFn,
 		warningHandler: c.warningHandler,
+		tracer:         c.tracer,
+		retryBudget:    c.retryBudget,
+
+		maxRequestSize:  c.maxRequestSize,
+		maxResponseSize: c.maxResponseSize,
+
+		responseCache:         c.responseCache,
+		responseCacheIdentity: c.responseCacheIdentity,
 
 		contentConfig:     contentConfig,
 		contentTypeNotSet: contentTypeDefaulted,
 	}
 
+	if c.retryPolicy != nil {
+		r.maxRetries = c.retryPolicy.maxRetries()
+		r.retryFn = c.retryPolicy.retryFn
+	}
+
 	r.setAcceptHeader()
 	return r
 }
@@ -491,6 +526,38 @@ func (r *Request) MaxRetries(maxRetries int) *Request {
 	return r
 }
 
+// Hedge makes the request send a second copy of itself when the first one
+// takes longer than usual, as determined by hedger. Only GET requests
+// without an io.Reader body get hedged. A nil hedger disables hedging.
+func (r *Request) Hedge(hedger *Hedger) *Request {
+	r.hedger = hedger
+	return r
+}
+
+// MaxRequestSize makes the request fail without being sent if its body is
+// larger than the given number of bytes. Bodies passed as an io.Reader are
+// not checked. Zero or a negative size removes the limit.
+func (r *Request) MaxRequestSize(size int64) *Request {
+	r.maxRequestSize = max(size, 0)
+	return r
+}
+
+// MaxResponseSize makes Do, DoRaw and StreamList fail with
+// ErrResponseTooLarge as soon as the response body exceeds the given number
+// of bytes. Zero or a negative size removes the limit.
+func (r *Request) MaxResponseSize(size int64) *Request {
+	r.maxResponseSize = max(size, 0)
+	return r
+}
+
+// Cache makes Do and DoRaw serve the request from cache when possible, and
+// cache its successful response, see [ResponseCache]. Only GET requests
+// without a body are cached. A nil cache disables caching.
+func (r *Request) Cache(cache *ResponseCache) *Request {
+	r.responseCache = cache
+	return r
+}
+
 // Body makes the request use obj as the body. Optional.
 // If obj is a string, try to read a file of that name.
 // If obj is a []byte, send it directly.
@@ -675,6 +742,7 @@ func (r *Request) tryThrottleWithInfo(ctx context.Context, retryInfo string) err
 		err = fmt.Errorf("client rate limiter Wait returned an error: %w", err)
 	}
 	latency := time.Since(now)
+	r.throttleWait = latency
 
 	if latency > longThrottleLatency {
 		if retryInfo == "" {
@@ -781,17 +849,21 @@ func (r *Request) Watch(ctx context.Context) (watch.Interface, error) {
 	}
 	retry := r.retryFn(r.maxRetries)
 	url := r.URL().String()
-	for {
+	for attempt := 0; ; attempt++ {
 		if err := retry.Before(ctx, r); err != nil {
 			return nil, retry.WrapPreviousError(err)
 		}
 
-		req, err := r.newHTTPRequest(ctx)
+		attemptCtx, span := r.startAttempt(ctx, attempt)
+		req, err := r.newHTTPRequest(attemptCtx)
 		if err != nil {
+			span.End(nil, err)
 			return nil, err
 		}
+		injectTraceParent(req, span)
 
 		resp, err := client.Do(req)
+		span.End(resp, err)
 		retry.After(ctx, r, resp, err)
 		if err == nil && resp.StatusCode == http.StatusOK {
 			return r.newStreamWatcher(ctx, resp)
@@ -909,16 +981,20 @@ func (r *Request) Stream(ctx context.Context) (io.ReadCloser, error) {
 
 	retry := r.retryFn(r.maxRetries)
 	url := r.URL().String()
-	for {
+	for attempt := 0; ; attempt++ {
 		if err := retry.Before(ctx, r); err != nil {
 			return nil, err
 		}
 
-		req, err := r.newHTTPRequest(ctx)
+		attemptCtx, span := r.startAttempt(ctx, attempt)
+		req, err := r.newHTTPRequest(attemptCtx)
 		if err != nil {
+			span.End(nil, err)
 			return nil, err
 		}
+		injectTraceParent(req, span)
 		resp, err := client.Do(req)
+		span.End(resp, err)
 		retry.After(ctx, r, resp, err)
 		if err != nil {
 			// we only retry on an HTTP response with 'Retry-After' header
@@ -959,6 +1035,9 @@ func (r *Request) Stream(ctx context.Context) (io.ReadCloser, error) {
 // to GET, PUT or DELETE a named resource(resourceName != ""), again, if
 // namespaceSet is true then namespace must not be empty.
 func (r *Request) requestPreflightCheck() error {
+	if r.maxRequestSize > 0 && int64(len(r.bodyBytes)) > r.maxRequestSize {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrRequestTooLarge, len(r.bodyBytes), r.maxRequestSize)
+	}
 	if !r.namespaceSet {
 		return nil
 	}
@@ -1043,6 +1122,17 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 		return err
 	}
 
+	cache, fresh := r.lookupResponseCache()
+	if fresh {
+		req, err := r.newHTTPRequest(ctx)
+		if err != nil {
+			return err
+		}
+		klog.FromContext(ctx).V(6).Info("Using cached response", "url", r.URL())
+		fn(req, cache.entry.response())
+		return nil
+	}
+
 	client := r.c.Client
 	if client == nil {
 		client = http.DefaultClient
@@ -1077,15 +1167,27 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 
 	// Right now we make about ten retry attempts if we get a Retry-After response.
 	retry := r.retryFn(r.maxRetries)
-	for {
+	for attempt := 0; ; attempt++ {
 		if err := retry.Before(ctx, r); err != nil {
 			return retry.WrapPreviousError(err)
 		}
-		req, err := r.newHTTPRequest(ctx)
+		attemptCtx, span := r.startAttempt(ctx, attempt)
+		req, err := r.newHTTPRequest(attemptCtx)
 		if err != nil {
+			span.End(nil, err)
 			return err
 		}
-		resp, err := client.Do(req)
+		injectTraceParent(req, span)
+		if cache != nil {
+			cache.revalidate(req)
+		}
+		var resp *http.Response
+		if r.isHedgeable() {
+			resp, err = r.doHedged(client, req)
+		} else {
+			resp, err = client.Do(req)
+		}
+		span.End(resp, err)
 		// The value -1 or a value of 0 with a non-nil Body indicates that the length is unknown.
 		// https://pkg.go.dev/net/http#Request
 		if req.ContentLength >= 0 && !(req.Body != nil && req.ContentLength == 0) {
@@ -1104,6 +1206,11 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 				if resp == nil {
 					return
 				}
+				if cache != nil {
+					var done func()
+					resp, done = cache.response(resp)
+					defer done()
+				}
 				fn(req, resp)
 			}
 
@@ -1154,7 +1261,7 @@ func (r *Request) DoRaw(ctx context.Context) ([]byte, error) {
 
 	var result Result
 	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
-		result.body, result.err = io.ReadAll(resp.Body)
+		result.body, result.err = r.readResponseBody(resp)
 		logBody(logger, 2, "Response Body", result.body)
 		if resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusPartialContent {
 			result.err = r.transformUnstructuredResponseError(resp, req, result.body)
@@ -1174,10 +1281,15 @@ func (r *Request) transformResponse(ctx context.Context, resp *http.Response, re
 	logger := klog.FromContext(ctx)
 	var body []byte
 	if resp.Body != nil {
-		data, err := io.ReadAll(resp.Body)
+		data, err := r.readResponseBody(resp)
 		switch err.(type) {
 		case nil:
 			body = data
+		case *responseTooLargeError:
+			return Result{
+				err:    err,
+				logger: logger,
+			}
 		case http2.StreamError:
 			// This is trying to catch the scenario that the server may close the connection when sending the
 			// response body. This can be caused by server timeout due to a slow network connection.
@@ -0,0 +1,296 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/lru"
+)
+
+// ResponseCache caches the successful responses to GET requests, so that
+// clients which repeatedly read the same objects don't transfer them again.
+//
+// Responses with an ETag header are revalidated with an If-None-Match
+// header, and the cached body is used when the server responds with 304 Not
+// Modified. Responses without an ETag are used without contacting the
+// server for the TTL of the cache, and so are the responses to requests
+// whose resourceVersion parameter is "0", because the server would be
+// allowed to respond with a stale object as well. Regardless of the TTL, a
+// request whose resourceVersion matches the resourceVersion of the cached
+// JSON object or list is served from the cache. Requests for any other
+// resourceVersion are always sent to the server.
+//
+// Only Do and DoRaw use the cache; watches, streams and requests with a
+// body are never cached. A ResponseCache is safe for concurrent use. The
+// cached responses are partitioned by the credentials, impersonation and
+// client certificate of the Config and by the Authorization and
+// Impersonate headers of the request, so clients with different identities
+// may share a cache. Credentials added by a custom Transport or
+// WrapTransport are not known to the cache, clients which use them must not
+// share it.
+type ResponseCache struct {
+	ttl     time.Duration
+	entries *lru.Cache
+	clock   clock.PassiveClock
+}
+
+type cachedResponse struct {
+	body            []byte
+	contentType     string
+	etag            string
+	resourceVersion string
+	stored          time.Time
+}
+
+// NewResponseCache returns a cache which keeps the responses to up to
+// maxEntries distinct requests, evicting the least recently used ones.
+// Responses without an ETag are used without revalidation for ttl.
+func NewResponseCache(maxEntries int, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:     ttl,
+		entries: lru.New(maxEntries),
+		clock:   clock.RealClock{},
+	}
+}
+
+// lookup returns the cached response for key, if any, and whether it may
+// be used without asking the server for a request with the given
+// resourceVersion parameter.
+func (c *ResponseCache) lookup(key, resourceVersion string) (*cachedResponse, bool) {
+	value, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*cachedResponse)
+	switch {
+	case resourceVersion == "0":
+		// Any cached response will do, as long as it isn't older than
+		// the TTL.
+	case len(resourceVersion) > 0:
+		return entry, resourceVersion == entry.resourceVersion
+	case len(entry.etag) > 0:
+		return entry, false
+	}
+	return entry, c.clock.Since(entry.stored) < c.ttl
+}
+
+// responseCacheIdentity returns a hash of the settings of config which
+// decide as whom the server sees its requests.
+func responseCacheIdentity(config *Config) string {
+	identity := struct {
+		Username, Password, BearerToken, BearerTokenFile string
+		Impersonate                                      ImpersonationConfig
+		CertFile, KeyFile                                string
+		CertData, KeyData                                []byte
+		AuthProvider                                     *clientcmdapi.AuthProviderConfig
+		ExecCommand                                      string
+		ExecArgs                                         []string
+		ExecEnv                                          []clientcmdapi.ExecEnvVar
+	}{
+		Username:        config.Username,
+		Password:        config.Password,
+		BearerToken:     config.BearerToken,
+		BearerTokenFile: config.BearerTokenFile,
+		Impersonate:     config.Impersonate,
+		CertFile:        config.CertFile,
+		KeyFile:         config.KeyFile,
+		CertData:        config.CertData,
+		KeyData:         config.KeyData,
+		AuthProvider:    config.AuthProvider,
+	}
+	if config.ExecProvider != nil {
+		identity.ExecCommand = config.ExecProvider.Command
+		identity.ExecArgs = config.ExecProvider.Args
+		identity.ExecEnv = config.ExecProvider.Env
+	}
+	// The fields are plain data, so marshaling them cannot fail.
+	data, _ := json.Marshal(identity)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// requestIdentity returns a hash of the headers of a request which override
+// the identity of the client, or an empty string if there are none.
+func requestIdentity(header http.Header) string {
+	var identity []string
+	for name, values := range header {
+		if name == "Authorization" || strings.HasPrefix(name, "Impersonate-") {
+			identity = append(identity, name+": "+strings.Join(values, ","))
+		}
+	}
+	if len(identity) == 0 {
+		return ""
+	}
+	sort.Strings(identity)
+	sum := sha256.Sum256([]byte(strings.Join(identity, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// store caches body as the response to the request identified by key. The
+// body is copied, because it may be returned to the user.
+func (c *ResponseCache) store(key string, header http.Header, body []byte) {
+	contentType := header.Get("Content-Type")
+	c.entries.Add(key, &cachedResponse{
+		body:            bytes.Clone(body),
+		contentType:     contentType,
+		etag:            header.Get("ETag"),
+		resourceVersion: resourceVersionOf(contentType, body),
+		stored:          c.clock.Now(),
+	})
+}
+
+// response returns a response which serves the cached body.
+func (e *cachedResponse) response() *http.Response {
+	header := http.Header{}
+	if len(e.contentType) > 0 {
+		header.Set("Content-Type", e.contentType)
+	}
+	if len(e.etag) > 0 {
+		header.Set("ETag", e.etag)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(http.StatusOK) + " " + http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+	}
+}
+
+// resourceVersionOf returns the resourceVersion of the object or list
+// encoded as JSON in body, or an empty string for other content types.
+func resourceVersionOf(contentType string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/json" {
+		return ""
+	}
+	var object struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return ""
+	}
+	return object.Metadata.ResourceVersion
+}
+
+// responseCacheLookup is the state of a request which uses a response
+// cache.
+type responseCacheLookup struct {
+	cache *ResponseCache
+	key   string
+	// entry is the cached response, if any.
+	entry *cachedResponse
+}
+
+// lookupResponseCache looks up the cached response to the request. It
+// returns nil if the request doesn't use a response cache, and whether the
+// cached response may be used without asking the server.
+func (r *Request) lookupResponseCache() (*responseCacheLookup, bool) {
+	if r.responseCache == nil || r.verb != http.MethodGet || r.body != nil || r.bodyBytes != nil {
+		return nil, false
+	}
+	if watch := r.params.Get("watch"); watch == "true" || watch == "1" {
+		return nil, false
+	}
+	// The requested resourceVersion decides whether the cached response
+	// may be used, rather than being part of the key. The same URL may be
+	// served in different content types.
+	u := r.URL()
+	query := u.Query()
+	query.Del("resourceVersion")
+	query.Del("resourceVersionMatch")
+	u.RawQuery = query.Encode()
+	lookup := &responseCacheLookup{
+		cache: r.responseCache,
+		key: strings.Join([]string{
+			r.responseCacheIdentity,
+			requestIdentity(r.headers),
+			u.String(),
+			strings.Join(r.headers.Values("Accept"), ","),
+		}, " "),
+	}
+	var fresh bool
+	lookup.entry, fresh = r.responseCache.lookup(lookup.key, r.params.Get("resourceVersion"))
+	return lookup, fresh
+}
+
+// revalidate makes req ask the server whether the cached response is still
+// current, if it has an ETag.
+func (l *responseCacheLookup) revalidate(req *http.Request) {
+	if l.entry == nil || len(l.entry.etag) == 0 {
+		return
+	}
+	// The headers are shared with the Request and its other attempts.
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("If-None-Match", l.entry.etag)
+	req.Header = header
+}
+
+// response replaces a 304 Not Modified response with the cached one, and
+// makes a successful response get cached once its body was read. The
+// returned func must be called once the caller is done with the response.
+func (l *responseCacheLookup) response(resp *http.Response) (*http.Response, func()) {
+	if l.entry != nil && resp.StatusCode == http.StatusNotModified {
+		resp = l.entry.response()
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body == nil {
+		return resp, func() {}
+	}
+	body := &recordingReader{ReadCloser: resp.Body}
+	cached := *resp
+	cached.Body = body
+	return &cached, func() {
+		if body.eof {
+			l.cache.store(l.key, resp.Header, body.data.Bytes())
+		}
+	}
+}
+
+// recordingReader records the data read from a body, and whether it was
+// read completely.
+type recordingReader struct {
+	io.ReadCloser
+	data bytes.Buffer
+	eof  bool
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.data.Write(p[:n])
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+const cachedConfigMap = `{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":"config","namespace":"default","resourceVersion":"5"}}`
+
+func newCachedRequest(t *testing.T, cache *ResponseCache, fn clientFunc) func() *Request {
+	base, err := url.Parse("https://example.com")
+	require.NoError(t, err)
+	return func() *Request {
+		return NewRequestWithClient(base, "/api/v1", defaultContentConfig(), clientForFunc(fn)).
+			Cache(cache).
+			Verb(http.MethodGet).
+			Namespace("default").
+			Resource("configmaps").
+			Name("config")
+	}
+}
+
+func jsonResponse(statusCode int, etag, body string) *http.Response {
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	if len(etag) > 0 {
+		header.Set("ETag", etag)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestResponseCacheETag(t *testing.T) {
+	var ifNoneMatch []string
+	newRequest := newCachedRequest(t, NewResponseCache(10, time.Hour), func(req *http.Request) (*http.Response, error) {
+		ifNoneMatch = append(ifNoneMatch, req.Header.Get("If-None-Match"))
+		if req.Header.Get("If-None-Match") == `"5"` {
+			return jsonResponse(http.StatusNotModified, `"5"`, ""), nil
+		}
+		return jsonResponse(http.StatusOK, `"5"`, cachedConfigMap), nil
+	})
+
+	for i := 0; i < 3; i++ {
+		configMap := &v1.ConfigMap{}
+		require.NoError(t, newRequest().Do(context.Background()).Into(configMap))
+		assert.Equal(t, "5", configMap.ResourceVersion)
+	}
+	// Responses with an ETag are always revalidated.
+	assert.Equal(t, []string{"", `"5"`, `"5"`}, ifNoneMatch)
+
+	body, err := newRequest().DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.JSONEq(t, cachedConfigMap, string(body))
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	cache := NewResponseCache(10, time.Minute)
+	clock := testingclock.NewFakePassiveClock(time.Now())
+	cache.clock = clock
+
+	requests := 0
+	newRequest := newCachedRequest(t, cache, func(req *http.Request) (*http.Response, error) {
+		requests++
+		return jsonResponse(http.StatusOK, "", cachedConfigMap), nil
+	})
+
+	get := func(r *Request) {
+		t.Helper()
+		configMap := &v1.ConfigMap{}
+		require.NoError(t, r.Do(context.Background()).Into(configMap))
+		assert.Equal(t, "config", configMap.Name)
+	}
+
+	get(newRequest())
+	get(newRequest())
+	get(newRequest().Param("resourceVersion", "0"))
+	assert.Equal(t, 1, requests, "expected the later requests to be served from the cache")
+
+	// After the TTL, only requests for the cached resourceVersion are
+	// served from the cache.
+	clock.SetTime(clock.Now().Add(2 * time.Minute))
+	get(newRequest().Param("resourceVersion", "5"))
+	assert.Equal(t, 1, requests)
+	get(newRequest().Param("resourceVersion", "0"))
+	assert.Equal(t, 2, requests)
+	clock.SetTime(clock.Now().Add(2 * time.Minute))
+	get(newRequest())
+	assert.Equal(t, 3, requests)
+
+	// Requests for another resourceVersion are always sent.
+	get(newRequest().Param("resourceVersion", "6"))
+	assert.Equal(t, 4, requests)
+}
+
+func TestResponseCacheIdentity(t *testing.T) {
+	var requests atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		// The name of the object tells who asked for it.
+		name := req.Header.Get("Authorization") + "/" + req.Header.Get("Impersonate-User")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":%q}}`, name)
+	}))
+	defer testServer.Close()
+
+	config := &Config{
+		Host: testServer.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion:         &v1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		BearerToken:   "alice",
+		ResponseCache: NewResponseCache(10, time.Hour),
+	}
+	other := CopyConfig(config)
+	other.BearerToken = "bob"
+	impersonating := CopyConfig(config)
+	impersonating.Impersonate.UserName = "carol"
+
+	get := func(config *Config, header ...string) string {
+		t.Helper()
+		c, err := RESTClientFor(config)
+		require.NoError(t, err)
+		r := c.Get().Namespace("default").Resource("configmaps").Name("config")
+		if len(header) > 0 {
+			r.SetHeader(header[0], header[1])
+		}
+		configMap := &v1.ConfigMap{}
+		require.NoError(t, r.Do(context.Background()).Into(configMap))
+		return configMap.Name
+	}
+
+	assert.Equal(t, "Bearer alice/", get(config))
+	assert.Equal(t, "Bearer alice/", get(config))
+	assert.Equal(t, int32(1), requests.Load(), "expected the second request to be served from the cache")
+
+	assert.Equal(t, "Bearer bob/", get(other))
+	assert.Equal(t, "Bearer alice/carol", get(impersonating))
+	assert.Equal(t, "Bearer alice/dave", get(config, "Impersonate-User", "dave"))
+	assert.Equal(t, "Bearer alice/", get(config))
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestResponseCacheSkipped(t *testing.T) {
+	cache := NewResponseCache(10, time.Hour)
+	requests := 0
+	statusCode := http.StatusNotFound
+	newRequest := newCachedRequest(t, cache, func(req *http.Request) (*http.Response, error) {
+		requests++
+		if statusCode != http.StatusOK {
+			return jsonResponse(statusCode, "", `{"kind":"Status","apiVersion":"v1","status":"Failure","code":404,"reason":"NotFound"}`), nil
+		}
+		return jsonResponse(statusCode, "", cachedConfigMap), nil
+	})
+
+	// Errors are not cached.
+	require.Error(t, newRequest().Do(context.Background()).Error())
+	require.Error(t, newRequest().Do(context.Background()).Error())
+	assert.Equal(t, 2, requests)
+
+	// Neither are other verbs, watches and requests without a cache.
+	statusCode = http.StatusOK
+	require.NoError(t, newRequest().Verb(http.MethodDelete).Do(context.Background()).Error())
+	require.NoError(t, newRequest().Param("watch", "true").Do(context.Background()).Error())
+	require.NoError(t, newRequest().Cache(nil).Do(context.Background()).Error())
+	require.NoError(t, newRequest().Verb(http.MethodDelete).Do(context.Background()).Error())
+	assert.Equal(t, 6, requests)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/net"
+)
+
+// RetryableError is a class of errors which a [RetryPolicy] can retry.
+type RetryableError string
+
+const (
+	// RetryableErrorConnectionReset matches connections reset by the peer.
+	RetryableErrorConnectionReset RetryableError = "ConnectionReset"
+	// RetryableErrorConnectionRefused matches refused connections.
+	RetryableErrorConnectionRefused RetryableError = "ConnectionRefused"
+	// RetryableErrorEOF matches connections closed before the response
+	// was complete.
+	RetryableErrorEOF RetryableError = "EOF"
+	// RetryableErrorHTTP2ConnectionLost matches lost HTTP/2 connections.
+	RetryableErrorHTTP2ConnectionLost RetryableError = "HTTP2ConnectionLost"
+	// RetryableErrorTLSHandshakeTimeout matches TLS handshakes which
+	// timed out.
+	RetryableErrorTLSHandshakeTimeout RetryableError = "TLSHandshakeTimeout"
+	// RetryableErrorTimeout matches any timeout.
+	RetryableErrorTimeout RetryableError = "Timeout"
+)
+
+const (
+	defaultMaxRetries        = 10
+	defaultRetryInitialDelay = time.Second
+	defaultRetryMaxDelay     = 30 * time.Second
+)
+
+// RetryPolicy configures which failed requests of a RESTClient get
+// retried, in addition to the requests for which the server sent a
+// Retry-After header. It applies to all clients built from a Config with
+// the policy, see [Config.RetryPolicy].
+//
+// Requests whose body is an io.Reader are never retried.
+type RetryPolicy struct {
+	// MaxRetries is the default for [Request.MaxRetries]. If zero, 10
+	// retries are made. A negative value disables retries.
+	MaxRetries int
+
+	// Verbs lists the HTTP methods of requests which get retried after
+	// one of the Errors or StatusCodes. If empty, only GET requests are
+	// retried. Retrying a request which is not idempotent may apply it
+	// twice.
+	Verbs []string
+
+	// StatusCodes lists the response status codes which get retried even
+	// without a Retry-After header.
+	StatusCodes []int
+
+	// Errors lists the classes of errors which get retried.
+	Errors []RetryableError
+
+	// InitialBackoff is the delay before the first retry after one of the
+	// Errors or StatusCodes. It doubles with every further retry up to
+	// MaxBackoff. They default to one and thirty seconds, respectively.
+	// A Retry-After header sent by the server takes precedence.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (p *RetryPolicy) maxRetries() int {
+	switch {
+	case p.MaxRetries == 0:
+		return defaultMaxRetries
+	case p.MaxRetries < 0:
+		return 0
+	default:
+		return p.MaxRetries
+	}
+}
+
+func (p *RetryPolicy) retryFn(maxRetries int) WithRetry {
+	return &withRetry{maxRetries: maxRetries, policy: p}
+}
+
+func (p *RetryPolicy) retriesVerb(verb string) bool {
+	if len(p.Verbs) == 0 {
+		return verb == http.MethodGet
+	}
+	return slices.Contains(p.Verbs, verb)
+}
+
+// retriesError returns true if the policy retries req after err. A nil
+// policy retries nothing.
+func (p *RetryPolicy) retriesError(req *http.Request, err error) bool {
+	if p == nil || !p.retriesVerb(req.Method) {
+		return false
+	}
+	for _, class := range p.Errors {
+		if matchesRetryableError(class, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// retriesStatus returns true if the policy retries req after a response
+// with the given status code. A nil policy retries nothing.
+func (p *RetryPolicy) retriesStatus(req *http.Request, statusCode int) bool {
+	return p != nil && p.retriesVerb(req.Method) && slices.Contains(p.StatusCodes, statusCode)
+}
+
+// backoff returns the delay before the given retry, counting from one.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = defaultRetryInitialDelay
+	}
+	maxDelay := p.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func matchesRetryableError(class RetryableError, err error) bool {
+	switch class {
+	case RetryableErrorConnectionReset:
+		return net.IsConnectionReset(err)
+	case RetryableErrorConnectionRefused:
+		return net.IsConnectionRefused(err)
+	case RetryableErrorEOF:
+		return net.IsProbableEOF(err)
+	case RetryableErrorHTTP2ConnectionLost:
+		return net.IsHTTP2ConnectionLost(err)
+	case RetryableErrorTLSHandshakeTimeout:
+		return strings.Contains(err.Error(), "TLS handshake timeout")
+	case RetryableErrorTimeout:
+		return net.IsTimeout(err)
+	default:
+		return false
+	}
+}
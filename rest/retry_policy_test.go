@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 30*time.Second, p.backoff(10))
+
+	p = &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, p.backoff(3))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy *RetryPolicy
+		verb   string
+		// failures is the number of requests which fail before the
+		// server responds successfully.
+		failures      int
+		fail          func(w http.ResponseWriter)
+		expectSuccess bool
+		expectCount   int32
+	}{
+		{
+			name:        "no policy, status code",
+			verb:        "GET",
+			failures:    1,
+			fail:        func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			expectCount: 1,
+		},
+		{
+			name:          "status code",
+			policy:        &RetryPolicy{StatusCodes: []int{http.StatusServiceUnavailable}},
+			verb:          "GET",
+			failures:      2,
+			fail:          func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			expectSuccess: true,
+			expectCount:   3,
+		},
+		{
+			name:        "max retries",
+			policy:      &RetryPolicy{MaxRetries: 1, StatusCodes: []int{http.StatusServiceUnavailable}},
+			verb:        "GET",
+			failures:    2,
+			fail:        func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			expectCount: 2,
+		},
+		{
+			name:        "verb not retried",
+			policy:      &RetryPolicy{StatusCodes: []int{http.StatusServiceUnavailable}},
+			verb:        "POST",
+			failures:    1,
+			fail:        func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			expectCount: 1,
+		},
+		{
+			name:   "error",
+			policy: &RetryPolicy{Verbs: []string{"POST"}, Errors: []RetryableError{RetryableErrorEOF}},
+			verb:   "POST",
+			fail: func(w http.ResponseWriter) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					_ = conn.Close()
+				}
+			},
+			failures:      1,
+			expectSuccess: true,
+			expectCount:   2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var count atomic.Int32
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if int(count.Add(1)) <= tc.failures {
+					tc.fail(w)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			if tc.policy != nil {
+				tc.policy.InitialBackoff = time.Millisecond
+			}
+			c, err := RESTClientFor(&Config{
+				Host: testServer.URL,
+				ContentConfig: ContentConfig{
+					GroupVersion:         &v1.SchemeGroupVersion,
+					NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				},
+				RetryPolicy: tc.policy,
+			})
+			require.NoError(t, err)
+
+			_, err = c.Verb(tc.verb).Body([]byte("{}")).DoRaw(context.Background())
+			if tc.expectSuccess {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+			assert.Equal(t, tc.expectCount, count.Load())
+		})
+	}
+}
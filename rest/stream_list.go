@@ -0,0 +1,474 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/klog/v2"
+)
+
+// StreamList executes the request, which must return a list, and decodes
+// the items of the list one at a time while the response body is read,
+// calling fn with each of them. Unlike Do, it never holds the whole
+// response body in memory. newItem returns the empty object into which an
+// item gets decoded. fn may keep the items; if it returns an error, reading
+// the response stops and the error is returned. The metadata of the list is
+// returned once all items were decoded.
+//
+// JSON and protobuf responses are decoded incrementally. Responses of any
+// other content type are decoded as a whole, with items of the type chosen
+// by the decoder.
+func (r *Request) StreamList(ctx context.Context, newItem func() runtime.Object, fn func(runtime.Object) error) (*metav1.ListMeta, error) {
+	_, listMeta, err := r.streamList(ctx, newItem, fn)
+	return listMeta, err
+}
+
+// StreamListInto is like StreamList, but collects the items into list,
+// which must be a list type such as *v1.PodList or
+// *unstructured.UnstructuredList, and sets its metadata. If an error is
+// returned, list has no items.
+func (r *Request) StreamListInto(ctx context.Context, list runtime.Object) error {
+	itemsPtr, err := meta.GetItemsPtr(list)
+	if err != nil {
+		return err
+	}
+	items := reflect.ValueOf(itemsPtr).Elem()
+	elemType := items.Type().Elem()
+	newItem, err := newListItemFunc(elemType)
+	if err != nil {
+		return err
+	}
+
+	items.Set(reflect.MakeSlice(items.Type(), 0, 0))
+	gvk, listMeta, err := r.streamList(ctx, newItem, func(item runtime.Object) error {
+		v := reflect.ValueOf(item)
+		if elemType.Kind() != reflect.Pointer {
+			v = v.Elem()
+		}
+		if v.Type() != elemType {
+			return fmt.Errorf("cannot add an item of type %T to %T", item, list)
+		}
+		items.Set(reflect.Append(items, v))
+		return nil
+	})
+	if err != nil {
+		items.Set(reflect.Zero(items.Type()))
+		return err
+	}
+
+	if _, ok := list.(runtime.Unstructured); ok {
+		list.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	accessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	accessor.SetResourceVersion(listMeta.ResourceVersion)
+	accessor.SetContinue(listMeta.Continue)
+	accessor.SetRemainingItemCount(listMeta.RemainingItemCount)
+	return nil
+}
+
+// newListItemFunc returns a function which creates the items of a list
+// whose Items field has elements of type elemType.
+func newListItemFunc(elemType reflect.Type) (func() runtime.Object, error) {
+	t := elemType
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || !reflect.PointerTo(t).Implements(reflect.TypeFor[runtime.Object]()) {
+		return nil, fmt.Errorf("list items of type %v are not supported", elemType)
+	}
+	return func() runtime.Object {
+		return reflect.New(t).Interface().(runtime.Object)
+	}, nil
+}
+
+func (r *Request) streamList(ctx context.Context, newItem func() runtime.Object, fn func(runtime.Object) error) (schema.GroupVersionKind, *metav1.ListMeta, error) {
+	logger := klog.FromContext(ctx)
+	if r.body == nil {
+		logBody(logger, 2, "Request Body", r.bodyBytes)
+	}
+
+	var gvk schema.GroupVersionKind
+	var listMeta *metav1.ListMeta
+	var decodeErr error
+	// Caching the list would read it into memory.
+	r.responseCache = nil
+	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
+		gvk, listMeta, decodeErr = r.decodeList(ctx, req, resp, newItem, fn)
+	})
+	if err != nil {
+		return gvk, nil, err
+	}
+	return gvk, listMeta, decodeErr
+}
+
+// decodeList decodes the list in the body of resp.
+func (r *Request) decodeList(ctx context.Context, req *http.Request, resp *http.Response, newItem func() runtime.Object, fn func(runtime.Object) error) (schema.GroupVersionKind, *metav1.ListMeta, error) {
+	if resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusPartialContent {
+		return schema.GroupVersionKind{}, nil, r.transformResponse(ctx, resp, req).Error()
+	}
+	handleWarnings(ctx, resp.Header, r.warningHandler)
+
+	contentType := resp.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = r.contentConfig.ContentType
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, errors.NewInternalError(err)
+	}
+	decoder, err := r.contentConfig.Negotiator.Decoder(mediaType, params)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+
+	body, err := r.responseBodyReader(resp)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	defer func() {
+		metrics.ResponseSize.Observe(ctx, r.verb, r.URL().Host, float64(body.n))
+	}()
+
+	switch mediaType {
+	case runtime.ContentTypeJSON:
+		return decodeJSONList(body, decoder, newItem, fn)
+	case runtime.ContentTypeProtobuf:
+		return decodeProtobufList(body, newItem, fn)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	obj, err := runtime.Decode(decoder, data)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	accessor, err := meta.ListAccessor(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	listMeta := &metav1.ListMeta{
+		ResourceVersion:    accessor.GetResourceVersion(),
+		Continue:           accessor.GetContinue(),
+		RemainingItemCount: accessor.GetRemainingItemCount(),
+	}
+	if err := meta.EachListItem(obj, fn); err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	return obj.GetObjectKind().GroupVersionKind(), listMeta, nil
+}
+
+// decodeJSONList decodes a JSON list from body, one item at a time.
+func decodeJSONList(body io.Reader, decoder runtime.Decoder, newItem func() runtime.Object, fn func(runtime.Object) error) (schema.GroupVersionKind, *metav1.ListMeta, error) {
+	var apiVersion, kind string
+	listMeta := &metav1.ListMeta{}
+	d := json.NewDecoder(body)
+	if err := expectJSONDelim(d, '{'); err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	for d.More() {
+		token, err := d.Token()
+		if err != nil {
+			return schema.GroupVersionKind{}, nil, err
+		}
+		switch token {
+		case "apiVersion":
+			err = d.Decode(&apiVersion)
+		case "kind":
+			err = d.Decode(&kind)
+		case "metadata":
+			err = d.Decode(listMeta)
+		case "items":
+			// The kind of the items is only known if the server sent it
+			// before the items, which the apiserver always does.
+			var itemGVK *schema.GroupVersionKind
+			if len(kind) > 0 {
+				gvk := schema.FromAPIVersionAndKind(apiVersion, strings.TrimSuffix(kind, "List"))
+				itemGVK = &gvk
+			}
+			err = decodeJSONListItems(d, itemGVK, decoder, newItem, fn)
+		default:
+			var ignored json.RawMessage
+			err = d.Decode(&ignored)
+		}
+		if err != nil {
+			return schema.GroupVersionKind{}, nil, err
+		}
+	}
+	if err := expectJSONDelim(d, '}'); err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	return schema.FromAPIVersionAndKind(apiVersion, kind), listMeta, nil
+}
+
+func decodeJSONListItems(d *json.Decoder, gvk *schema.GroupVersionKind, decoder runtime.Decoder, newItem func() runtime.Object, fn func(runtime.Object) error) error {
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected the items of the list to be an array, got %v", token)
+	}
+	for d.More() {
+		var data json.RawMessage
+		if err := d.Decode(&data); err != nil {
+			return err
+		}
+		item, err := decodeJSONListItem(data, gvk, decoder, newItem())
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(d, ']')
+}
+
+func decodeJSONListItem(data []byte, gvk *schema.GroupVersionKind, decoder runtime.Decoder, into runtime.Object) (runtime.Object, error) {
+	// Like UnstructuredList, don't require the items of unstructured lists
+	// to carry their kind.
+	if u, ok := into.(runtime.Unstructured); ok {
+		var content map[string]interface{}
+		if err := utiljson.Unmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		u.SetUnstructuredContent(content)
+		if gvk != nil && u.GetObjectKind().GroupVersionKind().Empty() {
+			u.GetObjectKind().SetGroupVersionKind(*gvk)
+		}
+		return u, nil
+	}
+	obj, _, err := decoder.Decode(data, gvk, into)
+	return obj, err
+}
+
+func expectJSONDelim(d *json.Decoder, delim json.Delim) error {
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v in the list, got %v", delim, token)
+	}
+	return nil
+}
+
+// protobufPrefix is the magic number of the Kubernetes protobuf encoding.
+var protobufPrefix = []byte{0x6b, 0x38, 0x73, 0x00}
+
+const (
+	protobufWireVarint  = 0
+	protobufWireFixed64 = 1
+	protobufWireBytes   = 2
+	protobufWireFixed32 = 5
+)
+
+// decodeProtobufList decodes a protobuf list from body, one item at a time.
+// The list is encoded as a runtime.Unknown whose Raw field holds the list
+// message, which has its ListMeta in field 1 and its items in field 2.
+func decodeProtobufList(body io.Reader, newItem func() runtime.Object, fn func(runtime.Object) error) (schema.GroupVersionKind, *metav1.ListMeta, error) {
+	var gvk schema.GroupVersionKind
+	listMeta := &metav1.ListMeta{}
+
+	br := bufio.NewReader(body)
+	prefix := make([]byte, len(protobufPrefix))
+	if _, err := io.ReadFull(br, prefix); err != nil {
+		return gvk, nil, err
+	}
+	if !bytes.Equal(prefix, protobufPrefix) {
+		return gvk, nil, fmt.Errorf("the response is not a protobuf message, expected prefix %x", protobufPrefix)
+	}
+
+	unknown := protobufReader{r: br}
+	for {
+		field, wireType, err := unknown.next()
+		if err == io.EOF {
+			return gvk, listMeta, nil
+		}
+		if err != nil {
+			return gvk, nil, err
+		}
+		switch {
+		case field == 1 && wireType == protobufWireBytes:
+			data, err := unknown.readBytes()
+			if err != nil {
+				return gvk, nil, err
+			}
+			var typeMeta runtime.TypeMeta
+			if err := typeMeta.Unmarshal(data); err != nil {
+				return gvk, nil, err
+			}
+			gvk = schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind)
+		case field == 2 && wireType == protobufWireBytes:
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				return gvk, nil, unexpectedEOF(err)
+			}
+			raw := &io.LimitedReader{R: br, N: int64(length)}
+			if err := decodeProtobufListFields(protobufReader{r: bufio.NewReader(raw)}, listMeta, newItem, fn); err != nil {
+				return gvk, nil, err
+			}
+			if raw.N > 0 {
+				return gvk, nil, io.ErrUnexpectedEOF
+			}
+		default:
+			if err := unknown.skip(wireType); err != nil {
+				return gvk, nil, err
+			}
+		}
+	}
+}
+
+// protobufUnmarshaler is implemented by the generated protobuf types.
+type protobufUnmarshaler interface {
+	Unmarshal(data []byte) error
+}
+
+func decodeProtobufListFields(list protobufReader, listMeta *metav1.ListMeta, newItem func() runtime.Object, fn func(runtime.Object) error) error {
+	for {
+		field, wireType, err := list.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == protobufWireBytes:
+			data, err := list.readBytes()
+			if err != nil {
+				return err
+			}
+			if err := listMeta.Unmarshal(data); err != nil {
+				return err
+			}
+		case field == 2 && wireType == protobufWireBytes:
+			data, err := list.readBytes()
+			if err != nil {
+				return err
+			}
+			item := newItem()
+			u, ok := item.(protobufUnmarshaler)
+			if !ok {
+				return fmt.Errorf("cannot decode protobuf into %T", item)
+			}
+			if err := u.Unmarshal(data); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		default:
+			if err := list.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// protobufReader reads the fields of a protobuf message one at a time.
+type protobufReader struct {
+	r *bufio.Reader
+}
+
+// next returns the number and wire type of the next field, or io.EOF at
+// the end of the message.
+func (p protobufReader) next() (uint64, uint64, error) {
+	tag, err := binary.ReadUvarint(p.r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tag >> 3, tag & 7, nil
+}
+
+// readBytes reads the value of a length-delimited field.
+func (p protobufReader) readBytes() ([]byte, error) {
+	length, err := binary.ReadUvarint(p.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	// Don't trust the length with the allocation, the data may be corrupt.
+	data, err := io.ReadAll(io.LimitReader(p.r, int64(length)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// skip skips the value of a field with the given wire type.
+func (p protobufReader) skip(wireType uint64) error {
+	var length uint64
+	switch wireType {
+	case protobufWireVarint:
+		_, err := binary.ReadUvarint(p.r)
+		return unexpectedEOF(err)
+	case protobufWireFixed64:
+		length = 8
+	case protobufWireFixed32:
+		length = 4
+	case protobufWireBytes:
+		var err error
+		if length, err = binary.ReadUvarint(p.r); err != nil {
+			return unexpectedEOF(err)
+		}
+	default:
+		return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+	}
+	n, err := io.CopyN(io.Discard, p.r, int64(length))
+	if err == io.EOF || uint64(n) != length {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// unexpectedEOF turns io.EOF, which only marks the end of a message between
+// fields, into io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func testPodList(n int) *v1.PodList {
+	remaining := int64(42)
+	list := &v1.PodList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "123", Continue: "next", RemainingItemCount: &remaining},
+	}
+	for i := 0; i < n; i++ {
+		list.Items = append(list.Items, v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"},
+			Spec:       v1.PodSpec{NodeName: "node"},
+		})
+	}
+	return list
+}
+
+func listServer(t *testing.T, mediaType string, list runtime.Object) *httptest.Server {
+	info, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), mediaType)
+	require.True(t, ok)
+	body, err := runtime.Encode(scheme.Codecs.EncoderForVersion(info.Serializer, v1.SchemeGroupVersion), list)
+	require.NoError(t, err)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", mediaType)
+		_, _ = w.Write(body)
+	}))
+}
+
+func listClient(t *testing.T, srv *httptest.Server, mediaType string) *RESTClient {
+	c, err := RESTClientFor(&Config{
+		Host: srv.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion:         &v1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			ContentType:          mediaType,
+		},
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestStreamList(t *testing.T) {
+	expected := testPodList(3)
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf, runtime.ContentTypeYAML} {
+		t.Run(mediaType, func(t *testing.T) {
+			srv := listServer(t, mediaType, expected)
+			defer srv.Close()
+
+			var names []string
+			listMeta, err := listClient(t, srv, mediaType).Get().Resource("pods").StreamList(context.Background(),
+				func() runtime.Object { return &v1.Pod{} },
+				func(item runtime.Object) error {
+					pod, ok := item.(*v1.Pod)
+					require.True(t, ok, "unexpected item %T", item)
+					assert.Equal(t, "node", pod.Spec.NodeName)
+					names = append(names, pod.Name)
+					return nil
+				})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"pod-0", "pod-1", "pod-2"}, names)
+			assert.Equal(t, &expected.ListMeta, listMeta)
+		})
+	}
+}
+
+func TestStreamListStops(t *testing.T) {
+	srv := listServer(t, runtime.ContentTypeJSON, testPodList(3))
+	defer srv.Close()
+
+	stop := errors.New("stop")
+	var count int
+	_, err := listClient(t, srv, runtime.ContentTypeJSON).Get().StreamList(context.Background(),
+		func() runtime.Object { return &v1.Pod{} },
+		func(runtime.Object) error {
+			count++
+			return stop
+		})
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+}
+
+func TestStreamListInto(t *testing.T) {
+	expected := testPodList(2)
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+		t.Run(mediaType, func(t *testing.T) {
+			srv := listServer(t, mediaType, expected)
+			defer srv.Close()
+
+			list := &v1.PodList{}
+			require.NoError(t, listClient(t, srv, mediaType).Get().StreamListInto(context.Background(), list))
+			assert.Equal(t, expected, list)
+		})
+	}
+
+	t.Run("unstructured", func(t *testing.T) {
+		srv := listServer(t, runtime.ContentTypeJSON, expected)
+		defer srv.Close()
+
+		list := &unstructured.UnstructuredList{}
+		require.NoError(t, listClient(t, srv, runtime.ContentTypeJSON).Get().StreamListInto(context.Background(), list))
+		assert.Equal(t, "PodList", list.GetKind())
+		assert.Equal(t, "123", list.GetResourceVersion())
+		assert.Equal(t, "next", list.GetContinue())
+		require.Len(t, list.Items, 2)
+		assert.Equal(t, "Pod", list.Items[0].GetKind())
+		assert.Equal(t, "v1", list.Items[0].GetAPIVersion())
+		assert.Equal(t, "pod-1", list.Items[1].GetName())
+	})
+}
+
+func TestStreamListError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}`))
+	}))
+	defer srv.Close()
+
+	list := &v1.PodList{}
+	err := listClient(t, srv, runtime.ContentTypeJSON).Get().StreamListInto(context.Background(), list)
+	require.True(t, apierrors.IsForbidden(err), "unexpected error: %v", err)
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	body := `{"kind":"PodList","apiVersion":"v1","items":[` + strings.Repeat(`{"metadata":{"name":"pod"}},`, 99) + `{}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		if req.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	c := listClient(t, srv, runtime.ContentTypeJSON)
+	ctx := context.Background()
+
+	_, err := c.Get().MaxResponseSize(int64(len(body))).DoRaw(ctx)
+	require.NoError(t, err)
+	_, err = c.Get().MaxResponseSize(100).DoRaw(ctx)
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	err = c.Get().Param("chunked", "true").MaxResponseSize(100).Do(ctx).Error()
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+
+	var count int
+	_, err = c.Get().Param("chunked", "true").MaxResponseSize(1000).StreamList(ctx,
+		func() runtime.Object { return &v1.Pod{} },
+		func(runtime.Object) error {
+			count++
+			return nil
+		})
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Greater(t, count, 0, "expected the items before the limit to be decoded")
+
+	c.maxResponseSize = 100
+	err = c.Get().Do(ctx).Error()
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	_, err = c.Get().MaxResponseSize(0).DoRaw(ctx)
+	require.NoError(t, err)
+}
+
+func TestMaxRequestSize(t *testing.T) {
+	var count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count++
+	}))
+	defer srv.Close()
+	c := listClient(t, srv, runtime.ContentTypeJSON)
+
+	_, err := c.Post().Body([]byte("{}")).MaxRequestSize(1).DoRaw(context.Background())
+	require.ErrorIs(t, err, ErrRequestTooLarge)
+	assert.Equal(t, 0, count)
+	_, err = c.Post().Body([]byte("{}")).MaxRequestSize(2).DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
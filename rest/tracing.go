@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// traceParentHeader is the W3C Trace Context header which carries the
+// span of the caller to the server.
+const traceParentHeader = "traceparent"
+
+// RequestTracer creates spans for requests sent by a RESTClient. It allows
+// plugging in a tracing library like OpenTelemetry without client-go
+// depending on it.
+type RequestTracer interface {
+	// StartAttempt is called before each attempt to send a request,
+	// including retries. The returned context is used for the HTTP
+	// request of that attempt, so spans started by the transport become
+	// children of the attempt span.
+	StartAttempt(ctx context.Context, attempt RequestAttempt) (context.Context, RequestSpan)
+}
+
+// RequestSpan is the span of a single request attempt.
+type RequestSpan interface {
+	// TraceParent returns the W3C traceparent header value for the span,
+	// see [FormatTraceParent]. It is added to the request unless the
+	// request already has that header. An empty value disables
+	// propagation.
+	TraceParent() string
+
+	// End is called once the response headers were received or the
+	// attempt failed. For Watch and Stream the span therefore does not
+	// cover reading the response body.
+	End(resp *http.Response, err error)
+}
+
+// RequestAttempt describes a request attempt for which a span is started.
+type RequestAttempt struct {
+	// Verb is the HTTP method of the request.
+	Verb string
+	// URL is the URL of the request.
+	URL *url.URL
+	// Namespace, Resource, Subresource and Name are set for requests
+	// which were built with the corresponding Request methods.
+	Namespace   string
+	Resource    string
+	Subresource string
+	Name        string
+	// Retry is zero for the first attempt and counts the retries after it.
+	Retry int
+	// ThrottleWait is how long the client-side rate limiter delayed the
+	// attempt.
+	ThrottleWait time.Duration
+}
+
+// FormatTraceParent formats the W3C traceparent header value for the given
+// trace and span, as needed for [RequestSpan.TraceParent].
+func FormatTraceParent(traceID [16]byte, spanID [8]byte, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:]) + "-" + flags
+}
+
+type noopRequestSpan struct{}
+
+func (noopRequestSpan) TraceParent() string                { return "" }
+func (noopRequestSpan) End(resp *http.Response, err error) {}
+
+// startAttempt starts the span for the next attempt of r. retry is zero for
+// the first attempt.
+func (r *Request) startAttempt(ctx context.Context, retry int) (context.Context, RequestSpan) {
+	if r.tracer == nil {
+		return ctx, noopRequestSpan{}
+	}
+	return r.tracer.StartAttempt(ctx, RequestAttempt{
+		Verb:         r.verb,
+		URL:          r.URL(),
+		Namespace:    r.namespace,
+		Resource:     r.resource,
+		Subresource:  r.subresource,
+		Name:         r.resourceName,
+		Retry:        retry,
+		ThrottleWait: r.throttleWait,
+	})
+}
+
+// injectTraceParent adds the traceparent header of span to req. The header
+// of req is shared by all attempts, so it gets copied first.
+func injectTraceParent(req *http.Request, span RequestSpan) {
+	traceParent := span.TraceParent()
+	if traceParent == "" || req.Header.Get(traceParentHeader) != "" {
+		return
+	}
+	req.Header = req.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(traceParentHeader, traceParent)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+type recordingTracer struct {
+	lock     sync.Mutex
+	attempts []RequestAttempt
+	codes    []int
+}
+
+func (t *recordingTracer) StartAttempt(ctx context.Context, attempt RequestAttempt) (context.Context, RequestSpan) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.attempts = append(t.attempts, attempt)
+	spanID := [8]byte{byte(len(t.attempts))}
+	return ctx, &recordingSpan{tracer: t, traceParent: FormatTraceParent([16]byte{1}, spanID, true)}
+}
+
+type recordingSpan struct {
+	tracer      *recordingTracer
+	traceParent string
+}
+
+func (s *recordingSpan) TraceParent() string { return s.traceParent }
+
+func (s *recordingSpan) End(resp *http.Response, err error) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+	code := 0
+	if resp != nil {
+		code = resp.StatusCode
+	}
+	s.tracer.codes = append(s.tracer.codes, code)
+}
+
+func TestRequestTracing(t *testing.T) {
+	var traceParents []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceParents = append(traceParents, req.Header.Get("traceparent"))
+		if len(traceParents) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	tracer := &recordingTracer{}
+	c, err := RESTClientFor(&Config{
+		Host: testServer.URL,
+		ContentConfig: ContentConfig{
+			GroupVersion:         &v1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		Tracer: tracer,
+	})
+	require.NoError(t, err)
+
+	_, err = c.Get().Namespace("ns").Resource("pods").Name("foo").DoRaw(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"00-01000000000000000000000000000000-0100000000000000-01",
+		"00-01000000000000000000000000000000-0200000000000000-01",
+	}, traceParents)
+	assert.Equal(t, []int{http.StatusTooManyRequests, http.StatusOK}, tracer.codes)
+	require.Len(t, tracer.attempts, 2)
+	for i, attempt := range tracer.attempts {
+		assert.Equal(t, "GET", attempt.Verb)
+		assert.Equal(t, "ns", attempt.Namespace)
+		assert.Equal(t, "pods", attempt.Resource)
+		assert.Equal(t, "foo", attempt.Name)
+		assert.Equal(t, i, attempt.Retry)
+		assert.Equal(t, "/v1/namespaces/ns/pods/foo", attempt.URL.Path)
+	}
+}
+
+func TestRequestTracingKeepsTraceParent(t *testing.T) {
+	var traceParent string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceParent = req.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	c := testRESTClient(t, testServer)
+	c.tracer = &recordingTracer{}
+	_, err := c.Get().SetHeader("traceparent", "caller").DoRaw(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "caller", traceParent)
+}
@@ -24,6 +24,7 @@ import (
 	"k8s.io/client-go/pkg/apis/clientauthentication"
 	"k8s.io/client-go/plugin/pkg/client/auth/exec"
 	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
 )
 
 // HTTPClientFor returns an http.Client that will provide the authentication
@@ -65,7 +66,11 @@ func TransportFor(config *Config) (http.RoundTripper, error) {
 	if err != nil {
 		return nil, err
 	}
-	return transport.New(cfg)
+	rt, err := transport.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return wrapEndpoints(config, rt)
 }
 
 // HTTPWrappersForConfig wraps a round tripper with any relevant layered behavior from the
@@ -108,7 +113,9 @@ func (c *Config) TransportConfig() (*transport.Config, error) {
 			Groups:   c.Impersonate.Groups,
 			Extra:    c.Impersonate.Extra,
 		},
-		Proxy: c.Proxy,
+		Proxy:              c.Proxy,
+		Audit:              c.Audit,
+		ConnectionsPerHost: c.ConnectionsPerHost,
 	}
 
 	if c.Dial != nil {
@@ -127,6 +134,16 @@ func (c *Config) TransportConfig() (*transport.Config, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if c.ExecProvider.CacheCredentials {
+			// The cluster only tells apart the credentials which are cached
+			// on disk for different clusters. Without it, they are not
+			// cached, which must not fail the client.
+			var err error
+			cluster, err = ConfigToExecCluster(c)
+			if err != nil {
+				klog.V(2).Infof("Not caching exec plugin credentials on disk: %v", err)
+				cluster = nil
+			}
 		}
 		provider, err := exec.GetAuthenticator(c.ExecProvider, cluster)
 		if err != nil {
@@ -147,3 +147,27 @@ uml0obOEy+ON91k+SWTJ3ggmF/U=
 	}
 	wg.Wait()
 }
+
+func TestTransportConfigExecCacheCredentials(t *testing.T) {
+	config := &Config{
+		Host: "https://localhost:8443",
+		TLSClientConfig: TLSClientConfig{
+			CAFile: "/nonexistent/ca.crt",
+		},
+		ExecProvider: &clientcmdapi.ExecConfig{
+			Command:          "credential-plugin",
+			APIVersion:       "client.authentication.k8s.io/v1beta1",
+			CacheCredentials: true,
+		},
+	}
+	// The CA file is only needed for the disk cache, which is skipped.
+	if _, err := config.TransportConfig(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// The plugin needs the CA data of the cluster.
+	config.ExecProvider.ProvideClusterInfo = true
+	if _, err := config.TransportConfig(); err == nil || !strings.Contains(err.Error(), "failed to load CA bundle") {
+		t.Errorf("expected an error loading the CA bundle, got %v", err)
+	}
+}
@@ -118,6 +118,9 @@ type withRetry struct {
 	maxRetries int
 	attempts   int
 
+	// policy, if set, retries further errors and status codes.
+	policy *RetryPolicy
+
 	// retry after parameters that pertain to the attempt that is to
 	// be made soon, so as to enable 'Before' and 'After' to refer
 	// to the retry parameters.
@@ -165,15 +168,17 @@ func (r *withRetry) IsNextRetry(ctx context.Context, restReq *Request, httpReq *
 	}
 
 	// if the server returned an error, it takes precedence over the http response.
-	var errIsRetryable bool
-	if f != nil && err != nil && f.IsErrorRetryable(httpReq, err) {
-		errIsRetryable = true
-		// we have a retryable error, for which we will create an
-		// artificial "Retry-After" response.
-		resp = retryAfterResponse()
-	}
-	if err != nil && !errIsRetryable {
-		return false
+	if err != nil {
+		switch {
+		case r.policy.retriesError(httpReq, err):
+			return r.retryWithBackoff(ctx, restReq, resp, err)
+		case f != nil && f.IsErrorRetryable(httpReq, err):
+			// we have a retryable error, for which we will create an
+			// artificial "Retry-After" response.
+			resp = retryAfterResponse()
+		default:
+			return false
+		}
 	}
 
 	// if we are here, we have either a or b:
@@ -183,6 +188,13 @@ func (r *withRetry) IsNextRetry(ctx context.Context, restReq *Request, httpReq *
 	//     need to check if it is retryable
 	seconds, wait := checkWait(resp)
 	if !wait {
+		if err == nil && r.policy.retriesStatus(httpReq, resp.StatusCode) {
+			return r.retryWithBackoff(ctx, restReq, resp, err)
+		}
+		return false
+	}
+
+	if !r.withdrawRetryBudget(ctx, restReq) {
 		return false
 	}
 
@@ -192,6 +204,30 @@ func (r *withRetry) IsNextRetry(ctx context.Context, restReq *Request, httpReq *
 	return true
 }
 
+// retryWithBackoff prepares a retry which the policy asked for, after the
+// backoff delay of the policy.
+func (r *withRetry) retryWithBackoff(ctx context.Context, restReq *Request, resp *http.Response, err error) bool {
+	if !r.withdrawRetryBudget(ctx, restReq) {
+		return false
+	}
+	r.retryAfter.Wait = r.policy.backoff(r.attempts)
+	message := fmt.Sprintf("retries: %d, backoff: %v", r.attempts, r.retryAfter.Wait)
+	if err != nil {
+		r.retryAfter.Reason = fmt.Sprintf("%s - retry-reason: due to retryable error, error: %v", message, err)
+	} else {
+		r.retryAfter.Reason = fmt.Sprintf("%s - retry-reason: %d", message, resp.StatusCode)
+	}
+	return true
+}
+
+func (r *withRetry) withdrawRetryBudget(ctx context.Context, restReq *Request) bool {
+	if !restReq.retryBudget.withdraw() {
+		klog.FromContext(ctx).V(4).Info("Not retrying request, retry budget exhausted", "url", restReq.URL())
+		return false
+	}
+	return true
+}
+
 func (r *withRetry) Before(ctx context.Context, request *Request) error {
 	// If the request context is already canceled there
 	// is no need to retry.
@@ -206,6 +242,8 @@ func (r *withRetry) Before(ctx context.Context, request *Request) error {
 	// can apply these retry after parameters prior to the next attempt.
 	// 'r.retryAfter == nil' indicates that this is the very first attempt.
 	if r.retryAfter == nil {
+		request.retryBudget.deposit()
+
 		// we do a backoff sleep before the first attempt is made,
 		// (preserving current behavior).
 		if request.backoff != nil {
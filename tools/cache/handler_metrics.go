@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides abstractions for setting the provider (e.g., prometheus)
+// of the metrics for shared informer event handlers.
+
+package cache
+
+import (
+	"sync"
+)
+
+// HandlerMetricsProvider generates metrics for the event handlers of shared
+// informers. Metrics are only created for handlers which were given a name
+// through [HandlerOptions.Name].
+type HandlerMetricsProvider interface {
+	// NewPendingNotificationsMetric returns a gauge for the number of
+	// notifications waiting to be delivered to the handler.
+	NewPendingNotificationsMetric(name string) GaugeMetric
+	// NewNotificationLagMetric returns a summary of how long, in seconds,
+	// notifications waited before they were delivered to the handler.
+	NewNotificationLagMetric(name string) SummaryMetric
+	// NewCoalescedNotificationsMetric returns a counter for notifications
+	// which were merged into other pending notifications.
+	NewCoalescedNotificationsMetric(name string) CounterMetric
+}
+
+type noopHandlerMetricsProvider struct{}
+
+func (noopHandlerMetricsProvider) NewPendingNotificationsMetric(name string) GaugeMetric {
+	return noopMetric{}
+}
+func (noopHandlerMetricsProvider) NewNotificationLagMetric(name string) SummaryMetric {
+	return noopMetric{}
+}
+func (noopHandlerMetricsProvider) NewCoalescedNotificationsMetric(name string) CounterMetric {
+	return noopMetric{}
+}
+
+var handlerMetricsFactory = struct {
+	metricsProvider HandlerMetricsProvider
+	setProviders    sync.Once
+}{
+	metricsProvider: noopHandlerMetricsProvider{},
+}
+
+// SetHandlerMetricsProvider sets the metrics provider for event handlers.
+func SetHandlerMetricsProvider(metricsProvider HandlerMetricsProvider) {
+	handlerMetricsFactory.setProviders.Do(func() {
+		handlerMetricsFactory.metricsProvider = metricsProvider
+	})
+}
+
+// handlerMetrics are the metrics of a single processorListener. A nil
+// *handlerMetrics records nothing.
+type handlerMetrics struct {
+	pending   GaugeMetric
+	lag       SummaryMetric
+	coalesced CounterMetric
+}
+
+func newHandlerMetrics(name string) *handlerMetrics {
+	if name == "" {
+		return nil
+	}
+	provider := handlerMetricsFactory.metricsProvider
+	return &handlerMetrics{
+		pending:   provider.NewPendingNotificationsMetric(name),
+		lag:       provider.NewNotificationLagMetric(name),
+		coalesced: provider.NewCoalescedNotificationsMetric(name),
+	}
+}
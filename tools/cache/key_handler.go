@@ -0,0 +1,167 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// ObjectNameQueue receives the names of objects which need to be processed.
+// It is typically a workqueue.TypedInterface[ObjectName] or one of its
+// rate limiting variants.
+type ObjectNameQueue interface {
+	Add(item ObjectName)
+}
+
+// UpdatePredicate decides whether an update of an object is relevant.
+// Like handlers, predicates MUST NOT modify the objects they are given.
+type UpdatePredicate func(oldObj, newObj interface{}) bool
+
+// GenerationChanged is an UpdatePredicate which accepts updates that changed
+// the generation, i.e. the spec, of an object.
+func GenerationChanged(oldObj, newObj interface{}) bool {
+	oldMeta, newMeta, ok := accessors(oldObj, newObj)
+	return !ok || oldMeta.GetGeneration() != newMeta.GetGeneration()
+}
+
+// LabelsChanged is an UpdatePredicate which accepts updates that changed
+// the labels of an object.
+func LabelsChanged(oldObj, newObj interface{}) bool {
+	oldMeta, newMeta, ok := accessors(oldObj, newObj)
+	return !ok || !equality.Semantic.DeepEqual(oldMeta.GetLabels(), newMeta.GetLabels())
+}
+
+// AnnotationsChanged is an UpdatePredicate which accepts updates that
+// changed the annotations of an object.
+func AnnotationsChanged(oldObj, newObj interface{}) bool {
+	oldMeta, newMeta, ok := accessors(oldObj, newObj)
+	return !ok || !equality.Semantic.DeepEqual(oldMeta.GetAnnotations(), newMeta.GetAnnotations())
+}
+
+// AnyUpdatePredicate combines predicates such that an update is accepted
+// if at least one of them accepts it.
+func AnyUpdatePredicate(predicates ...UpdatePredicate) UpdatePredicate {
+	return func(oldObj, newObj interface{}) bool {
+		for _, predicate := range predicates {
+			if predicate(oldObj, newObj) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// accessors returns the object metadata of both objects, if they have any.
+func accessors(oldObj, newObj interface{}) (metav1.Object, metav1.Object, bool) {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return nil, nil, false
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return nil, nil, false
+	}
+	return oldMeta, newMeta, true
+}
+
+// KeyHandlerOptions configures a handler added with [AddKeyHandler].
+type KeyHandlerOptions struct {
+	HandlerOptions
+
+	// Filter, if set, limits the handler to objects for which it
+	// returns true. An update is relevant if either the old or the new
+	// object passes the filter.
+	Filter func(obj interface{}) bool
+
+	// UpdatePredicate, if set, limits the updates which cause an object
+	// to be queued. Adds and deletes are always queued.
+	UpdatePredicate UpdatePredicate
+}
+
+// AddKeyHandler adds an event handler to informer which adds the name of
+// each added, updated or deleted object to queue. Deleted objects whose
+// final state is unknown are handled as well.
+//
+// Because only the name is of interest, options.BufferPolicy defaults to
+// BufferCoalesce, which keeps the notifications that are pending while the
+// handler is busy at one per object. Updates which get merged are checked
+// against options.UpdatePredicate as one update from the oldest to the
+// latest state.
+func AddKeyHandler(informer SharedInformer, queue ObjectNameQueue, options KeyHandlerOptions) (ResourceEventHandlerRegistration, error) {
+	if options.BufferPolicy == nil {
+		options.BufferPolicy = &BufferPolicy{Mode: BufferCoalesce}
+	}
+	handler := &keyHandler{
+		logger:          ptr.Deref(options.Logger, klog.Background()),
+		queue:           queue,
+		filter:          options.Filter,
+		updatePredicate: options.UpdatePredicate,
+	}
+	return informer.AddEventHandlerWithOptions(handler, options.HandlerOptions)
+}
+
+// keyHandler is the ResourceEventHandler behind AddKeyHandler.
+type keyHandler struct {
+	logger          klog.Logger
+	queue           ObjectNameQueue
+	filter          func(obj interface{}) bool
+	updatePredicate UpdatePredicate
+}
+
+func (h *keyHandler) OnAdd(obj interface{}, isInInitialList bool) {
+	if h.filter != nil && !h.filter(obj) {
+		return
+	}
+	h.enqueue(obj)
+}
+
+func (h *keyHandler) OnUpdate(oldObj, newObj interface{}) {
+	if h.filter != nil && !h.filter(oldObj) && !h.filter(newObj) {
+		return
+	}
+	if h.updatePredicate != nil && !h.updatePredicate(oldObj, newObj) {
+		return
+	}
+	h.enqueue(newObj)
+}
+
+func (h *keyHandler) OnDelete(obj interface{}) {
+	if h.filter != nil {
+		filterObj := obj
+		if d, ok := obj.(DeletedFinalStateUnknown); ok {
+			filterObj = d.Obj
+		}
+		if !h.filter(filterObj) {
+			return
+		}
+	}
+	h.enqueue(obj)
+}
+
+func (h *keyHandler) enqueue(obj interface{}) {
+	name, err := DeletionHandlingObjectToName(obj)
+	if err != nil {
+		utilruntime.HandleErrorWithLogger(h.logger, err, "Failed to get the name of an object", "object", obj)
+		return
+	}
+	h.queue.Add(name)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+type fakeObjectNameQueue struct {
+	lock  sync.Mutex
+	items []ObjectName
+}
+
+func (q *fakeObjectNameQueue) Add(item ObjectName) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items = append(q.items, item)
+}
+
+func (q *fakeObjectNameQueue) get() []ObjectName {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]ObjectName(nil), q.items...)
+}
+
+func TestKeyHandler(t *testing.T) {
+	withGeneration := func(name string, generation int64, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Generation: generation, Labels: labels}}
+	}
+	selected := map[string]string{"app": "selected"}
+	nameA := NewObjectName("ns", "a")
+
+	testCases := []struct {
+		name     string
+		handler  keyHandler
+		events   func(h ResourceEventHandler)
+		expected []ObjectName
+	}{
+		{
+			name: "all events",
+			events: func(h ResourceEventHandler) {
+				h.OnAdd(withGeneration("a", 1, nil), true)
+				h.OnUpdate(withGeneration("a", 1, nil), withGeneration("a", 1, nil))
+				h.OnDelete(withGeneration("a", 1, nil))
+			},
+			expected: []ObjectName{nameA, nameA, nameA},
+		},
+		{
+			name: "tombstone",
+			events: func(h ResourceEventHandler) {
+				h.OnDelete(DeletedFinalStateUnknown{Key: "ns/a", Obj: withGeneration("a", 1, nil)})
+			},
+			expected: []ObjectName{nameA},
+		},
+		{
+			name:    "generation changed",
+			handler: keyHandler{updatePredicate: GenerationChanged},
+			events: func(h ResourceEventHandler) {
+				h.OnUpdate(withGeneration("a", 1, nil), withGeneration("a", 1, selected))
+				h.OnUpdate(withGeneration("a", 1, nil), withGeneration("a", 2, nil))
+			},
+			expected: []ObjectName{nameA},
+		},
+		{
+			name:    "generation or labels changed",
+			handler: keyHandler{updatePredicate: AnyUpdatePredicate(GenerationChanged, LabelsChanged)},
+			events: func(h ResourceEventHandler) {
+				h.OnUpdate(withGeneration("a", 1, nil), withGeneration("a", 1, selected))
+				h.OnUpdate(withGeneration("a", 1, selected), withGeneration("a", 1, selected))
+			},
+			expected: []ObjectName{nameA},
+		},
+		{
+			name: "filter",
+			handler: keyHandler{filter: func(obj interface{}) bool {
+				return obj.(*v1.Pod).Labels["app"] == "selected"
+			}},
+			events: func(h ResourceEventHandler) {
+				h.OnAdd(withGeneration("b", 1, nil), false)
+				h.OnAdd(withGeneration("a", 1, selected), false)
+				// No longer selected, still relevant.
+				h.OnUpdate(withGeneration("a", 1, selected), withGeneration("a", 1, nil))
+				h.OnUpdate(withGeneration("b", 1, nil), withGeneration("b", 1, nil))
+				h.OnDelete(DeletedFinalStateUnknown{Key: "ns/b", Obj: withGeneration("b", 1, nil)})
+				h.OnDelete(DeletedFinalStateUnknown{Key: "ns/a", Obj: withGeneration("a", 1, selected)})
+			},
+			expected: []ObjectName{nameA, nameA, nameA},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			queue := &fakeObjectNameQueue{}
+			handler := tc.handler
+			handler.logger = klog.Background()
+			handler.queue = queue
+			tc.events(&handler)
+			assert.Equal(t, tc.expected, queue.get())
+		})
+	}
+}
+
+func TestAddKeyHandler(t *testing.T) {
+	source := newFakeControllerSource(t)
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}})
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	queue := &fakeObjectNameQueue{}
+	handle, err := AddKeyHandler(informer, queue, KeyHandlerOptions{UpdatePredicate: GenerationChanged})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+	require.True(t, WaitForCacheSync(ctx.Done(), informer.HasSynced, handle.HasSynced))
+
+	source.Modify(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Labels: map[string]string{"a": "b"}}})
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b"}})
+	source.Delete(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}})
+
+	expected := []ObjectName{NewObjectName("ns", "a"), NewObjectName("ns", "b"), NewObjectName("ns", "a")}
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(queue.get()) >= len(expected), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expected, queue.get())
+}
+
+// blockingObjectNameQueue blocks the first Add until unblock is closed.
+type blockingObjectNameQueue struct {
+	fakeObjectNameQueue
+	blocked chan struct{}
+	unblock chan struct{}
+	once    sync.Once
+}
+
+func (q *blockingObjectNameQueue) Add(item ObjectName) {
+	q.once.Do(func() {
+		close(q.blocked)
+		<-q.unblock
+	})
+	q.fakeObjectNameQueue.Add(item)
+}
+
+func TestAddKeyHandlerCoalescesByDefault(t *testing.T) {
+	source := newFakeControllerSource(t)
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}})
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	queue := &blockingObjectNameQueue{blocked: make(chan struct{}), unblock: make(chan struct{})}
+	_, err := AddKeyHandler(informer, queue, KeyHandlerOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+	<-queue.blocked
+
+	// While the handler is busy, the updates get merged into one.
+	source.Modify(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 2}})
+	source.Modify(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 3}})
+	source.Modify(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 4}})
+	time.Sleep(100 * time.Millisecond)
+	close(queue.unblock)
+
+	expected := []ObjectName{NewObjectName("ns", "a"), NewObjectName("ns", "a"), NewObjectName("ns", "a")}
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(queue.get()) >= len(expected), nil
+	})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, expected, queue.get())
+}
+
+func TestAddKeyHandlerCoalescingRecreatedObject(t *testing.T) {
+	source := newFakeControllerSource(t)
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}})
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	queue := &blockingObjectNameQueue{blocked: make(chan struct{}), unblock: make(chan struct{})}
+	_, err := AddKeyHandler(informer, queue, KeyHandlerOptions{
+		HandlerOptions:  HandlerOptions{BufferPolicy: &BufferPolicy{Mode: BufferCoalesce}},
+		UpdatePredicate: GenerationChanged,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+	<-queue.blocked
+
+	// While the handler is busy, b waits for delivery and the notifications
+	// for a get buffered. The recreated object has the same generation, but
+	// must not be mistaken for an update which the predicate filters out.
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b"}})
+	source.Delete(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}})
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}})
+	time.Sleep(100 * time.Millisecond)
+	close(queue.unblock)
+
+	expected := []ObjectName{NewObjectName("ns", "a"), NewObjectName("ns", "b"), NewObjectName("ns", "a"), NewObjectName("ns", "a")}
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(queue.get()) >= len(expected), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expected, queue.get())
+}
@@ -0,0 +1,840 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// NewNamespacedInformerFunc constructs a SharedIndexInformer which only
+// lists and watches objects in the given namespace.
+type NewNamespacedInformerFunc func(namespace string) SharedIndexInformer
+
+// MultiNamespaceInformer is a SharedIndexInformer for namespaced objects
+// which is backed by one informer per namespace. It is meant for clients
+// which may only list and watch a known set of namespaces, for example
+// because RBAC forbids cluster-wide watches.
+//
+// All per-namespace informers are presented through a single merged
+// Indexer and a single stream of notifications per handler. Notifications
+// for one handler are delivered sequentially, even when they originate
+// from different namespaces. HasSynced only returns true once the informers
+// of all namespaces in the set have synced.
+//
+// Namespaces can be added and removed at any time. Handlers which are
+// registered when a namespace is removed receive a delete notification,
+// wrapped in DeletedFinalStateUnknown, for each object that was cached for
+// that namespace.
+type MultiNamespaceInformer interface {
+	SharedIndexInformer
+
+	// AddNamespace starts watching the given namespace. It is a no-op if
+	// the namespace is already watched. If the informer is running, the
+	// informer for the new namespace is started right away.
+	AddNamespace(namespace string) error
+	// RemoveNamespace stops watching the given namespace and drops its
+	// objects from the cache. It is a no-op if the namespace is not watched.
+	RemoveNamespace(namespace string) error
+	// Namespaces returns the sorted list of watched namespaces.
+	Namespaces() []string
+}
+
+// NewMultiNamespaceInformer creates a MultiNamespaceInformer which watches
+// the given namespaces, using newInformer to construct the informer for
+// each of them.
+func NewMultiNamespaceInformer(newInformer NewNamespacedInformerFunc, namespaces ...string) MultiNamespaceInformer {
+	m := &multiNamespaceInformer{
+		newInformer:   newInformer,
+		shards:        map[string]*namespaceShard{},
+		registrations: map[*multiNamespaceRegistration]struct{}{},
+		indexers:      Indexers{},
+	}
+	for _, namespace := range namespaces {
+		if _, exists := m.shards[namespace]; !exists {
+			m.shards[namespace] = m.newShardLocked(namespace)
+		}
+	}
+	return m
+}
+
+type multiNamespaceInformer struct {
+	newInformer NewNamespacedInformerFunc
+
+	// lock protects all fields below.
+	lock   sync.RWMutex
+	shards map[string]*namespaceShard
+	// registrations are the handlers which get added to every shard,
+	// including shards for namespaces which are added later.
+	registrations map[*multiNamespaceRegistration]struct{}
+	// indexers are the indexers added through AddIndexers, which are
+	// also added to shards created later.
+	indexers          Indexers
+	transform         TransformFunc
+	watchErrorHandler WatchErrorHandlerWithContext
+
+	// ctx is the context passed to RunWithContext, set once started.
+	ctx              context.Context
+	started, stopped bool
+	wg               sync.WaitGroup
+}
+
+// namespaceShard is the informer for a single namespace together with
+// the registrations of the merged handlers on it.
+type namespaceShard struct {
+	namespace     string
+	informer      SharedIndexInformer
+	registrations map[*multiNamespaceRegistration]*shardRegistration
+	cancel        context.CancelCauseFunc
+}
+
+// shardRegistration is the registration of a merged handler on one shard.
+// It forwards the notifications of the shard to the merged handler until
+// it gets detached.
+type shardRegistration struct {
+	registration *multiNamespaceRegistration
+	handle       ResourceEventHandlerRegistration
+
+	// lock makes sure that no notification gets forwarded once detach
+	// has returned.
+	lock     sync.Mutex
+	detached bool
+}
+
+func (s *shardRegistration) OnAdd(obj interface{}, isInInitialList bool) {
+	s.forward(addNotification{newObj: obj, isInInitialList: isInInitialList})
+}
+
+func (s *shardRegistration) OnUpdate(oldObj, newObj interface{}) {
+	s.forward(updateNotification{oldObj: oldObj, newObj: newObj})
+}
+
+func (s *shardRegistration) OnDelete(obj interface{}) {
+	s.forward(deleteNotification{oldObj: obj})
+}
+
+func (s *shardRegistration) forward(notification interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.detached {
+		s.registration.add(notification)
+	}
+}
+
+func (s *shardRegistration) detach() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.detached = true
+}
+
+// multiNamespaceRegistration is the handle returned for handlers added to
+// a multiNamespaceInformer. The notifications of all shards are merged
+// into a single processorListener, which delivers them to the handler
+// one at a time and applies the BufferPolicy of the handler.
+type multiNamespaceRegistration struct {
+	informer *multiNamespaceInformer
+	// shardOptions are the options used for the registrations on the
+	// shards. Buffering is done by listener instead.
+	shardOptions HandlerOptions
+	listener     *processorListener
+
+	// lock protects the state of listener. Notifications are only added
+	// while it runs.
+	lock             sync.RWMutex
+	running, stopped bool
+}
+
+var _ ResourceEventHandlerRegistration = &multiNamespaceRegistration{}
+
+// HasSynced reports whether the handler has been registered with, and has
+// been delivered all initial notifications from, every namespace.
+func (r *multiNamespaceRegistration) HasSynced() bool {
+	return r.listener.HasSynced()
+}
+
+// shardsSynced reports whether the registrations of the handler on all
+// shards have synced.
+func (r *multiNamespaceRegistration) shardsSynced() bool {
+	r.informer.lock.RLock()
+	defer r.informer.lock.RUnlock()
+
+	if !r.informer.started {
+		return false
+	}
+	for _, shard := range r.informer.shards {
+		s, ok := shard.registrations[r]
+		if !ok || !s.handle.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// add queues a notification for the handler. Notifications are dropped
+// if the listener is not running.
+func (r *multiNamespaceRegistration) add(notification interface{}) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.running && !r.stopped {
+		r.listener.add(notification)
+	}
+}
+
+func (r *multiNamespaceRegistration) start(wg *sync.WaitGroup) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.running || r.stopped {
+		return
+	}
+	r.running = true
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		r.listener.run()
+	}()
+	go func() {
+		defer wg.Done()
+		r.listener.pop()
+	}()
+}
+
+// stop stops the delivery of notifications. Pending notifications are
+// dropped.
+func (r *multiNamespaceRegistration) stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.running && !r.stopped {
+		close(r.listener.addCh) // Tell .pop() to stop. .pop() will tell .run() to stop
+	}
+	r.stopped = true
+}
+
+func (m *multiNamespaceInformer) newShardLocked(namespace string) *namespaceShard {
+	return &namespaceShard{
+		namespace:     namespace,
+		informer:      m.newInformer(namespace),
+		registrations: map[*multiNamespaceRegistration]*shardRegistration{},
+	}
+}
+
+// addToShardLocked registers a merged handler on a shard.
+func (m *multiNamespaceInformer) addToShardLocked(shard *namespaceShard, registration *multiNamespaceRegistration) error {
+	s := &shardRegistration{registration: registration}
+	handle, err := shard.informer.AddEventHandlerWithOptions(s, registration.shardOptions)
+	if err != nil {
+		return err
+	}
+	s.handle = handle
+	shard.registrations[registration] = s
+	return nil
+}
+
+// configureShardLocked copies the informer-wide settings and handlers to a
+// shard for a namespace which gets added after construction.
+func (m *multiNamespaceInformer) configureShardLocked(shard *namespaceShard) error {
+	var errs []error
+	if m.transform != nil {
+		errs = append(errs, shard.informer.SetTransform(m.transform))
+	}
+	if m.watchErrorHandler != nil {
+		errs = append(errs, shard.informer.SetWatchErrorHandlerWithContext(m.watchErrorHandler))
+	}
+	if len(m.indexers) > 0 {
+		errs = append(errs, shard.informer.AddIndexers(m.indexers))
+	}
+	for registration := range m.registrations {
+		errs = append(errs, m.addToShardLocked(shard, registration))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (m *multiNamespaceInformer) startShardLocked(shard *namespaceShard) {
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	shard.cancel = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		shard.informer.RunWithContext(ctx)
+	}()
+}
+
+func (m *multiNamespaceInformer) AddNamespace(namespace string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return fmt.Errorf("namespace %q was not added because the informer has stopped already", namespace)
+	}
+	if _, exists := m.shards[namespace]; exists {
+		return nil
+	}
+
+	shard := m.newShardLocked(namespace)
+	if err := m.configureShardLocked(shard); err != nil {
+		return fmt.Errorf("failed to set up informer for namespace %q: %w", namespace, err)
+	}
+	m.shards[namespace] = shard
+	if m.started {
+		m.startShardLocked(shard)
+	}
+	return nil
+}
+
+// RemoveNamespace does not wait for the informer of the namespace to stop.
+// The delete notifications for its objects are queued for the handlers
+// like any other notification, so it is safe to call from a handler.
+func (m *multiNamespaceInformer) RemoveNamespace(namespace string) error {
+	shard := func() *namespaceShard {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		shard := m.shards[namespace]
+		delete(m.shards, namespace)
+		return shard
+	}()
+	if shard == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, s := range shard.registrations {
+		errs = append(errs, shard.informer.RemoveEventHandler(s.handle))
+		s.detach()
+	}
+	if shard.cancel != nil {
+		shard.cancel(fmt.Errorf("namespace %q was removed", namespace))
+	}
+
+	// Tell the handlers which are still registered that the objects of the
+	// namespace are gone. Their final state is unknown because the watch
+	// was stopped rather than observing the deletion.
+	objs := shard.informer.GetIndexer().List()
+	for registration := range shard.registrations {
+		for _, obj := range objs {
+			key, err := DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			registration.add(deleteNotification{oldObj: DeletedFinalStateUnknown{Key: key, Obj: obj}})
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (m *multiNamespaceInformer) Namespaces() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	namespaces := make([]string, 0, len(m.shards))
+	for namespace := range m.shards {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// shardFor returns the shard responsible for the given namespace, or nil.
+func (m *multiNamespaceInformer) shardFor(namespace string) *namespaceShard {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.shards[namespace]
+}
+
+// allShards returns a snapshot of the current shards.
+func (m *multiNamespaceInformer) allShards() []*namespaceShard {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	shards := make([]*namespaceShard, 0, len(m.shards))
+	for _, shard := range m.shards {
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+func (m *multiNamespaceInformer) AddEventHandler(handler ResourceEventHandler) (ResourceEventHandlerRegistration, error) {
+	return m.AddEventHandlerWithOptions(handler, HandlerOptions{})
+}
+
+func (m *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration) (ResourceEventHandlerRegistration, error) {
+	return m.AddEventHandlerWithOptions(handler, HandlerOptions{ResyncPeriod: &resyncPeriod})
+}
+
+func (m *multiNamespaceInformer) AddEventHandlerWithOptions(handler ResourceEventHandler, options HandlerOptions) (ResourceEventHandlerRegistration, error) {
+	pendingNotifications, err := newNotificationBuffer(options.BufferPolicy, initialBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("handler %v was not added to shared informer: %w", handler, err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return nil, fmt.Errorf("handler %v was not added to shared informer because it has stopped already", handler)
+	}
+
+	registration := &multiNamespaceRegistration{
+		informer:     m,
+		shardOptions: HandlerOptions{Logger: options.Logger, ResyncPeriod: options.ResyncPeriod},
+	}
+	logger := ptr.Deref(options.Logger, klog.Background())
+	registration.listener = newProcessListener(logger, handler, 0, 0, time.Now(), pendingNotifications, newHandlerMetrics(options.Name), registration.shardsSynced)
+	if m.started {
+		registration.start(&m.wg)
+	}
+
+	var errs []error
+	for _, shard := range m.shards {
+		if err := m.addToShardLocked(shard, registration); err != nil {
+			errs = append(errs, fmt.Errorf("namespace %q: %w", shard.namespace, err))
+		}
+	}
+	if len(errs) > 0 {
+		for _, shard := range m.shards {
+			if s, ok := shard.registrations[registration]; ok {
+				_ = shard.informer.RemoveEventHandler(s.handle)
+				s.detach()
+				delete(shard.registrations, registration)
+			}
+		}
+		registration.stop()
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	m.registrations[registration] = struct{}{}
+	return registration, nil
+}
+
+func (m *multiNamespaceInformer) RemoveEventHandler(handle ResourceEventHandlerRegistration) error {
+	registration, ok := handle.(*multiNamespaceRegistration)
+	if !ok || registration.informer != m {
+		return fmt.Errorf("registration %v was not returned by this informer", handle)
+	}
+
+	removed, err := func() (bool, error) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		if _, exists := m.registrations[registration]; !exists {
+			return false, nil
+		}
+		delete(m.registrations, registration)
+		var errs []error
+		for _, shard := range m.shards {
+			if s, ok := shard.registrations[registration]; ok {
+				errs = append(errs, shard.informer.RemoveEventHandler(s.handle))
+				s.detach()
+				delete(shard.registrations, registration)
+			}
+		}
+		return true, utilerrors.NewAggregate(errs)
+	}()
+	// Stopping waits for a notification which is being added, so it must
+	// not be done while holding the lock which the handler might need.
+	if removed {
+		registration.stop()
+	}
+	return err
+}
+
+func (m *multiNamespaceInformer) GetStore() Store {
+	return m.GetIndexer()
+}
+
+func (m *multiNamespaceInformer) GetIndexer() Indexer {
+	return &multiNamespaceIndexer{informer: m}
+}
+
+func (m *multiNamespaceInformer) AddIndexers(indexers Indexers) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return fmt.Errorf("indexer was not added because it has stopped already")
+	}
+	for name := range indexers {
+		if _, exists := m.indexers[name]; exists {
+			return fmt.Errorf("indexer conflict: %v", name)
+		}
+	}
+	var errs []error
+	for _, shard := range m.shards {
+		errs = append(errs, shard.informer.AddIndexers(indexers))
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+	for name, indexFunc := range indexers {
+		m.indexers[name] = indexFunc
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) GetController() Controller {
+	return &multiNamespaceController{informer: m}
+}
+
+func (m *multiNamespaceInformer) Run(stopCh <-chan struct{}) {
+	m.RunWithContext(wait.ContextForChannel(stopCh))
+}
+
+func (m *multiNamespaceInformer) RunWithContext(ctx context.Context) {
+	defer utilruntime.HandleCrashWithContext(ctx)
+	logger := klog.FromContext(ctx)
+
+	started := func() bool {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		if m.started {
+			return false
+		}
+		m.ctx = ctx
+		m.started = true
+		for registration := range m.registrations {
+			registration.start(&m.wg)
+		}
+		for _, shard := range m.shards {
+			m.startShardLocked(shard)
+		}
+		return true
+	}()
+	if !started {
+		logger.Info("Warning: the multiNamespaceInformer has started, run more than once is not allowed")
+		return
+	}
+
+	<-ctx.Done()
+
+	registrations := func() []*multiNamespaceRegistration {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		m.stopped = true
+		registrations := make([]*multiNamespaceRegistration, 0, len(m.registrations))
+		for registration := range m.registrations {
+			registrations = append(registrations, registration)
+		}
+		return registrations
+	}()
+	for _, registration := range registrations {
+		registration.stop()
+	}
+	m.wg.Wait()
+}
+
+func (m *multiNamespaceInformer) HasSynced() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if !m.started {
+		return false
+	}
+	for _, shard := range m.shards {
+		if !shard.informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion always returns the empty string because each
+// namespace is watched separately and there is no single resource
+// version which describes the state of all of them.
+func (m *multiNamespaceInformer) LastSyncResourceVersion() string {
+	return ""
+}
+
+func (m *multiNamespaceInformer) SetWatchErrorHandler(handler WatchErrorHandler) error {
+	return m.SetWatchErrorHandlerWithContext(func(_ context.Context, r *Reflector, err error) {
+		handler(r, err)
+	})
+}
+
+func (m *multiNamespaceInformer) SetWatchErrorHandlerWithContext(handler WatchErrorHandlerWithContext) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.started {
+		return fmt.Errorf("informer has already started")
+	}
+	var errs []error
+	for _, shard := range m.shards {
+		errs = append(errs, shard.informer.SetWatchErrorHandlerWithContext(handler))
+	}
+	m.watchErrorHandler = handler
+	return utilerrors.NewAggregate(errs)
+}
+
+func (m *multiNamespaceInformer) SetTransform(handler TransformFunc) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.started {
+		return fmt.Errorf("informer has already started")
+	}
+	var errs []error
+	for _, shard := range m.shards {
+		errs = append(errs, shard.informer.SetTransform(handler))
+	}
+	m.transform = handler
+	return utilerrors.NewAggregate(errs)
+}
+
+func (m *multiNamespaceInformer) IsStopped() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.stopped
+}
+
+// multiNamespaceController implements the deprecated GetController result
+// for a multiNamespaceInformer.
+type multiNamespaceController struct {
+	informer *multiNamespaceInformer
+}
+
+func (c *multiNamespaceController) RunWithContext(context.Context) {
+}
+
+func (c *multiNamespaceController) Run(stopCh <-chan struct{}) {
+}
+
+func (c *multiNamespaceController) HasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+func (c *multiNamespaceController) LastSyncResourceVersion() string {
+	return ""
+}
+
+// multiNamespaceIndexer merges the indexers of all namespace shards.
+// Operations on a single object or key are routed to the shard of its
+// namespace, everything else is answered by all shards.
+type multiNamespaceIndexer struct {
+	informer *multiNamespaceInformer
+}
+
+var _ Indexer = &multiNamespaceIndexer{}
+
+func (i *multiNamespaceIndexer) indexerForObject(obj interface{}) (Indexer, error) {
+	if d, ok := obj.(DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return i.indexerForNamespace(accessor.GetNamespace())
+}
+
+func (i *multiNamespaceIndexer) indexerForNamespace(namespace string) (Indexer, error) {
+	shard := i.informer.shardFor(namespace)
+	if shard == nil {
+		return nil, fmt.Errorf("namespace %q is not watched by this informer", namespace)
+	}
+	return shard.informer.GetIndexer(), nil
+}
+
+func (i *multiNamespaceIndexer) Add(obj interface{}) error {
+	indexer, err := i.indexerForObject(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Add(obj)
+}
+
+func (i *multiNamespaceIndexer) Update(obj interface{}) error {
+	indexer, err := i.indexerForObject(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Update(obj)
+}
+
+func (i *multiNamespaceIndexer) Delete(obj interface{}) error {
+	indexer, err := i.indexerForObject(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Delete(obj)
+}
+
+func (i *multiNamespaceIndexer) List() []interface{} {
+	var list []interface{}
+	for _, shard := range i.informer.allShards() {
+		list = append(list, shard.informer.GetIndexer().List()...)
+	}
+	return list
+}
+
+func (i *multiNamespaceIndexer) ListKeys() []string {
+	var keys []string
+	for _, shard := range i.informer.allShards() {
+		keys = append(keys, shard.informer.GetIndexer().ListKeys()...)
+	}
+	return keys
+}
+
+func (i *multiNamespaceIndexer) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	indexer, err := i.indexerForObject(obj)
+	if err != nil {
+		return nil, false, nil
+	}
+	return indexer.Get(obj)
+}
+
+func (i *multiNamespaceIndexer) GetByKey(key string) (item interface{}, exists bool, err error) {
+	namespace, _, err := SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	shard := i.informer.shardFor(namespace)
+	if shard == nil {
+		return nil, false, nil
+	}
+	return shard.informer.GetIndexer().GetByKey(key)
+}
+
+// Replace replaces the content of every shard with the items of the
+// given list which belong to its namespace.
+func (i *multiNamespaceIndexer) Replace(list []interface{}, resourceVersion string) error {
+	byNamespace := map[string][]interface{}{}
+	for _, obj := range list {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		byNamespace[accessor.GetNamespace()] = append(byNamespace[accessor.GetNamespace()], obj)
+	}
+	shards := i.informer.allShards()
+	for _, shard := range shards {
+		delete(byNamespace, shard.namespace)
+	}
+	if len(byNamespace) > 0 {
+		return fmt.Errorf("namespaces %v are not watched by this informer", sets.List(sets.KeySet(byNamespace)))
+	}
+
+	var errs []error
+	for _, shard := range shards {
+		var items []interface{}
+		for _, obj := range list {
+			if accessor, _ := meta.Accessor(obj); accessor.GetNamespace() == shard.namespace {
+				items = append(items, obj)
+			}
+		}
+		errs = append(errs, shard.informer.GetIndexer().Replace(items, resourceVersion))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (i *multiNamespaceIndexer) Resync() error {
+	var errs []error
+	for _, shard := range i.informer.allShards() {
+		errs = append(errs, shard.informer.GetIndexer().Resync())
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (i *multiNamespaceIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var result []interface{}
+	for _, shard := range i.informer.allShards() {
+		items, err := shard.informer.GetIndexer().Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+func (i *multiNamespaceIndexer) IndexKeys(indexName, indexedValue string) ([]string, error) {
+	if indexName == NamespaceIndex {
+		indexer, err := i.indexerForNamespace(indexedValue)
+		if err != nil {
+			return []string{}, nil
+		}
+		return indexer.IndexKeys(indexName, indexedValue)
+	}
+
+	var result []string
+	for _, shard := range i.informer.allShards() {
+		keys, err := shard.informer.GetIndexer().IndexKeys(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+func (i *multiNamespaceIndexer) ListIndexFuncValues(indexName string) []string {
+	values := sets.New[string]()
+	for _, shard := range i.informer.allShards() {
+		values.Insert(shard.informer.GetIndexer().ListIndexFuncValues(indexName)...)
+	}
+	return sets.List(values)
+}
+
+func (i *multiNamespaceIndexer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	if indexName == NamespaceIndex {
+		indexer, err := i.indexerForNamespace(indexedValue)
+		if err != nil {
+			return []interface{}{}, nil
+		}
+		return indexer.ByIndex(indexName, indexedValue)
+	}
+
+	var result []interface{}
+	for _, shard := range i.informer.allShards() {
+		items, err := shard.informer.GetIndexer().ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+func (i *multiNamespaceIndexer) GetIndexers() Indexers {
+	shards := i.informer.allShards()
+	if len(shards) > 0 {
+		return shards[0].informer.GetIndexer().GetIndexers()
+	}
+
+	i.informer.lock.RLock()
+	defer i.informer.lock.RUnlock()
+	indexers := Indexers{}
+	for name, indexFunc := range i.informer.indexers {
+		indexers[name] = indexFunc
+	}
+	return indexers
+}
+
+func (i *multiNamespaceIndexer) AddIndexers(newIndexers Indexers) error {
+	return i.informer.AddIndexers(newIndexers)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fcache "k8s.io/client-go/tools/cache/testing"
+)
+
+type namespaceEventRecorder struct {
+	lock    sync.Mutex
+	added   sets.Set[string]
+	deleted sets.Set[string]
+}
+
+func newNamespaceEventRecorder() *namespaceEventRecorder {
+	return &namespaceEventRecorder{added: sets.New[string](), deleted: sets.New[string]()}
+}
+
+func (r *namespaceEventRecorder) handler() ResourceEventHandler {
+	return ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, _ := MetaNamespaceKeyFunc(obj)
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.added.Insert(key)
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, _ := DeletionHandlingMetaNamespaceKeyFunc(obj)
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.deleted.Insert(key)
+		},
+	}
+}
+
+func (r *namespaceEventRecorder) snapshot() (sets.Set[string], sets.Set[string]) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.added.Clone(), r.deleted.Clone()
+}
+
+func newTestMultiNamespaceInformer(t *testing.T, namespaces ...string) (MultiNamespaceInformer, map[string]*fcache.FakeControllerSource) {
+	sources := map[string]*fcache.FakeControllerSource{}
+	for _, namespace := range []string{"ns1", "ns2", "ns3"} {
+		source := newFakeControllerSource(t)
+		for _, name := range []string{"a", "b"} {
+			source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}})
+		}
+		sources[namespace] = source
+	}
+	informer := NewMultiNamespaceInformer(func(namespace string) SharedIndexInformer {
+		return NewSharedIndexInformer(sources[namespace], &v1.Pod{}, 0, Indexers{NamespaceIndex: MetaNamespaceIndexFunc})
+	}, namespaces...)
+	return informer, sources
+}
+
+func TestMultiNamespaceInformer(t *testing.T) {
+	informer, sources := newTestMultiNamespaceInformer(t, "ns1", "ns2")
+	recorder := newNamespaceEventRecorder()
+	handle, err := informer.AddEventHandler(recorder.handler())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+
+	require.True(t, WaitForCacheSync(ctx.Done(), informer.HasSynced, handle.HasSynced))
+	assert.Equal(t, []string{"ns1", "ns2"}, informer.Namespaces())
+	assert.ElementsMatch(t, []string{"ns1/a", "ns1/b", "ns2/a", "ns2/b"}, informer.GetIndexer().ListKeys())
+
+	pods, err := informer.GetIndexer().ByIndex(NamespaceIndex, "ns2")
+	require.NoError(t, err)
+	assert.Len(t, pods, 2)
+	pods, err = informer.GetIndexer().ByIndex(NamespaceIndex, "ns3")
+	require.NoError(t, err)
+	assert.Empty(t, pods)
+
+	_, exists, err := informer.GetIndexer().GetByKey("ns1/a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	_, exists, err = informer.GetIndexer().GetByKey("ns3/a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Adding a namespace while running starts watching it right away.
+	require.NoError(t, informer.AddNamespace("ns3"))
+	require.True(t, WaitForCacheSync(ctx.Done(), informer.HasSynced, handle.HasSynced))
+	assert.Len(t, informer.GetIndexer().List(), 6)
+
+	sources["ns3"].Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "c"}})
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		added, _ := recorder.snapshot()
+		return added.Has("ns3/c"), nil
+	}))
+
+	// Removing a namespace drops its objects and reports them as deleted.
+	require.NoError(t, informer.RemoveNamespace("ns1"))
+	assert.Equal(t, []string{"ns2", "ns3"}, informer.Namespaces())
+	assert.ElementsMatch(t, []string{"ns2/a", "ns2/b", "ns3/a", "ns3/b", "ns3/c"}, informer.GetIndexer().ListKeys())
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		_, deleted := recorder.snapshot()
+		return deleted.Len() == 2, nil
+	}))
+	added, deleted := recorder.snapshot()
+	assert.Equal(t, sets.New("ns1/a", "ns1/b", "ns2/a", "ns2/b", "ns3/a", "ns3/b", "ns3/c"), added)
+	assert.Equal(t, sets.New("ns1/a", "ns1/b"), deleted)
+}
+
+func TestMultiNamespaceInformerRemoveNamespaceFromHandler(t *testing.T) {
+	informer, _ := newTestMultiNamespaceInformer(t, "ns1", "ns2")
+	recorder := newNamespaceEventRecorder()
+	_, err := informer.AddEventHandler(recorder.handler())
+	require.NoError(t, err)
+	// The handler removes the namespace of the first object it sees. The
+	// delete notifications get queued behind the notification which is
+	// being handled instead of being delivered while it is.
+	var once sync.Once
+	_, err = informer.AddEventHandler(ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			once.Do(func() {
+				pod := obj.(*v1.Pod)
+				assert.NoError(t, informer.RemoveNamespace(pod.Namespace))
+				assert.NotContains(t, informer.Namespaces(), pod.Namespace)
+			})
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(informer.Namespaces()) == 1 && informer.HasSynced(), nil
+	}))
+	assert.Len(t, informer.GetIndexer().List(), 2)
+}
+
+func TestMultiNamespaceInformerRemoveHandler(t *testing.T) {
+	informer, _ := newTestMultiNamespaceInformer(t, "ns1", "ns2")
+	recorder := newNamespaceEventRecorder()
+	handle, err := informer.AddEventHandler(recorder.handler())
+	require.NoError(t, err)
+
+	require.NoError(t, informer.RemoveEventHandler(handle))
+	// Removing a handler is idempotent.
+	require.NoError(t, informer.RemoveEventHandler(handle))
+	require.NoError(t, informer.RemoveNamespace("ns1"))
+
+	_, deleted := recorder.snapshot()
+	assert.Empty(t, deleted)
+}
+
+func TestMultiNamespaceInformerStopped(t *testing.T) {
+	informer, _ := newTestMultiNamespaceInformer(t, "ns1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	informer.RunWithContext(ctx)
+
+	assert.True(t, informer.IsStopped())
+	_, err := informer.AddEventHandler(ResourceEventHandlerFuncs{})
+	require.Error(t, err)
+	require.Error(t, informer.AddNamespace("ns2"))
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/utils/buffer"
+)
+
+// BufferMode selects how the notifications for an event handler are
+// buffered while the handler is busy.
+type BufferMode int
+
+const (
+	// BufferUnbounded buffers every notification until the handler gets to
+	// it. A handler which does not keep up causes memory usage to grow
+	// without limit. This is the default.
+	BufferUnbounded BufferMode = iota
+
+	// BufferBlock buffers up to BufferPolicy.Size notifications. Once the
+	// buffer is full, the informer blocks until the handler has caught up.
+	// While it is blocked, no other handler of the same informer receives
+	// notifications either.
+	BufferBlock
+
+	// BufferCoalesce keeps at most one pending notification per object and
+	// merges later notifications for the same object into it, so that the
+	// handler only sees the latest state:
+	//
+	//   - add followed by update becomes an add of the latest object,
+	//   - update followed by update becomes an update from the oldest to the latest object,
+	//   - update followed by delete becomes a delete,
+	//   - add followed by delete is dropped completely,
+	//   - delete followed by add is delivered as both, so that the handler sees
+	//     that the object was recreated, and later notifications are merged
+	//     into the add.
+	//
+	// Up to BufferPolicy.Size objects can have a pending notification. Once
+	// that limit is reached, the informer blocks as with BufferBlock. A
+	// zero Size does not limit the number of objects, which are bounded
+	// by the size of the informer's cache anyway.
+	BufferCoalesce
+)
+
+// BufferPolicy configures the buffering of notifications for an event
+// handler. See [HandlerOptions.BufferPolicy].
+type BufferPolicy struct {
+	// Mode selects how notifications get buffered.
+	Mode BufferMode
+
+	// Size limits the number of buffered notifications for BufferBlock
+	// and the number of objects with pending notifications for
+	// BufferCoalesce. It must be positive for BufferBlock, zero means no
+	// limit for BufferCoalesce, and it is ignored for BufferUnbounded.
+	Size int
+}
+
+// notificationBuffer holds the notifications of a processorListener which
+// have been received from the sharedProcessor but not yet been handed over
+// to the handler.
+type notificationBuffer interface {
+	// WriteOne adds a notification. It returns the notifications which were
+	// superseded by the new one and thus will never be delivered.
+	WriteOne(notification interface{}) []interface{}
+	// ReadOne removes and returns the oldest notification.
+	ReadOne() (interface{}, bool)
+	// Len returns the number of buffered notifications.
+	Len() int
+	// Full reports whether the buffer must not grow any further.
+	Full() bool
+}
+
+// newNotificationBuffer constructs the buffer for the given policy. A nil
+// policy is the same as BufferUnbounded.
+func newNotificationBuffer(policy *BufferPolicy, initialSize int) (notificationBuffer, error) {
+	if policy == nil || policy.Mode == BufferUnbounded {
+		return &unboundedNotificationBuffer{
+			TypedRingGrowing: *buffer.NewTypedRingGrowing[interface{}](buffer.RingGrowingOptions{InitialSize: initialSize}),
+		}, nil
+	}
+	switch policy.Mode {
+	case BufferBlock:
+		if policy.Size <= 0 {
+			return nil, fmt.Errorf("buffer size must be positive, got %d", policy.Size)
+		}
+		return &boundedNotificationBuffer{
+			TypedRingGrowing: *buffer.NewTypedRingGrowing[interface{}](buffer.RingGrowingOptions{InitialSize: min(initialSize, policy.Size)}),
+			size:             policy.Size,
+		}, nil
+	case BufferCoalesce:
+		if policy.Size < 0 {
+			return nil, fmt.Errorf("buffer size must not be negative, got %d", policy.Size)
+		}
+		if policy.Size > 0 {
+			initialSize = min(initialSize, policy.Size)
+		}
+		return newCoalescingNotificationBuffer(policy.Size, initialSize), nil
+	default:
+		return nil, fmt.Errorf("unknown buffer mode %d", policy.Mode)
+	}
+}
+
+type unboundedNotificationBuffer struct {
+	buffer.TypedRingGrowing[interface{}]
+}
+
+func (b *unboundedNotificationBuffer) WriteOne(notification interface{}) []interface{} {
+	b.TypedRingGrowing.WriteOne(notification)
+	return nil
+}
+
+func (b *unboundedNotificationBuffer) Full() bool {
+	return false
+}
+
+type boundedNotificationBuffer struct {
+	buffer.TypedRingGrowing[interface{}]
+	size int
+}
+
+func (b *boundedNotificationBuffer) WriteOne(notification interface{}) []interface{} {
+	b.TypedRingGrowing.WriteOne(notification)
+	return nil
+}
+
+func (b *boundedNotificationBuffer) Full() bool {
+	return b.Len() >= b.size
+}
+
+// coalescingNotificationBuffer keeps one notification per object key, in
+// the order in which the objects were first queued.
+type coalescingNotificationBuffer struct {
+	// order holds the keys in the order in which they need to be
+	// delivered. Entries whose sequence number does not match the one in
+	// pending are stale and get skipped.
+	order   buffer.TypedRingGrowing[coalescingEntry]
+	pending map[string]coalescingEntry
+	// stale counts the stale entries in order. Once they outnumber the
+	// pending ones, order gets compacted, so that it doesn't grow while
+	// objects are added and deleted again before the handler gets to them.
+	stale int
+	// recreated counts the pending entries with a recreated notification.
+	recreated int
+	// next is the recreated notification of the entry which was read
+	// last, it gets delivered right after the delete.
+	next interface{}
+	size int
+	seq  uint64
+}
+
+type coalescingEntry struct {
+	key          string
+	seq          uint64
+	notification interface{}
+	// recreated is the add after a delete of the object, merged with
+	// the notifications which followed it.
+	recreated interface{}
+}
+
+func newCoalescingNotificationBuffer(size, initialSize int) *coalescingNotificationBuffer {
+	return &coalescingNotificationBuffer{
+		order:   *buffer.NewTypedRingGrowing[coalescingEntry](buffer.RingGrowingOptions{InitialSize: initialSize}),
+		pending: make(map[string]coalescingEntry),
+		size:    size,
+	}
+}
+
+func (b *coalescingNotificationBuffer) WriteOne(notification interface{}) []interface{} {
+	b.seq++
+	key, err := DeletionHandlingMetaNamespaceKeyFunc(notificationObject(notification))
+	if err != nil {
+		// Objects without a key cannot be coalesced, so treat them as unique.
+		key = fmt.Sprintf("\x00%d", b.seq)
+	}
+
+	if entry, exists := b.pending[key]; exists {
+		if entry.recreated != nil {
+			merged, dropped := mergeNotifications(entry.recreated, notification)
+			if merged == nil {
+				// Added and deleted again, the delete is still pending.
+				b.recreated--
+			}
+			entry.recreated = merged
+			b.pending[key] = entry
+			return dropped
+		}
+		if _, ok := unwrapTimedNotification(entry.notification).(deleteNotification); ok {
+			if _, ok := unwrapTimedNotification(notification).(addNotification); ok {
+				entry.recreated = notification
+				b.pending[key] = entry
+				b.recreated++
+				return nil
+			}
+		}
+		merged, dropped := mergeNotifications(entry.notification, notification)
+		if merged == nil {
+			delete(b.pending, key)
+			b.stale++
+			if b.stale > len(b.pending) {
+				b.compact()
+			}
+			return dropped
+		}
+		entry.notification = merged
+		b.pending[key] = entry
+		return dropped
+	}
+
+	entry := coalescingEntry{key: key, seq: b.seq, notification: notification}
+	b.pending[key] = entry
+	b.order.WriteOne(entry)
+	return nil
+}
+
+func (b *coalescingNotificationBuffer) ReadOne() (interface{}, bool) {
+	if b.next != nil {
+		next := b.next
+		b.next = nil
+		return next, true
+	}
+	for {
+		entry, ok := b.order.ReadOne()
+		if !ok {
+			return nil, false
+		}
+		current, exists := b.pending[entry.key]
+		if !exists || current.seq != entry.seq {
+			b.stale--
+			continue
+		}
+		delete(b.pending, entry.key)
+		if current.recreated != nil {
+			b.recreated--
+			b.next = current.recreated
+		}
+		return current.notification, true
+	}
+}
+
+// compact removes the stale entries from order.
+func (b *coalescingNotificationBuffer) compact() {
+	for i, n := 0, b.order.Len(); i < n; i++ {
+		entry, _ := b.order.ReadOne()
+		if current, exists := b.pending[entry.key]; exists && current.seq == entry.seq {
+			b.order.WriteOne(entry)
+		}
+	}
+	b.stale = 0
+}
+
+func (b *coalescingNotificationBuffer) Len() int {
+	n := len(b.pending) + b.recreated
+	if b.next != nil {
+		n++
+	}
+	return n
+}
+
+func (b *coalescingNotificationBuffer) Full() bool {
+	return b.size > 0 && len(b.pending) >= b.size
+}
+
+// timedNotification records when a notification was queued for a handler,
+// which is needed for the handler lag metric.
+type timedNotification struct {
+	notification interface{}
+	queued       time.Time
+}
+
+// notificationObject returns the object that a notification is about.
+func notificationObject(notification interface{}) interface{} {
+	switch n := notification.(type) {
+	case timedNotification:
+		return notificationObject(n.notification)
+	case addNotification:
+		return n.newObj
+	case updateNotification:
+		return n.newObj
+	case deleteNotification:
+		return n.oldObj
+	default:
+		return nil
+	}
+}
+
+// mergeNotifications combines two consecutive notifications for the same
+// object as documented for BufferCoalesce. It returns the merged
+// notification, which is nil if both cancel each other out, and those
+// input notifications which are not represented by the result.
+func mergeNotifications(prev, next interface{}) (interface{}, []interface{}) {
+	if timed, ok := prev.(timedNotification); ok {
+		// Keep the time of the older notification, the handler has been
+		// waiting for this object since then.
+		merged, dropped := mergeNotifications(timed.notification, unwrapTimedNotification(next))
+		if merged == nil {
+			return nil, dropped
+		}
+		return timedNotification{notification: merged, queued: timed.queued}, dropped
+	}
+	next = unwrapTimedNotification(next)
+
+	switch p := prev.(type) {
+	case addNotification:
+		switch n := next.(type) {
+		case updateNotification:
+			return addNotification{newObj: n.newObj, isInInitialList: p.isInInitialList}, []interface{}{n}
+		case deleteNotification:
+			return nil, []interface{}{p, n}
+		}
+	case updateNotification:
+		switch n := next.(type) {
+		case updateNotification:
+			return updateNotification{oldObj: p.oldObj, newObj: n.newObj}, []interface{}{n}
+		case deleteNotification:
+			return n, []interface{}{p}
+		}
+	}
+	// Anything else does not happen for a consistent stream of
+	// notifications. Keep the latest one.
+	return next, []interface{}{prev}
+}
+
+func unwrapTimedNotification(notification interface{}) interface{} {
+	if timed, ok := notification.(timedNotification); ok {
+		return timed.notification
+	}
+	return notification
+}
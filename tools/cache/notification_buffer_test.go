@@ -0,0 +1,258 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func testPod(name, resourceVersion string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, ResourceVersion: resourceVersion}}
+}
+
+func TestCoalescingNotificationBuffer(t *testing.T) {
+	a1, a2, a3 := testPod("a", "1"), testPod("a", "2"), testPod("a", "3")
+	b1 := testPod("b", "1")
+
+	testCases := []struct {
+		name          string
+		notifications []interface{}
+		expected      []interface{}
+		expectDropped int
+	}{
+		{
+			name:          "add and update",
+			notifications: []interface{}{addNotification{newObj: a1, isInInitialList: true}, updateNotification{oldObj: a1, newObj: a2}},
+			expected:      []interface{}{addNotification{newObj: a2, isInInitialList: true}},
+			expectDropped: 1,
+		},
+		{
+			name:          "updates",
+			notifications: []interface{}{updateNotification{oldObj: a1, newObj: a2}, updateNotification{oldObj: a2, newObj: a3}},
+			expected:      []interface{}{updateNotification{oldObj: a1, newObj: a3}},
+			expectDropped: 1,
+		},
+		{
+			name:          "update and delete",
+			notifications: []interface{}{updateNotification{oldObj: a1, newObj: a2}, deleteNotification{oldObj: a2}},
+			expected:      []interface{}{deleteNotification{oldObj: a2}},
+			expectDropped: 1,
+		},
+		{
+			name:          "add and delete",
+			notifications: []interface{}{addNotification{newObj: a1}, addNotification{newObj: b1}, deleteNotification{oldObj: a1}},
+			expected:      []interface{}{addNotification{newObj: b1}},
+			expectDropped: 2,
+		},
+		{
+			name:          "delete and add",
+			notifications: []interface{}{deleteNotification{oldObj: DeletedFinalStateUnknown{Key: "ns/a", Obj: a1}}, addNotification{newObj: a2}},
+			expected:      []interface{}{deleteNotification{oldObj: DeletedFinalStateUnknown{Key: "ns/a", Obj: a1}}, addNotification{newObj: a2}},
+		},
+		{
+			name:          "delete, add and update",
+			notifications: []interface{}{deleteNotification{oldObj: a1}, addNotification{newObj: a2}, addNotification{newObj: b1}, updateNotification{oldObj: a2, newObj: a3}},
+			expected:      []interface{}{deleteNotification{oldObj: a1}, addNotification{newObj: a3}, addNotification{newObj: b1}},
+			expectDropped: 1,
+		},
+		{
+			name:          "delete, add and delete",
+			notifications: []interface{}{deleteNotification{oldObj: a1}, addNotification{newObj: a2}, deleteNotification{oldObj: a2}},
+			expected:      []interface{}{deleteNotification{oldObj: a1}},
+			expectDropped: 2,
+		},
+		{
+			name:          "add, delete and add again keeps order of the second add",
+			notifications: []interface{}{addNotification{newObj: a1}, deleteNotification{oldObj: a1}, addNotification{newObj: b1}, addNotification{newObj: a2}},
+			expected:      []interface{}{addNotification{newObj: b1}, addNotification{newObj: a2}},
+			expectDropped: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := newNotificationBuffer(&BufferPolicy{Mode: BufferCoalesce, Size: 10}, 1)
+			require.NoError(t, err)
+
+			dropped := 0
+			for _, notification := range tc.notifications {
+				dropped += len(b.WriteOne(notification))
+			}
+			assert.Equal(t, tc.expectDropped, dropped)
+			assert.Equal(t, len(tc.expected), b.Len())
+
+			var actual []interface{}
+			for {
+				notification, ok := b.ReadOne()
+				if !ok {
+					break
+				}
+				actual = append(actual, notification)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestCoalescingNotificationBufferChurn(t *testing.T) {
+	b := newCoalescingNotificationBuffer(2, 1)
+	b.WriteOne(addNotification{newObj: testPod("a", "1")})
+
+	// objects which are added and deleted again must not pile up
+	for i := 0; i < 1000; i++ {
+		b.WriteOne(addNotification{newObj: testPod("b", "1")})
+		b.WriteOne(deleteNotification{oldObj: testPod("b", "1")})
+	}
+	assert.Equal(t, 1, b.Len())
+	assert.LessOrEqual(t, b.order.Len(), 2*b.Len()+1)
+	assert.False(t, b.Full())
+
+	notification, ok := b.ReadOne()
+	require.True(t, ok)
+	assert.Equal(t, addNotification{newObj: testPod("a", "1")}, notification)
+	_, ok = b.ReadOne()
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.stale)
+}
+
+func TestNotificationBufferFull(t *testing.T) {
+	_, err := newNotificationBuffer(&BufferPolicy{Mode: BufferBlock}, 1)
+	require.Error(t, err)
+
+	b, err := newNotificationBuffer(&BufferPolicy{Mode: BufferBlock, Size: 2}, 1)
+	require.NoError(t, err)
+	b.WriteOne(addNotification{newObj: testPod("a", "1")})
+	assert.False(t, b.Full())
+	b.WriteOne(updateNotification{newObj: testPod("a", "2")})
+	assert.True(t, b.Full())
+
+	b, err = newNotificationBuffer(&BufferPolicy{Mode: BufferCoalesce, Size: 2}, 1)
+	require.NoError(t, err)
+	b.WriteOne(addNotification{newObj: testPod("a", "1")})
+	b.WriteOne(updateNotification{newObj: testPod("a", "2")})
+	assert.False(t, b.Full())
+	b.WriteOne(addNotification{newObj: testPod("b", "1")})
+	assert.True(t, b.Full())
+}
+
+func TestSharedInformerCoalescingHandler(t *testing.T) {
+	source := newFakeControllerSource(t)
+	source.Add(testPod("a", ""))
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	// The handler blocks until released, so that notifications pile up.
+	release := make(chan struct{})
+	var lock sync.Mutex
+	var received []string
+	handler := ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			<-release
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			received = append(received, newObj.(*v1.Pod).Labels["version"])
+		},
+	}
+	handle, err := informer.AddEventHandlerWithOptions(handler, HandlerOptions{BufferPolicy: &BufferPolicy{Mode: BufferCoalesce, Size: 10}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+
+	require.True(t, WaitForCacheSync(ctx.Done(), informer.HasSynced))
+	// The first notification for the object is being handled, so
+	// the updates all end up in the buffer.
+	for _, version := range []string{"1", "2", "3"} {
+		pod := testPod("a", "")
+		pod.Labels = map[string]string{"version": version}
+		source.Modify(pod)
+	}
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return informer.GetStore().List()[0].(*v1.Pod).Labels["version"] == "3", nil
+	}))
+	close(release)
+
+	require.True(t, WaitForCacheSync(ctx.Done(), handle.HasSynced))
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) > 0 && received[len(received)-1] == "3", nil
+	}))
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Less(t, len(received), 3, "updates should have been coalesced")
+}
+
+func TestSharedInformerBlockingHandler(t *testing.T) {
+	source := newFakeControllerSource(t)
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	release := make(chan struct{})
+	var lock sync.Mutex
+	added := 0
+	handler := ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			<-release
+			lock.Lock()
+			defer lock.Unlock()
+			added++
+		},
+	}
+	_, err := informer.AddEventHandlerWithOptions(handler, HandlerOptions{BufferPolicy: &BufferPolicy{Mode: BufferBlock, Size: 1}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg wait.Group
+	wg.StartWithContext(ctx, informer.RunWithContext)
+	defer wg.Wait()
+	defer cancel()
+	require.True(t, WaitForCacheSync(ctx.Done(), informer.HasSynced))
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		source.Add(testPod(name, ""))
+	}
+	// One notification is being handled, one is waiting to be handed over
+	// and one is buffered. The informer is blocked on the next one and
+	// cannot store the remaining pod.
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(informer.GetStore().List()) == 4, nil
+	}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, informer.GetStore().List(), 4)
+
+	close(release)
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return added == 5, nil
+	}))
+	assert.Len(t, informer.GetStore().List(), 5)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// NewReferenceCountedInformer wraps informer such that onUnused gets called
+// each time RemoveEventHandler removes the last event handler that was
+// added through the returned informer. Informer factories use this to stop
+// informers which nobody is interested in anymore.
+//
+// onUnused is called without any locks held.
+func NewReferenceCountedInformer(informer SharedIndexInformer, onUnused func()) SharedIndexInformer {
+	return &referenceCountedInformer{
+		SharedIndexInformer: informer,
+		onUnused:            onUnused,
+		handles:             map[ResourceEventHandlerRegistration]struct{}{},
+	}
+}
+
+type referenceCountedInformer struct {
+	SharedIndexInformer
+	onUnused func()
+
+	lock    sync.Mutex
+	handles map[ResourceEventHandlerRegistration]struct{}
+}
+
+func (r *referenceCountedInformer) AddEventHandler(handler ResourceEventHandler) (ResourceEventHandlerRegistration, error) {
+	return r.track(r.SharedIndexInformer.AddEventHandler(handler))
+}
+
+func (r *referenceCountedInformer) AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration) (ResourceEventHandlerRegistration, error) {
+	return r.track(r.SharedIndexInformer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod))
+}
+
+func (r *referenceCountedInformer) AddEventHandlerWithOptions(handler ResourceEventHandler, options HandlerOptions) (ResourceEventHandlerRegistration, error) {
+	return r.track(r.SharedIndexInformer.AddEventHandlerWithOptions(handler, options))
+}
+
+func (r *referenceCountedInformer) track(handle ResourceEventHandlerRegistration, err error) (ResourceEventHandlerRegistration, error) {
+	if err != nil {
+		return handle, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.handles[handle] = struct{}{}
+	return handle, nil
+}
+
+func (r *referenceCountedInformer) RemoveEventHandler(handle ResourceEventHandlerRegistration) error {
+	if err := r.SharedIndexInformer.RemoveEventHandler(handle); err != nil {
+		return err
+	}
+
+	unused := func() bool {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		if _, ok := r.handles[handle]; !ok {
+			return false
+		}
+		delete(r.handles, handle)
+		return len(r.handles) == 0
+	}()
+	if unused {
+		r.onUnused()
+	}
+	return nil
+}
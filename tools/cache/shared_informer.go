@@ -28,7 +28,6 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache/synctrack"
-	"k8s.io/utils/buffer"
 	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 
@@ -261,6 +260,15 @@ type HandlerOptions struct {
 	//
 	// If nil, the default resync period of the shared informer is used.
 	ResyncPeriod *time.Duration
+
+	// BufferPolicy limits how many notifications get buffered for the
+	// handler while it is busy with earlier ones. If nil, all
+	// notifications are buffered.
+	BufferPolicy *BufferPolicy
+
+	// Name identifies the handler in metrics. No metrics are recorded
+	// for handlers without a name. See [SetHandlerMetricsProvider].
+	Name string
 }
 
 // SharedIndexInformer provides add and get Indexers ability based on SharedInformer.
@@ -692,7 +700,11 @@ func (s *sharedIndexInformer) AddEventHandlerWithOptions(handler ResourceEventHa
 		}
 	}
 
-	listener := newProcessListener(logger, handler, resyncPeriod, determineResyncPeriod(logger, resyncPeriod, s.resyncCheckPeriod), s.clock.Now(), initialBufferSize, s.HasSynced)
+	pendingNotifications, err := newNotificationBuffer(options.BufferPolicy, initialBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("handler %v was not added to shared informer: %w", handler, err)
+	}
+	listener := newProcessListener(logger, handler, resyncPeriod, determineResyncPeriod(logger, resyncPeriod, s.resyncCheckPeriod), s.clock.Now(), pendingNotifications, newHandlerMetrics(options.Name), s.HasSynced)
 
 	if !s.started {
 		return s.processor.addListener(listener), nil
@@ -943,10 +955,14 @@ func (p *sharedProcessor) resyncCheckPeriodChanged(logger klog.Logger, resyncChe
 
 // processorListener relays notifications from a sharedProcessor to
 // one ResourceEventHandler --- using two goroutines, two unbuffered
-// channels, and an unbounded ring buffer.  The `add(notification)`
+// channels, and a notification buffer.  The `add(notification)`
 // function sends the given notification to `addCh`.  One goroutine
 // runs `pop()`, which pumps notifications from `addCh` to `nextCh`
-// using storage in the ring buffer while `nextCh` is not keeping up.
+// using storage in the buffer while `nextCh` is not keeping up.
+// Depending on the handler's BufferPolicy, the buffer is unbounded,
+// bounded, or coalesces notifications for the same object; once a
+// bounded buffer is full, `pop()` stops receiving from `addCh`, which
+// blocks `add(notification)`.
 // Another goroutine runs `run()`, which receives notifications from
 // `nextCh` and synchronously invokes the appropriate handler method.
 //
@@ -961,12 +977,14 @@ type processorListener struct {
 
 	syncTracker *synctrack.SingleFileTracker
 
-	// pendingNotifications is an unbounded ring buffer that holds all notifications not yet distributed.
-	// There is one per listener, but a failing/stalled listener will have infinite pendingNotifications
+	// pendingNotifications holds all notifications not yet distributed.
+	// There is one per listener. Unless the handler was added with a bounded
+	// BufferPolicy, a failing/stalled listener will have infinite pendingNotifications
 	// added until we OOM.
-	// TODO: This is no worse than before, since reflectors were backed by unbounded DeltaFIFOs, but
-	// we should try to do something better.
-	pendingNotifications buffer.RingGrowing
+	pendingNotifications notificationBuffer
+
+	// metrics is nil if the handler has no name.
+	metrics *handlerMetrics
 
 	// requestedResyncPeriod is how frequently the listener wants a
 	// full resync from the shared informer, but modified by two
@@ -997,14 +1015,15 @@ func (p *processorListener) HasSynced() bool {
 	return p.syncTracker.HasSynced()
 }
 
-func newProcessListener(logger klog.Logger, handler ResourceEventHandler, requestedResyncPeriod, resyncPeriod time.Duration, now time.Time, bufferSize int, hasSynced func() bool) *processorListener {
+func newProcessListener(logger klog.Logger, handler ResourceEventHandler, requestedResyncPeriod, resyncPeriod time.Duration, now time.Time, pendingNotifications notificationBuffer, metrics *handlerMetrics, hasSynced func() bool) *processorListener {
 	ret := &processorListener{
 		logger:                logger,
 		nextCh:                make(chan interface{}),
 		addCh:                 make(chan interface{}),
 		handler:               handler,
 		syncTracker:           &synctrack.SingleFileTracker{UpstreamHasSynced: hasSynced},
-		pendingNotifications:  *buffer.NewRingGrowing(bufferSize),
+		pendingNotifications:  pendingNotifications,
+		metrics:               metrics,
 		requestedResyncPeriod: requestedResyncPeriod,
 		resyncPeriod:          resyncPeriod,
 	}
@@ -1018,6 +1037,9 @@ func (p *processorListener) add(notification interface{}) {
 	if a, ok := notification.(addNotification); ok && a.isInInitialList {
 		p.syncTracker.Start()
 	}
+	if p.metrics != nil {
+		notification = timedNotification{notification: notification, queued: time.Now()}
+	}
 	p.addCh <- notification
 }
 
@@ -1028,6 +1050,11 @@ func (p *processorListener) pop() {
 	var nextCh chan<- interface{}
 	var notification interface{}
 	for {
+		// Stop accepting notifications while a bounded buffer is full.
+		addCh := p.addCh
+		if p.pendingNotifications.Full() {
+			addCh = nil
+		}
 		select {
 		case nextCh <- notification:
 			// Notification dispatched
@@ -1036,7 +1063,7 @@ func (p *processorListener) pop() {
 			if !ok { // Nothing to pop
 				nextCh = nil // Disable this select case
 			}
-		case notificationToAdd, ok := <-p.addCh:
+		case notificationToAdd, ok := <-addCh:
 			if !ok {
 				return
 			}
@@ -1045,9 +1072,30 @@ func (p *processorListener) pop() {
 				notification = notificationToAdd
 				nextCh = p.nextCh
 			} else { // There is already a notification waiting to be dispatched
-				p.pendingNotifications.WriteOne(notificationToAdd)
+				for _, dropped := range p.pendingNotifications.WriteOne(notificationToAdd) {
+					p.dropped(dropped)
+				}
 			}
 		}
+		if p.metrics != nil {
+			pending := p.pendingNotifications.Len()
+			if notification != nil {
+				pending++
+			}
+			p.metrics.pending.Set(float64(pending))
+		}
+	}
+}
+
+// dropped is called for notifications which were merged into another
+// notification by a coalescing buffer and thus never get delivered.
+func (p *processorListener) dropped(notification interface{}) {
+	if p.metrics != nil {
+		p.metrics.coalesced.Inc()
+	}
+	if a, ok := notification.(addNotification); ok && a.isInInitialList {
+		// The initial list has been delivered as far as this notification is concerned.
+		p.syncTracker.Finished()
 	}
 }
 
@@ -1069,6 +1117,10 @@ func (p *processorListener) run() {
 			sleepAfterCrash = true
 			defer utilruntime.HandleCrashWithLogger(p.logger)
 
+			if timed, ok := next.(timedNotification); ok {
+				p.metrics.lag.Observe(time.Since(timed.queued).Seconds())
+				next = timed.notification
+			}
 			switch notification := next.(type) {
 			case updateNotification:
 				p.handler.OnUpdate(notification.oldObj, notification.newObj)
@@ -72,6 +72,9 @@ type Cluster struct {
 	LocationOfOrigin string `json:"-"`
 	// Server is the address of the kubernetes cluster (https://hostname:port).
 	Server string `json:"server"`
+	// Endpoints lists further addresses of the same cluster, which are used when Server cannot be reached.
+	// +optional
+	Endpoints []string `json:"endpoints,omitempty"`
 	// TLSServerName is used to check server certificate. If TLSServerName is empty, the hostname used to contact the server is used.
 	// +optional
 	TLSServerName string `json:"tls-server-name,omitempty"`
@@ -95,6 +98,13 @@ type Cluster struct {
 	// attach, port forward).
 	// +optional
 	ProxyURL string `json:"proxy-url,omitempty"`
+	// UnixSocket is the path of a Unix domain socket on which the server listens. Connections are made over the socket,
+	// while Server is still used for the URL of requests and for verifying the certificate of the server.
+	// +optional
+	UnixSocket string `json:"unix-socket,omitempty"`
+	// SSHTunnel configures an SSH bastion host through which the server is reached.
+	// +optional
+	SSHTunnel *SSHTunnel `json:"ssh-tunnel,omitempty"`
 	// DisableCompression allows client to opt-out of response compression for all requests to the server. This is useful
 	// to speed up requests (specifically lists) when client-server network bandwidth is ample, by saving time on
 	// compression (server-side) and decompression (client-side): https://github.com/kubernetes/kubernetes/issues/112296.
@@ -105,6 +115,24 @@ type Cluster struct {
 	Extensions map[string]runtime.Object `json:"extensions,omitempty"`
 }
 
+// SSHTunnel describes an SSH bastion host through which a cluster is reached. The connections are forwarded by the
+// OpenSSH client, so the ssh_config of the user applies.
+type SSHTunnel struct {
+	// Host is the bastion host, as [user@]host[:port].
+	Host string `json:"host"`
+	// IdentityFile is the path to the private key to authenticate with. If empty, the keys of the SSH agent and the
+	// default identities are used.
+	// +optional
+	IdentityFile string `json:"identity-file,omitempty"`
+	// AgentSocket is the path to the socket of the SSH agent. If empty, the SSH_AUTH_SOCK environment variable is used.
+	// +optional
+	AgentSocket string `json:"agent-socket,omitempty"`
+	// KnownHostsFile is the path to the file with the public keys of the trusted bastion hosts. If empty, the default
+	// files are used. Unknown hosts are always rejected.
+	// +optional
+	KnownHostsFile string `json:"known-hosts-file,omitempty"`
+}
+
 // AuthInfo contains information that describes identity information.  This is use to tell the kubernetes cluster who you are.
 type AuthInfo struct {
 	// LocationOfOrigin indicates where this object came from.  It is used for round tripping config post-merge, but never serialized.
@@ -269,6 +297,15 @@ type ExecConfig struct {
 	// +optional
 	InteractiveMode ExecInteractiveMode `json:"interactiveMode,omitempty"`
 
+	// CacheCredentials determines whether the credentials returned by this exec
+	// plugin are cached on disk, so that processes with the same exec config and
+	// cluster share them until they expire instead of each executing the plugin,
+	// e.g. short-lived kubectl invocations. Only credentials with an
+	// expirationTimestamp are cached, in ~/.kube/cache/exec-credentials, readable
+	// by the user only. By default, it is set to false.
+	// +optional
+	CacheCredentials bool `json:"cacheCredentials,omitempty"`
+
 	// StdinUnavailable indicates whether the exec authenticator can pass standard
 	// input through to this exec plugin. For example, a higher level entity might be using
 	// standard input for something else and therefore it would not be safe for the exec
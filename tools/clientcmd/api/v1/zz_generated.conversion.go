@@ -116,6 +116,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*SSHTunnel)(nil), (*api.SSHTunnel)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_SSHTunnel_To_api_SSHTunnel(a.(*SSHTunnel), b.(*api.SSHTunnel), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*api.SSHTunnel)(nil), (*SSHTunnel)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_api_SSHTunnel_To_v1_SSHTunnel(a.(*api.SSHTunnel), b.(*SSHTunnel), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*map[string]*api.AuthInfo)(nil), (*[]NamedAuthInfo)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_Map_string_To_Pointer_api_AuthInfo_To_Slice_v1_NamedAuthInfo(a.(*map[string]*api.AuthInfo), b.(*[]NamedAuthInfo), scope)
 	}); err != nil {
@@ -252,11 +262,14 @@ func Convert_api_AuthProviderConfig_To_v1_AuthProviderConfig(in *api.AuthProvide
 
 func autoConvert_v1_Cluster_To_api_Cluster(in *Cluster, out *api.Cluster, s conversion.Scope) error {
 	out.Server = in.Server
+	out.Endpoints = *(*[]string)(unsafe.Pointer(&in.Endpoints))
 	out.TLSServerName = in.TLSServerName
 	out.InsecureSkipTLSVerify = in.InsecureSkipTLSVerify
 	out.CertificateAuthority = in.CertificateAuthority
 	out.CertificateAuthorityData = *(*[]byte)(unsafe.Pointer(&in.CertificateAuthorityData))
 	out.ProxyURL = in.ProxyURL
+	out.UnixSocket = in.UnixSocket
+	out.SSHTunnel = (*api.SSHTunnel)(unsafe.Pointer(in.SSHTunnel))
 	out.DisableCompression = in.DisableCompression
 	if err := Convert_Slice_v1_NamedExtension_To_Map_string_To_runtime_Object(&in.Extensions, &out.Extensions, s); err != nil {
 		return err
@@ -272,11 +285,14 @@ func Convert_v1_Cluster_To_api_Cluster(in *Cluster, out *api.Cluster, s conversi
 func autoConvert_api_Cluster_To_v1_Cluster(in *api.Cluster, out *Cluster, s conversion.Scope) error {
 	// INFO: in.LocationOfOrigin opted out of conversion generation
 	out.Server = in.Server
+	out.Endpoints = *(*[]string)(unsafe.Pointer(&in.Endpoints))
 	out.TLSServerName = in.TLSServerName
 	out.InsecureSkipTLSVerify = in.InsecureSkipTLSVerify
 	out.CertificateAuthority = in.CertificateAuthority
 	out.CertificateAuthorityData = *(*[]byte)(unsafe.Pointer(&in.CertificateAuthorityData))
 	out.ProxyURL = in.ProxyURL
+	out.UnixSocket = in.UnixSocket
+	out.SSHTunnel = (*SSHTunnel)(unsafe.Pointer(in.SSHTunnel))
 	out.DisableCompression = in.DisableCompression
 	if err := Convert_Map_string_To_runtime_Object_To_Slice_v1_NamedExtension(&in.Extensions, &out.Extensions, s); err != nil {
 		return err
@@ -382,6 +398,7 @@ func autoConvert_v1_ExecConfig_To_api_ExecConfig(in *ExecConfig, out *api.ExecCo
 	out.InstallHint = in.InstallHint
 	out.ProvideClusterInfo = in.ProvideClusterInfo
 	out.InteractiveMode = api.ExecInteractiveMode(in.InteractiveMode)
+	out.CacheCredentials = in.CacheCredentials
 	return nil
 }
 
@@ -399,6 +416,7 @@ func autoConvert_api_ExecConfig_To_v1_ExecConfig(in *api.ExecConfig, out *ExecCo
 	out.ProvideClusterInfo = in.ProvideClusterInfo
 	// INFO: in.Config opted out of conversion generation
 	out.InteractiveMode = ExecInteractiveMode(in.InteractiveMode)
+	out.CacheCredentials = in.CacheCredentials
 	// INFO: in.StdinUnavailable opted out of conversion generation
 	// INFO: in.StdinUnavailableMessage opted out of conversion generation
 	return nil
@@ -456,3 +474,29 @@ func autoConvert_api_Preferences_To_v1_Preferences(in *api.Preferences, out *Pre
 func Convert_api_Preferences_To_v1_Preferences(in *api.Preferences, out *Preferences, s conversion.Scope) error {
 	return autoConvert_api_Preferences_To_v1_Preferences(in, out, s)
 }
+
+func autoConvert_v1_SSHTunnel_To_api_SSHTunnel(in *SSHTunnel, out *api.SSHTunnel, s conversion.Scope) error {
+	out.Host = in.Host
+	out.IdentityFile = in.IdentityFile
+	out.AgentSocket = in.AgentSocket
+	out.KnownHostsFile = in.KnownHostsFile
+	return nil
+}
+
+// Convert_v1_SSHTunnel_To_api_SSHTunnel is an autogenerated conversion function.
+func Convert_v1_SSHTunnel_To_api_SSHTunnel(in *SSHTunnel, out *api.SSHTunnel, s conversion.Scope) error {
+	return autoConvert_v1_SSHTunnel_To_api_SSHTunnel(in, out, s)
+}
+
+func autoConvert_api_SSHTunnel_To_v1_SSHTunnel(in *api.SSHTunnel, out *SSHTunnel, s conversion.Scope) error {
+	out.Host = in.Host
+	out.IdentityFile = in.IdentityFile
+	out.AgentSocket = in.AgentSocket
+	out.KnownHostsFile = in.KnownHostsFile
+	return nil
+}
+
+// Convert_api_SSHTunnel_To_v1_SSHTunnel is an autogenerated conversion function.
+func Convert_api_SSHTunnel_To_v1_SSHTunnel(in *api.SSHTunnel, out *SSHTunnel, s conversion.Scope) error {
+	return autoConvert_api_SSHTunnel_To_v1_SSHTunnel(in, out, s)
+}
@@ -118,11 +118,21 @@ func (in *AuthProviderConfig) DeepCopy() *AuthProviderConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.CertificateAuthorityData != nil {
 		in, out := &in.CertificateAuthorityData, &out.CertificateAuthorityData
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.SSHTunnel != nil {
+		in, out := &in.SSHTunnel, &out.SSHTunnel
+		*out = new(SSHTunnel)
+		**out = **in
+	}
 	if in.Extensions != nil {
 		in, out := &in.Extensions, &out.Extensions
 		*out = make(map[string]runtime.Object, len(*in))
@@ -326,3 +336,19 @@ func (in *Preferences) DeepCopy() *Preferences {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHTunnel) DeepCopyInto(out *SSHTunnel) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHTunnel.
+func (in *SSHTunnel) DeepCopy() *SSHTunnel {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHTunnel)
+	in.DeepCopyInto(out)
+	return out
+}
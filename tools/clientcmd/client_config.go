@@ -28,12 +28,16 @@ import (
 	restclient "k8s.io/client-go/rest"
 	clientauth "k8s.io/client-go/tools/auth"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
 	"k8s.io/klog/v2"
 )
 
 const (
 	// clusterExtensionKey is reserved in the cluster extensions list for exec plugin config.
 	clusterExtensionKey = "client.authentication.k8s.io/exec"
+	// auditExtensionKey is reserved in the user extensions list for the
+	// audit log config, see auditExtension.
+	auditExtensionKey = "client-go.k8s.io/audit"
 )
 
 var (
@@ -195,6 +199,7 @@ func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
 
 	clientConfig := &restclient.Config{}
 	clientConfig.Host = configClusterInfo.Server
+	clientConfig.Endpoints = configClusterInfo.Endpoints
 	if configClusterInfo.ProxyURL != "" {
 		u, err := parseProxyURL(configClusterInfo.ProxyURL)
 		if err != nil {
@@ -202,6 +207,17 @@ func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
 		}
 		clientConfig.Proxy = http.ProxyURL(u)
 	}
+	switch {
+	case len(configClusterInfo.UnixSocket) != 0:
+		clientConfig.Dial = transport.NewUnixSocketDialer(configClusterInfo.UnixSocket)
+	case configClusterInfo.SSHTunnel != nil:
+		clientConfig.Dial = transport.NewSSHTunnelDialer(transport.SSHTunnelConfig{
+			Host:           configClusterInfo.SSHTunnel.Host,
+			IdentityFile:   configClusterInfo.SSHTunnel.IdentityFile,
+			AgentSocket:    configClusterInfo.SSHTunnel.AgentSocket,
+			KnownHostsFile: configClusterInfo.SSHTunnel.KnownHostsFile,
+		})
+	}
 
 	clientConfig.DisableCompression = configClusterInfo.DisableCompression
 
@@ -226,6 +242,13 @@ func (config *DirectClientConfig) ClientConfig() (*restclient.Config, error) {
 			Extra:    configAuthInfo.ImpersonateUserExtra,
 		}
 	}
+	if extension, ok := configAuthInfo.Extensions[auditExtensionKey]; ok {
+		audit, err := auditConfigFromExtension(extension)
+		if err != nil {
+			return nil, err
+		}
+		clientConfig.Audit = audit
+	}
 
 	// only try to read the auth information if we are secure
 	if restclient.IsConfigTransportTLS(*clientConfig) {
@@ -581,6 +604,15 @@ func (config *DirectClientConfig) getCluster() (clientcmdapi.Cluster, error) {
 		if config.overrides.ClusterInfo.TLSServerName != "" || config.overrides.ClusterInfo.Server != "" {
 			mergedClusterInfo.TLSServerName = config.overrides.ClusterInfo.TLSServerName
 		}
+
+		// The endpoints, Unix socket and SSH tunnel of the kubeconfig cluster
+		// belong to its server, so they must not be used together with an
+		// overridden --server.
+		if config.overrides.ClusterInfo.Server != "" {
+			mergedClusterInfo.Endpoints = config.overrides.ClusterInfo.Endpoints
+			mergedClusterInfo.UnixSocket = config.overrides.ClusterInfo.UnixSocket
+			mergedClusterInfo.SSHTunnel = config.overrides.ClusterInfo.SSHTunnel
+		}
 	}
 
 	return *mergedClusterInfo, nil
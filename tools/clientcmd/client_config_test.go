@@ -17,7 +17,9 @@ limitations under the License.
 package clientcmd
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -28,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/dump"
 	restclient "k8s.io/client-go/rest"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
 	utiltesting "k8s.io/client-go/util/testing"
 	"k8s.io/utils/ptr"
 )
@@ -378,6 +381,136 @@ func TestTLSServerNameClearsWhenServerNameSet(t *testing.T) {
 	matchStringArg("", actualCfg.ServerName, t)
 }
 
+func TestEndpoints(t *testing.T) {
+	config := createValidTestConfig()
+	config.Clusters["clean"].Endpoints = []string{"https://other.com:8080"}
+
+	actualCfg, err := NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual([]string{"https://other.com:8080"}, actualCfg.Endpoints) {
+		t.Errorf("Expected endpoints from the kubeconfig, got %v", actualCfg.Endpoints)
+	}
+
+	// Overriding the server drops the endpoints of the kubeconfig cluster.
+	actualCfg, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{
+		ClusterInfo: clientcmdapi.Cluster{
+			Server: "http://something",
+		},
+	}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(actualCfg.Endpoints) != 0 {
+		t.Errorf("Expected no endpoints, got %v", actualCfg.Endpoints)
+	}
+}
+
+func TestAuditExtension(t *testing.T) {
+	configDir := t.TempDir()
+	defer func(dir string) { RecommendedConfigDir = dir }(RecommendedConfigDir)
+	RecommendedConfigDir = configDir
+	auditLog := filepath.Join(configDir, "audit.jsonl")
+	config, err := Load([]byte(`
+apiVersion: v1
+kind: Config
+clusters:
+- name: clean
+  cluster:
+    server: https://anything.com:8080
+users:
+- name: clean
+  user:
+    token: the-token
+    extensions:
+    - name: client-go.k8s.io/audit
+      extension:
+        path: ` + auditLog + `
+contexts:
+- name: clean
+  context:
+    cluster: clean
+    user: clean
+current-context: clean
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	actualCfg, err := NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actualCfg.Audit == nil || actualCfg.Audit.AllVerbs {
+		t.Fatalf("Expected auditing of mutating requests, got %#v", actualCfg.Audit)
+	}
+	actualCfg.Audit.Sink.Audit(context.Background(), &transport.AuditEntry{Verb: "POST"})
+	data, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"verb":"POST"`) {
+		t.Errorf("Expected the entry in the audit log, got %q", data)
+	}
+
+	config.AuthInfos["clean"].Extensions[auditExtensionKey] = &runtime.Unknown{Raw: []byte(`{"allVerbs":true}`)}
+	_, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err == nil || !strings.Contains(err.Error(), "path must be set") {
+		t.Errorf("Expected an error for the extension without path, got %v", err)
+	}
+
+	// A kubeconfig must not append to files outside of the kube directory.
+	for _, path := range []string{filepath.Join(t.TempDir(), "audit.jsonl"), filepath.Join(configDir, "..", "audit.jsonl"), "audit.jsonl", configDir} {
+		config.AuthInfos["clean"].Extensions[auditExtensionKey] = &runtime.Unknown{Raw: []byte(`{"path":"` + path + `"}`)}
+		_, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+		if err == nil || !strings.Contains(err.Error(), "must be an absolute path within") {
+			t.Errorf("Expected an error for the path %q, got %v", path, err)
+		}
+	}
+}
+
+func TestDialers(t *testing.T) {
+	config := createValidTestConfig()
+	config.Clusters["clean"].UnixSocket = "/run/kubernetes/apiserver.sock"
+
+	actualCfg, err := NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actualCfg.Dial == nil {
+		t.Errorf("Expected a dialer for the Unix socket")
+	}
+
+	config.Clusters["clean"].SSHTunnel = &clientcmdapi.SSHTunnel{Host: "bastion.example.com"}
+	_, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err == nil || !strings.Contains(err.Error(), "only one of proxy-url, unix-socket and ssh-tunnel") {
+		t.Fatalf("Expected an error for both a Unix socket and an SSH tunnel, got %v", err)
+	}
+
+	config.Clusters["clean"].UnixSocket = ""
+	actualCfg, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actualCfg.Dial == nil {
+		t.Errorf("Expected a dialer for the SSH tunnel")
+	}
+
+	// Overriding the server drops the dialers of the kubeconfig cluster.
+	actualCfg, err = NewNonInteractiveClientConfig(*config, "clean", &ConfigOverrides{
+		ClusterInfo: clientcmdapi.Cluster{
+			Server: "http://something",
+		},
+	}, nil).ClientConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actualCfg.Dial != nil {
+		t.Errorf("Expected no dialer")
+	}
+}
+
 func TestFullImpersonateConfig(t *testing.T) {
 	config := createValidTestConfig()
 	config.Clusters["clean"] = &clientcmdapi.Cluster{
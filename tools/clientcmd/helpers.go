@@ -17,10 +17,16 @@ limitations under the License.
 package clientcmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/transport"
 )
 
 // ParseTimeout returns a parsed duration from a string
@@ -48,3 +54,61 @@ func parseProxyURL(proxyURL string) (*url.URL, error) {
 	}
 	return u, nil
 }
+
+// auditExtension is the user extension which appends an entry for each
+// mutating request, or each request if AllVerbs is set, to the JSON lines
+// file at Path:
+//
+//	users:
+//	- name: admin
+//	  user:
+//	    extensions:
+//	    - name: client-go.k8s.io/audit
+//	      extension:
+//	        path: /home/admin/.kube/requests.jsonl
+//
+// Path must be an absolute path within RecommendedConfigDir, because any
+// kubeconfig which is loaded may contain the extension and must not be able
+// to append to arbitrary files. Code which audits to other files sets
+// rest.Config.Audit instead.
+type auditExtension struct {
+	Path     string `json:"path"`
+	AllVerbs bool   `json:"allVerbs,omitempty"`
+}
+
+func auditConfigFromExtension(extension runtime.Object) (*transport.AuditConfig, error) {
+	data, err := json.Marshal(extension)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %w", auditExtensionKey, err)
+	}
+	var audit auditExtension
+	if err := json.Unmarshal(data, &audit); err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %w", auditExtensionKey, err)
+	}
+	if len(audit.Path) == 0 {
+		return nil, fmt.Errorf("invalid %s extension: path must be set", auditExtensionKey)
+	}
+	if !isInDir(audit.Path, RecommendedConfigDir) {
+		return nil, fmt.Errorf("invalid %s extension: path %q must be an absolute path within %s", auditExtensionKey, audit.Path, RecommendedConfigDir)
+	}
+	return &transport.AuditConfig{
+		Sink:     transport.NewFileAuditSink(audit.Path),
+		AllVerbs: audit.AllVerbs,
+	}, nil
+}
+
+// isInDir returns whether path is an absolute path within dir. Symbolic links
+// in the directory of path are resolved, so that they can't point elsewhere.
+func isInDir(path, dir string) bool {
+	if !filepath.IsAbs(path) || !filepath.IsAbs(dir) {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		path = filepath.Join(resolved, filepath.Base(path))
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -598,7 +598,11 @@ func GetConfigFileReferences(config *clientcmdapi.Config) []*string {
 }
 
 func GetClusterFileReferences(cluster *clientcmdapi.Cluster) []*string {
-	return []*string{&cluster.CertificateAuthority}
+	s := []*string{&cluster.CertificateAuthority, &cluster.UnixSocket}
+	if cluster.SSHTunnel != nil {
+		s = append(s, &cluster.SSHTunnel.IdentityFile, &cluster.SSHTunnel.AgentSocket, &cluster.SSHTunnel.KnownHostsFile)
+	}
+	return s
 }
 
 func GetAuthInfoFileReferences(authInfo *clientcmdapi.AuthInfo) []*string {
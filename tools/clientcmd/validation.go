@@ -238,11 +238,34 @@ func validateClusterInfo(clusterName string, clusterInfo clientcmdapi.Cluster) [
 			validationErrors = append(validationErrors, fmt.Errorf("no server found for cluster %q", clusterName))
 		}
 	}
+	for _, endpoint := range clusterInfo.Endpoints {
+		if len(endpoint) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("empty endpoint found for cluster %q", clusterName))
+		}
+	}
 	if proxyURL := clusterInfo.ProxyURL; proxyURL != "" {
 		if _, err := parseProxyURL(proxyURL); err != nil {
 			validationErrors = append(validationErrors, fmt.Errorf("invalid 'proxy-url' %q for cluster %q: %w", proxyURL, clusterName, err))
 		}
 	}
+	// The dialers of a Unix socket and an SSH tunnel ignore the address which they are asked to dial, so they
+	// can't be combined with each other or with a proxy.
+	dialers := 0
+	for _, set := range []bool{len(clusterInfo.ProxyURL) != 0, len(clusterInfo.UnixSocket) != 0, clusterInfo.SSHTunnel != nil} {
+		if set {
+			dialers++
+		}
+	}
+	if dialers > 1 {
+		validationErrors = append(validationErrors, fmt.Errorf("only one of proxy-url, unix-socket and ssh-tunnel may be specified for cluster %q", clusterName))
+	}
+	// The same dialer would be used for every endpoint, so all of them would reach the same server.
+	if len(clusterInfo.Endpoints) != 0 && (len(clusterInfo.UnixSocket) != 0 || clusterInfo.SSHTunnel != nil) {
+		validationErrors = append(validationErrors, fmt.Errorf("endpoints can't be combined with unix-socket or ssh-tunnel for cluster %q", clusterName))
+	}
+	if clusterInfo.SSHTunnel != nil && len(clusterInfo.SSHTunnel.Host) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("no host found in the ssh-tunnel of cluster %q", clusterName))
+	}
 	// Make sure CA data and CA file aren't both specified
 	if len(clusterInfo.CertificateAuthority) != 0 && len(clusterInfo.CertificateAuthorityData) != 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("certificate-authority-data and certificate-authority are both specified for %v. certificate-authority-data will override.", clusterName))
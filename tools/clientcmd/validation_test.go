@@ -296,6 +296,28 @@ func TestValidateCleanClusterInfo(t *testing.T) {
 	test.testConfig(t)
 }
 
+func TestValidateEndpointsWithDialerClusterInfo(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["unix socket"] = &clientcmdapi.Cluster{
+		Server:     "https://primary.example.com",
+		Endpoints:  []string{"https://secondary.example.com"},
+		UnixSocket: "/run/kubernetes/apiserver.sock",
+	}
+	config.Clusters["ssh tunnel"] = &clientcmdapi.Cluster{
+		Server:    "https://primary.example.com",
+		Endpoints: []string{"https://secondary.example.com"},
+		SSHTunnel: &clientcmdapi.SSHTunnel{Host: "bastion.example.com"},
+	}
+	test := configValidationTest{
+		config:                 config,
+		expectedErrorSubstring: []string{"endpoints can't be combined with unix-socket or ssh-tunnel"},
+	}
+
+	test.testCluster("unix socket", t)
+	test.testCluster("ssh tunnel", t)
+	test.testConfig(t)
+}
+
 func TestValidateCleanWithCAClusterInfo(t *testing.T) {
 	tempFile, _ := os.CreateTemp("", "")
 	defer utiltesting.CloseAndRemove(t, tempFile)
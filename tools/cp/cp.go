@@ -0,0 +1,305 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+	"k8s.io/klog/v2"
+)
+
+// ExecutorFunc creates the executor for a command in the container. stdin
+// tells whether the command reads stdin.
+type ExecutorFunc func(command []string, stdin bool) (remotecommand.Executor, error)
+
+// ExecutorFuncForPod returns an ExecutorFunc for the commands in a
+// container of a pod.
+func ExecutorFuncForPod(config *restclient.Config, pod types.NamespacedName, container string) ExecutorFunc {
+	return func(command []string, stdin bool) (remotecommand.Executor, error) {
+		return remotecommand.NewExecutorForPod(config, pod, container, command, stdin, false)
+	}
+}
+
+// Progress is the progress of a copy, reported for every chunk of a file.
+type Progress struct {
+	// Path is the path of the file, relative to the copied path and with
+	// forward slashes. It is empty when a single file is copied.
+	Path string
+	// Bytes is the number of bytes of the file which were copied, out of
+	// Size.
+	Bytes int64
+	Size  int64
+	// TotalBytes is the number of bytes of all files which were copied.
+	TotalBytes int64
+}
+
+// Options configures a copy.
+type Options struct {
+	// Retries is how often a copy is resumed after the connection to the
+	// container was lost. A copy from the container resumes where it
+	// stopped, a copy to the container starts over.
+	Retries int
+	// Backoff is the delay before a copy is resumed. By default, the delay
+	// starts at one second and doubles up to 30 seconds.
+	Backoff wait.Backoff
+	// Progress, if set, is called with the progress of the copy.
+	Progress func(Progress)
+}
+
+// DefaultBackoff is the default delay before a copy is resumed.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      30 * time.Second,
+}
+
+func (o *Options) backoff() wait.Backoff {
+	if o.Backoff.Duration == 0 {
+		return DefaultBackoff
+	}
+	return o.Backoff
+}
+
+// CopyToPod copies the local file or directory localPath to remotePath in
+// the container, replacing the files which exist there already.
+func CopyToPod(ctx context.Context, newExecutor ExecutorFunc, localPath, remotePath string, options Options) error {
+	remotePath = path.Clean(remotePath)
+	if remotePath == "/" || remotePath == "." {
+		return fmt.Errorf("invalid remote path %q: it must name a file or directory", remotePath)
+	}
+	command := []string{"tar", "-xmf", "-", "-C", path.Dir(remotePath)}
+	backoff := options.backoff()
+
+	for attempt := 0; ; attempt++ {
+		reader, writer := io.Pipe()
+		archiveErr := make(chan error, 1)
+		go func() {
+			err := writeArchive(writer, localPath, path.Base(remotePath), newProgressTracker(options.Progress))
+			writer.CloseWithError(err)
+			archiveErr <- err
+		}()
+
+		err := run(ctx, newExecutor, command, reader, io.Discard)
+		reader.Close()
+		// the archive fails with io.ErrClosedPipe if the command stopped
+		// reading it
+		if err := <-archiveErr; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return err
+		}
+		switch {
+		case err == nil:
+			return nil
+		case !shouldRetry(ctx, err, attempt, options.Retries):
+			return err
+		}
+		klog.V(2).InfoS("Copying to container failed, starting over", "path", remotePath, "attempt", attempt+1, "err", err)
+		if err := sleep(ctx, backoff.Step()); err != nil {
+			return err
+		}
+	}
+}
+
+// CopyFromPod copies the file or directory remotePath in the container to
+// localPath. Entries of the archive which would be written outside of
+// localPath, including through symbolic links, are skipped.
+func CopyFromPod(ctx context.Context, newExecutor ExecutorFunc, remotePath, localPath string, options Options) error {
+	remotePath = path.Clean(remotePath)
+	if remotePath == "/" {
+		return fmt.Errorf("invalid remote path %q: it must name a file or directory", remotePath)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reader := &resumingReader{
+		ctx:         ctx,
+		newExecutor: newExecutor,
+		dir:         path.Dir(remotePath),
+		base:        path.Base(remotePath),
+		retries:     options.Retries,
+		backoff:     options.backoff(),
+	}
+	defer reader.close()
+	return readArchive(reader, path.Base(remotePath), localPath, newProgressTracker(options.Progress))
+}
+
+// resumingReader reads an archive of a path in the container, and resumes
+// at the offset it stopped at when the connection to the container is
+// lost. This relies on tar writing the same archive for unchanged files.
+type resumingReader struct {
+	ctx         context.Context
+	newExecutor ExecutorFunc
+	dir, base   string
+	retries     int
+	backoff     wait.Backoff
+
+	offset  int64
+	attempt int
+	reader  *io.PipeReader
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	for {
+		if r.reader == nil {
+			r.start()
+		}
+		n, err := r.reader.Read(p)
+		r.offset += int64(n)
+		if err == nil || errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		r.close()
+		if n > 0 {
+			// deliver the data first, and resume with the next read
+			return n, nil
+		}
+		if !shouldRetry(r.ctx, err, r.attempt, r.retries) {
+			return 0, err
+		}
+		r.attempt++
+		klog.V(2).InfoS("Copying from container failed, resuming", "path", path.Join(r.dir, r.base), "offset", r.offset, "attempt", r.attempt, "err", err)
+		if err := sleep(r.ctx, r.backoff.Step()); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// start runs the command which writes the archive from the current offset.
+func (r *resumingReader) start() {
+	command := []string{"tar", "cf", "-", "-C", r.dir, r.base}
+	if r.offset > 0 {
+		// tail counts bytes from 1
+		command = []string{"sh", "-c", `tar cf - -C "$1" "$2" | tail -c +"$3"`, "sh", r.dir, r.base, strconv.FormatInt(r.offset+1, 10)}
+	}
+	reader, writer := io.Pipe()
+	r.reader = reader
+	go func() {
+		writer.CloseWithError(run(r.ctx, r.newExecutor, command, nil, writer))
+	}()
+}
+
+func (r *resumingReader) close() {
+	if r.reader != nil {
+		r.reader.Close()
+		r.reader = nil
+	}
+}
+
+// run runs a command in the container, and includes its stderr in the
+// error if it fails.
+func run(ctx context.Context, newExecutor ExecutorFunc, command []string, stdin io.Reader, stdout io.Writer) error {
+	executor, err := newExecutor(command, stdin != nil)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	var exitErr exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &commandError{command: command, err: exitErr, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return err
+}
+
+// commandError is the error of a command which failed in the container.
+// It isn't retried.
+type commandError struct {
+	command []string
+	err     exec.ExitError
+	stderr  string
+}
+
+func (e *commandError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("%s failed in the container: %v", e.command[0], e.err)
+	}
+	return fmt.Sprintf("%s failed in the container: %v: %s", e.command[0], e.err, e.stderr)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// shouldRetry tells whether a copy which failed with err is resumed.
+func shouldRetry(ctx context.Context, err error, attempt, retries int) bool {
+	var cmdErr *commandError
+	return attempt < retries && ctx.Err() == nil && !errors.As(err, &cmdErr)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// progressTracker reports the progress of the files of a copy.
+type progressTracker struct {
+	report func(Progress)
+	total  int64
+}
+
+func newProgressTracker(report func(Progress)) *progressTracker {
+	return &progressTracker{report: report}
+}
+
+// writer returns a writer which reports the progress of the file at path.
+func (t *progressTracker) writer(w io.Writer, path string, size int64) io.Writer {
+	if t.report == nil {
+		return w
+	}
+	return &progressWriter{w: w, tracker: t, progress: Progress{Path: path, Size: size}}
+}
+
+type progressWriter struct {
+	w        io.Writer
+	tracker  *progressTracker
+	progress Progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.tracker.total += int64(n)
+		w.progress.Bytes += int64(n)
+		w.progress.TotalBytes = w.tracker.total
+		w.tracker.report(w.progress)
+	}
+	return n, err
+}
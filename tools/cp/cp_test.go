@@ -0,0 +1,317 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cp
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+var errConnectionLost = errors.New("connection reset by peer")
+
+// fakeContainer stands in for the exec of the tar commands in a container
+// whose file system is root.
+type fakeContainer struct {
+	root string
+	// failAfter, if set, makes the next command lose its connection after
+	// that many bytes of the archive.
+	failAfter int64
+
+	lock     sync.Mutex
+	commands [][]string
+}
+
+func (c *fakeContainer) newExecutor(command []string, stdin bool) (remotecommand.Executor, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.commands = append(c.commands, command)
+	failAfter := c.failAfter
+	c.failAfter = 0
+	return &fakeExecutor{container: c, command: command, failAfter: failAfter}, nil
+}
+
+func (c *fakeContainer) executedCommands() [][]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.commands
+}
+
+type fakeExecutor struct {
+	container *fakeContainer
+	command   []string
+	failAfter int64
+}
+
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *fakeExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	command := strings.Join(e.command, " ")
+	switch {
+	case strings.HasPrefix(command, "tar cf - -C "):
+		return e.create(options, e.command[4], e.command[5], 0)
+	case strings.HasPrefix(command, "sh -c tar cf - "):
+		offset, err := strconv.ParseInt(e.command[6], 10, 64)
+		if err != nil {
+			return err
+		}
+		return e.create(options, e.command[4], e.command[5], offset-1)
+	case strings.HasPrefix(command, "tar -xmf - -C "):
+		return e.extract(options, e.command[4])
+	default:
+		return fmt.Errorf("unexpected command %q", command)
+	}
+}
+
+func (e *fakeExecutor) create(options remotecommand.StreamOptions, dir, base string, offset int64) error {
+	source := filepath.Join(e.container.root, dir, base)
+	if _, err := os.Lstat(source); err != nil {
+		fmt.Fprintf(options.Stderr, "tar: %s: Cannot stat: No such file or directory\n", base)
+		return exec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2}
+	}
+	var archive bytes.Buffer
+	if err := writeArchive(&archive, source, base, newProgressTracker(nil)); err != nil {
+		return err
+	}
+	data := archive.Bytes()[offset:]
+	if e.failAfter > 0 {
+		_, err := options.Stdout.Write(data[:e.failAfter])
+		if err != nil {
+			return err
+		}
+		return errConnectionLost
+	}
+	_, err := options.Stdout.Write(data)
+	return err
+}
+
+func (e *fakeExecutor) extract(options remotecommand.StreamOptions, dir string) error {
+	stdin := options.Stdin
+	if e.failAfter > 0 {
+		if _, err := io.CopyN(io.Discard, stdin, e.failAfter); err != nil {
+			return err
+		}
+		return errConnectionLost
+	}
+	tr := tar.NewReader(stdin)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(e.container.root, dir, header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, data, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeTree creates the files of tree under root, where the names of
+// directories end with a slash and links are given as "-> target".
+func writeTree(t *testing.T, root string, tree map[string]string) {
+	for name, content := range tree {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		switch {
+		case strings.HasSuffix(name, "/"):
+			require.NoError(t, os.MkdirAll(p, 0755))
+		case strings.HasPrefix(content, "-> "):
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+			require.NoError(t, os.Symlink(strings.TrimPrefix(content, "-> "), p))
+		default:
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+		}
+	}
+}
+
+// readTree returns the files under root in the form of writeTree.
+func readTree(t *testing.T, root string) map[string]string {
+	tree := map[string]string{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case info.IsDir():
+			tree[name+"/"] = ""
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			tree[name] = "-> " + link
+		default:
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			tree[name] = string(data)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return tree
+}
+
+var testTree = map[string]string{
+	"data/":            "",
+	"data/a.txt":       "a",
+	"data/sub/b.txt":   strings.Repeat("b", 10000),
+	"data/sub/":        "",
+	"data/link-to-a":   "-> a.txt",
+	"data/empty-dir/":  "",
+	"data/sub/c/d.txt": "d",
+	"data/sub/c/":      "",
+}
+
+func retryOptions(progress func(Progress)) Options {
+	return Options{Retries: 1, Backoff: wait.Backoff{Duration: time.Millisecond}, Progress: progress}
+}
+
+func TestCopyFromPod(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir(), failAfter: 5000}
+	writeTree(t, filepath.Join(container.root, "srv"), testTree)
+	local := filepath.Join(t.TempDir(), "copy")
+
+	var last Progress
+	err := CopyFromPod(context.Background(), container.newExecutor, "/srv/data", local, retryOptions(func(p Progress) {
+		last = p
+	}))
+	require.NoError(t, err)
+
+	expected := map[string]string{}
+	for name, content := range testTree {
+		if rel := strings.TrimPrefix(name, "data/"); rel != "" {
+			expected[rel] = content
+		}
+	}
+	assert.Equal(t, expected, readTree(t, local))
+	assert.Equal(t, int64(10002), last.TotalBytes)
+
+	commands := container.executedCommands()
+	require.Len(t, commands, 2)
+	assert.Equal(t, []string{"tar", "cf", "-", "-C", "/srv", "data"}, commands[0])
+	assert.Equal(t, []string{"sh", "-c", `tar cf - -C "$1" "$2" | tail -c +"$3"`, "sh", "/srv", "data", "5001"}, commands[1])
+}
+
+func TestCopyFromPodSingleFile(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir()}
+	writeTree(t, container.root, map[string]string{"etc/config": "setting"})
+	local := filepath.Join(t.TempDir(), "config.copy")
+
+	require.NoError(t, CopyFromPod(context.Background(), container.newExecutor, "/etc/config", local, Options{}))
+	data, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Equal(t, "setting", string(data))
+}
+
+func TestCopyFromPodCommandFails(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir()}
+	err := CopyFromPod(context.Background(), container.newExecutor, "/missing", t.TempDir(), retryOptions(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Cannot stat")
+	var exitErr exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+	assert.Len(t, container.executedCommands(), 1, "a failed command must not be retried")
+}
+
+func TestCopyFromPodRetriesExhausted(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir(), failAfter: 100}
+	writeTree(t, filepath.Join(container.root, "srv"), testTree)
+	err := CopyFromPod(context.Background(), container.newExecutor, "/srv/data", t.TempDir(), Options{})
+	assert.ErrorIs(t, err, errConnectionLost)
+}
+
+func TestCopyToPod(t *testing.T) {
+	local := filepath.Join(t.TempDir(), "data")
+	writeTree(t, filepath.Dir(local), testTree)
+	container := &fakeContainer{root: t.TempDir(), failAfter: 1024}
+	require.NoError(t, os.MkdirAll(filepath.Join(container.root, "srv"), 0755))
+
+	var last Progress
+	err := CopyToPod(context.Background(), container.newExecutor, local, "/srv/uploaded", retryOptions(func(p Progress) {
+		last = p
+	}))
+	require.NoError(t, err)
+
+	expected := map[string]string{}
+	for name, content := range testTree {
+		expected["uploaded"+strings.TrimPrefix(name, "data")] = content
+	}
+	assert.Equal(t, expected, readTree(t, filepath.Join(container.root, "srv")))
+	assert.Equal(t, int64(10002), last.TotalBytes)
+
+	commands := container.executedCommands()
+	require.Len(t, commands, 2, "the copy must start over")
+	assert.Equal(t, []string{"tar", "-xmf", "-", "-C", "/srv"}, commands[1])
+}
+
+func TestCopyToPodLocalError(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir()}
+	err := CopyToPod(context.Background(), container.newExecutor, filepath.Join(t.TempDir(), "missing"), "/srv/x", retryOptions(nil))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "unexpected error %v", err)
+}
+
+func TestCopyInvalidRemotePath(t *testing.T) {
+	container := &fakeContainer{root: t.TempDir()}
+	assert.Error(t, CopyToPod(context.Background(), container.newExecutor, t.TempDir(), "/", Options{}))
+	assert.Error(t, CopyFromPod(context.Background(), container.newExecutor, "/", t.TempDir(), Options{}))
+	assert.Empty(t, container.executedCommands())
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cp
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// writeArchive writes the file or directory localPath to w as a tar
+// archive, in which it is named name. Symbolic links are archived as links,
+// other special files are skipped.
+func writeArchive(w io.Writer, localPath, name string, tracker *progressTracker) error {
+	// a link to a directory is copied as the directory
+	root, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		archiveName, relName := name, ""
+		if rel != "." {
+			relName = filepath.ToSlash(rel)
+			archiveName = path.Join(name, relName)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			klog.V(2).InfoS("Skipping special file", "path", p, "mode", info.Mode())
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = archiveName
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		// the owner is left to the user which extracts the archive
+		header.Uname, header.Gname = "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.CopyN(tracker.writer(tw, relName, info.Size()), f, info.Size()); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%s was truncated while it was copied", p)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// readArchive extracts a tar archive of a file or directory named base to
+// dest. Entries outside of base, or which would be written outside of
+// dest, are skipped.
+func readArchive(r io.Reader, base, dest string, tracker *progressTracker) error {
+	dest = filepath.Clean(dest)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, ok := relativeName(header.Name, base)
+		if !ok {
+			klog.InfoS("Skipping unexpected archive entry", "name", header.Name)
+			continue
+		}
+		target, err := safeTarget(dest, rel)
+		if err != nil {
+			klog.InfoS("Skipping archive entry", "name", header.Name, "err", err)
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, header.FileInfo().Mode().Perm()|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, header, target, rel, tracker); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !linkInside(dest, target, header.Linkname) {
+				klog.InfoS("Skipping symbolic link which points outside of the destination", "name", header.Name, "link", header.Linkname)
+				continue
+			}
+			if err := replaceWith(target, func() error { return os.Symlink(header.Linkname, target) }); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			linkRel, ok := relativeName(header.Linkname, base)
+			if !ok {
+				klog.InfoS("Skipping hard link which points outside of the destination", "name", header.Name, "link", header.Linkname)
+				continue
+			}
+			linkTarget, err := safeTarget(dest, linkRel)
+			if err != nil {
+				klog.InfoS("Skipping hard link", "name", header.Name, "link", header.Linkname, "err", err)
+				continue
+			}
+			if err := replaceWith(target, func() error { return os.Link(linkTarget, target) }); err != nil {
+				return err
+			}
+		default:
+			klog.V(2).InfoS("Skipping special file", "name", header.Name, "type", header.Typeflag)
+		}
+	}
+}
+
+func extractFile(r io.Reader, header *tar.Header, target, rel string, tracker *progressTracker) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// don't write through a link which is replaced
+	if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tracker.writer(f, rel, header.Size), r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replaceWith creates a link at target with create, replacing the file
+// which exists there.
+func replaceWith(target string, create func() error) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return create()
+}
+
+// relativeName returns the name of an archive entry relative to the
+// archived file or directory base, with forward slashes.
+func relativeName(name, base string) (string, bool) {
+	name = path.Clean(name)
+	switch {
+	case base == ".":
+		if name == "." {
+			return "", true
+		}
+		return name, true
+	case name == base:
+		return "", true
+	case strings.HasPrefix(name, base+"/"):
+		return name[len(base)+1:], true
+	default:
+		return "", false
+	}
+}
+
+// safeTarget returns the path of an archive entry in dest. It fails if the
+// path is outside of dest, or would be written through a symbolic link.
+func safeTarget(dest, rel string) (string, error) {
+	if rel == "" {
+		return dest, nil
+	}
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%q is outside of the destination", rel)
+	}
+	parent := dest
+	for _, component := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if component == "." {
+			break
+		}
+		parent = filepath.Join(parent, component)
+		info, err := os.Lstat(parent)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%q is a symbolic link", parent)
+		}
+	}
+	return filepath.Join(dest, rel), nil
+}
+
+// linkInside tells whether a symbolic link at target to link points into
+// dest.
+func linkInside(dest, target, link string) bool {
+	link = filepath.FromSlash(link)
+	if filepath.IsAbs(link) {
+		return false
+	}
+	rel, err := filepath.Rel(dest, filepath.Join(filepath.Dir(target), link))
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cp
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadArchiveSanitizesPaths(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	writeEntry := func(header *tar.Header, content string) {
+		header.Size = int64(len(content))
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	writeEntry(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	writeEntry(&tar.Header{Name: "data/ok.txt", Typeflag: tar.TypeReg}, "ok")
+	writeEntry(&tar.Header{Name: "data/../escaped.txt", Typeflag: tar.TypeReg}, "escaped")
+	writeEntry(&tar.Header{Name: "/etc/absolute.txt", Typeflag: tar.TypeReg}, "absolute")
+	writeEntry(&tar.Header{Name: "other/file.txt", Typeflag: tar.TypeReg}, "other")
+	writeEntry(&tar.Header{Name: "data/outside", Typeflag: tar.TypeSymlink, Linkname: "../../"}, "")
+	writeEntry(&tar.Header{Name: "data/absolute", Typeflag: tar.TypeSymlink, Linkname: "/etc"}, "")
+	writeEntry(&tar.Header{Name: "data/inside", Typeflag: tar.TypeSymlink, Linkname: "ok.txt"}, "")
+	writeEntry(&tar.Header{Name: "data/sub/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	writeEntry(&tar.Header{Name: "data/sub-link", Typeflag: tar.TypeSymlink, Linkname: "sub"}, "")
+	writeEntry(&tar.Header{Name: "data/sub-link/through.txt", Typeflag: tar.TypeReg}, "through")
+	writeEntry(&tar.Header{Name: "data/hard", Typeflag: tar.TypeLink, Linkname: "data/ok.txt"}, "")
+	writeEntry(&tar.Header{Name: "data/hard-outside", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"}, "")
+	require.NoError(t, tw.Close())
+
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	require.NoError(t, readArchive(&archive, "data", dest, newProgressTracker(nil)))
+
+	assert.Equal(t, map[string]string{
+		"dest/":         "",
+		"dest/ok.txt":   "ok",
+		"dest/inside":   "-> ok.txt",
+		"dest/sub/":     "",
+		"dest/sub-link": "-> sub",
+		"dest/hard":     "ok",
+	}, readTree(t, parent))
+}
+
+func TestReadArchiveReplacesLinks(t *testing.T) {
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	require.NoError(t, os.MkdirAll(dest, 0755))
+	secret := filepath.Join(parent, "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0600))
+	// a link from an earlier copy must be replaced, not written through
+	require.NoError(t, os.Symlink(secret, filepath.Join(dest, "file")))
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 3}))
+	_, err := tw.Write([]byte("new"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	require.NoError(t, readArchive(&archive, "data", dest, newProgressTracker(nil)))
+	data, err := os.ReadFile(secret)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+	data, err = os.ReadFile(filepath.Join(dest, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestRelativeName(t *testing.T) {
+	for _, tc := range []struct {
+		name, base string
+		expected   string
+		ok         bool
+	}{
+		{name: "data", base: "data", expected: "", ok: true},
+		{name: "data/", base: "data", expected: "", ok: true},
+		{name: "data/a/b", base: "data", expected: "a/b", ok: true},
+		{name: "database", base: "data", ok: false},
+		{name: "./a", base: ".", expected: "a", ok: true},
+		{name: "other/a", base: "data", ok: false},
+	} {
+		rel, ok := relativeName(tc.name, tc.base)
+		assert.Equal(t, tc.ok, ok, "%s in %s", tc.name, tc.base)
+		assert.Equal(t, tc.expected, rel, "%s in %s", tc.name, tc.base)
+	}
+}
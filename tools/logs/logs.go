@@ -0,0 +1,336 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// Line is a line of the logs of a container.
+type Line struct {
+	Pod       string
+	Container string
+	// Timestamp is the time the container wrote the line.
+	Timestamp time.Time
+	// Text is the line without its trailing newline.
+	Text string
+}
+
+// Options configures a Follower.
+type Options struct {
+	// Container, if set, is the only container of the pods which is
+	// followed. By default, all containers are followed.
+	Container string
+	// SinceTime, SinceSeconds and TailLines limit the logs of a container
+	// which are read when it is followed first, like in v1.PodLogOptions.
+	SinceTime    *metav1.Time
+	SinceSeconds *int64
+	TailLines    *int64
+	// Backoff is the delay before the logs of a container are followed
+	// again after they stopped. By default, the delay starts at one second
+	// and doubles up to 30 seconds.
+	Backoff wait.Backoff
+}
+
+// DefaultBackoff is the default delay before the logs of a container are
+// followed again.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      30 * time.Second,
+}
+
+// Follower follows the logs of the pods in a namespace which match a
+// label selector.
+type Follower struct {
+	client    kubernetes.Interface
+	namespace string
+	selector  labels.Selector
+	options   Options
+
+	// openStream opens the logs of a container; it is replaced in tests.
+	openStream func(ctx context.Context, namespace, pod string, options *v1.PodLogOptions) (io.ReadCloser, error)
+
+	informer cache.SharedIndexInformer
+	lock     sync.Mutex
+	streams  map[streamKey]context.CancelFunc
+	// stopped is set once Run waits for the containers which are followed,
+	// no more are followed then.
+	stopped     bool
+	wg          sync.WaitGroup
+	handlerLock sync.Mutex
+}
+
+// streamKey identifies a container of a pod, which is followed once.
+type streamKey struct {
+	uid       types.UID
+	container string
+}
+
+// NewFollower creates a Follower for the pods in namespace which match
+// selector.
+func NewFollower(client kubernetes.Interface, namespace string, selector labels.Selector, options Options) *Follower {
+	if options.Backoff.Duration == 0 {
+		options.Backoff = DefaultBackoff
+	}
+	f := &Follower{
+		client:    client,
+		namespace: namespace,
+		selector:  selector,
+		options:   options,
+		streams:   map[streamKey]context.CancelFunc{},
+	}
+	f.openStream = func(ctx context.Context, namespace, pod string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+		return f.client.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+	}
+	return f
+}
+
+// Run follows the logs until ctx is done, and passes each line to handler.
+// handler is called for one line at a time; the lines of a container are
+// passed in order.
+func (f *Follower) Run(ctx context.Context, handler func(Line)) error {
+	f.informer = coreinformers.NewFilteredPodInformer(f.client, f.namespace, 0, cache.Indexers{}, func(options *metav1.ListOptions) {
+		options.LabelSelector = f.selector.String()
+	})
+	_, err := f.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			f.podChanged(ctx, obj, handler)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			f.podChanged(ctx, obj, handler)
+		},
+		DeleteFunc: f.podDeleted,
+	})
+	if err != nil {
+		return err
+	}
+
+	go f.informer.RunWithContext(ctx)
+	<-ctx.Done()
+	f.lock.Lock()
+	f.stopped = true
+	f.lock.Unlock()
+	f.wg.Wait()
+	return nil
+}
+
+// podChanged starts to follow the containers of a pod which started.
+func (f *Follower) podChanged(ctx context.Context, obj interface{}, handler func(Line)) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.stopped {
+		return
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if f.options.Container != "" && status.Name != f.options.Container {
+			continue
+		}
+		if status.State.Running == nil && status.State.Terminated == nil && status.RestartCount == 0 {
+			// the container has no logs yet
+			continue
+		}
+		key := streamKey{uid: pod.UID, container: status.Name}
+		if _, ok := f.streams[key]; ok {
+			continue
+		}
+		streamCtx, cancel := context.WithCancel(ctx)
+		f.streams[key] = cancel
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.follow(streamCtx, pod.Namespace, pod.Name, pod.UID, status.Name, handler)
+		}()
+	}
+}
+
+// podDeleted stops following the containers of a pod.
+func (f *Follower) podDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for key, cancel := range f.streams {
+		if key.uid == pod.UID {
+			cancel()
+			delete(f.streams, key)
+		}
+	}
+}
+
+// follow follows the logs of a container until ctx is done or the pod
+// completed, and resumes them after the last line it read when they stop.
+func (f *Follower) follow(ctx context.Context, namespace, pod string, uid types.UID, container string, handler func(Line)) {
+	logger := klog.FromContext(ctx).WithValues("pod", klog.KRef(namespace, pod), "container", container)
+	position := &position{}
+	backoff := f.options.Backoff
+	for {
+		logOptions := &v1.PodLogOptions{
+			Container:  container,
+			Follow:     true,
+			Timestamps: true,
+		}
+		if position.last.IsZero() {
+			logOptions.SinceTime = f.options.SinceTime
+			logOptions.SinceSeconds = f.options.SinceSeconds
+			logOptions.TailLines = f.options.TailLines
+		} else {
+			// SinceTime is truncated to seconds, the lines which were read
+			// already are skipped
+			logOptions.SinceTime = &metav1.Time{Time: position.last}
+		}
+
+		stream, err := f.openStream(ctx, namespace, pod, logOptions)
+		if err == nil {
+			var read bool
+			read, err = readLines(stream, position, func(timestamp time.Time, text string) {
+				f.handlerLock.Lock()
+				defer f.handlerLock.Unlock()
+				handler(Line{Pod: pod, Container: container, Timestamp: timestamp, Text: text})
+			})
+			stream.Close()
+			if read {
+				backoff = f.options.Backoff
+			}
+		}
+		if ctx.Err() != nil || f.completed(namespace, pod, uid) {
+			return
+		}
+		if err != nil {
+			logger.V(2).Info("Following logs failed, retrying", "err", err)
+		}
+
+		t := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// completed tells whether a pod is gone or won't run its containers
+// anymore, so that there are no more logs to follow.
+func (f *Follower) completed(namespace, name string, uid types.UID) bool {
+	obj, exists, err := f.informer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return true
+	}
+	pod, ok := obj.(*v1.Pod)
+	return !ok || pod.UID != uid || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// position is the position in the logs of a container: the timestamp of
+// the last line read, and how many lines with that timestamp were read.
+type position struct {
+	last  time.Time
+	count int
+}
+
+// readLines passes the lines of stream after position to deliver, and tells
+// whether there were any. A line which is cut off by an error isn't
+// passed, it is read again when the logs are resumed.
+func readLines(stream io.Reader, position *position, deliver func(timestamp time.Time, text string)) (bool, error) {
+	reader := bufio.NewReader(stream)
+	// the lines with the last timestamp which were passed already
+	skip := position.count
+	read := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return read, err
+		}
+		timestamp, text := parseLine(strings.TrimSuffix(line, "\n"))
+		switch {
+		case timestamp.IsZero():
+		case timestamp.Before(position.last):
+			continue
+		case timestamp.Equal(position.last):
+			if skip > 0 {
+				skip--
+				continue
+			}
+			position.count++
+		default:
+			position.last, position.count, skip = timestamp, 1, 0
+		}
+		read = true
+		deliver(timestamp, text)
+		if err != nil {
+			return read, nil
+		}
+	}
+}
+
+// parseLine splits a line of logs with timestamps into its timestamp and
+// text. A line without a timestamp has a zero timestamp.
+func parseLine(line string) (time.Time, string) {
+	prefix, text, found := strings.Cut(line, " ")
+	if !found {
+		prefix, text = line, ""
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, line
+	}
+	return timestamp, text
+}
+
+// WriteTo returns a handler for Follower.Run which writes the lines to w,
+// prefixed with their pod and container, and with their timestamp if
+// timestamps is set. Errors writing to w are ignored.
+func WriteTo(w io.Writer, timestamps bool) func(Line) {
+	return func(line Line) {
+		if timestamps && !line.Timestamp.IsZero() {
+			fmt.Fprintf(w, "[pod/%s/%s] %s %s\n", line.Pod, line.Container, line.Timestamp.Format(time.RFC3339Nano), line.Text)
+			return
+		}
+		fmt.Fprintf(w, "[pod/%s/%s] %s\n", line.Pod, line.Container, line.Text)
+	}
+}
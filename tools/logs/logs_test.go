@@ -0,0 +1,271 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeLogs serves the logs of containers, which are given with timestamps.
+// The stream of a container ends after the lines which were added when it
+// was opened, as if the connection was lost.
+type fakeLogs struct {
+	lock sync.Mutex
+	logs map[string][]string
+	// requests are the options of the streams which were opened.
+	requests map[string][]*v1.PodLogOptions
+}
+
+func newFakeLogs() *fakeLogs {
+	return &fakeLogs{
+		logs:     map[string][]string{},
+		requests: map[string][]*v1.PodLogOptions{},
+	}
+}
+
+func (l *fakeLogs) add(pod, container string, lines ...string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := pod + "/" + container
+	l.logs[key] = append(l.logs[key], lines...)
+}
+
+func (l *fakeLogs) open(ctx context.Context, namespace, pod string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := pod + "/" + options.Container
+	l.requests[key] = append(l.requests[key], options)
+
+	var buf bytes.Buffer
+	for _, line := range l.logs[key] {
+		timestamp, _ := parseLine(line)
+		if options.SinceTime != nil && timestamp.Before(options.SinceTime.Time) {
+			continue
+		}
+		buf.WriteString(line + "\n")
+	}
+	if buf.Len() == 0 {
+		return nil, errors.New("container is waiting to start")
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func (l *fakeLogs) requestsFor(key string) []*v1.PodLogOptions {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.requests[key]
+}
+
+func runningPod(name string, uid types.UID, containers ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: uid, Labels: map[string]string{"app": "web"}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	for _, container := range containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:  container,
+			State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+// lineCollector collects the lines of a Follower.
+type lineCollector struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (c *lineCollector) handle(line Line) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lines = append(c.lines, line.Pod+"/"+line.Container+": "+line.Text)
+}
+
+func (c *lineCollector) waitFor(t *testing.T, n int) []string {
+	var lines []string
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		lines = append([]string(nil), c.lines...)
+		return len(lines) >= n, nil
+	})
+	require.NoError(t, err, "got lines %v", lines)
+	return lines
+}
+
+func TestFollowerResumes(t *testing.T) {
+	client := fake.NewClientset(runningPod("web-1", "uid-1", "app"))
+	logs := newFakeLogs()
+	logs.add("web-1", "app",
+		"2025-01-01T00:00:00.100000000Z one",
+		"2025-01-01T00:00:00.200000000Z two",
+		"2025-01-01T00:00:00.200000000Z two again",
+	)
+
+	follower := NewFollower(client, "ns", labels.SelectorFromSet(labels.Set{"app": "web"}), Options{
+		Backoff: wait.Backoff{Duration: time.Millisecond},
+	})
+	follower.openStream = logs.open
+	collector := &lineCollector{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- follower.Run(ctx, collector.handle)
+	}()
+
+	collector.waitFor(t, 3)
+	// the stream ended, the next one resumes with the same second
+	logs.add("web-1", "app", "2025-01-01T00:00:01.000000000Z three")
+	collector.waitFor(t, 4)
+	// give the follower the chance to read the lines again
+	time.Sleep(50 * time.Millisecond)
+	lines := collector.waitFor(t, 4)
+	assert.Equal(t, []string{
+		"web-1/app: one",
+		"web-1/app: two",
+		"web-1/app: two again",
+		"web-1/app: three",
+	}, lines)
+
+	requests := logs.requestsFor("web-1/app")
+	require.GreaterOrEqual(t, len(requests), 2)
+	assert.Nil(t, requests[0].SinceTime)
+	assert.True(t, requests[0].Follow)
+	assert.True(t, requests[0].Timestamps)
+	require.NotNil(t, requests[1].SinceTime)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 200000000, time.UTC), requests[1].SinceTime.Time)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("Run didn't return")
+	}
+}
+
+func TestFollowerPicksUpNewPods(t *testing.T) {
+	other := runningPod("other", "uid-3", "app")
+	other.Labels = nil
+	client := fake.NewClientset(other)
+	logs := newFakeLogs()
+	logs.add("web-1", "app", "2025-01-01T00:00:00Z first pod")
+	logs.add("web-2", "app", "2025-01-01T00:00:00Z second pod")
+	logs.add("web-2", "sidecar", "2025-01-01T00:00:00Z sidecar")
+	logs.add("other", "app", "2025-01-01T00:00:00Z not selected")
+
+	follower := NewFollower(client, "ns", labels.SelectorFromSet(labels.Set{"app": "web"}), Options{
+		Container: "app",
+		Backoff:   wait.Backoff{Duration: time.Hour},
+	})
+	follower.openStream = logs.open
+	collector := &lineCollector{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = follower.Run(ctx, collector.handle)
+	}()
+
+	_, err := client.CoreV1().Pods("ns").Create(ctx, runningPod("web-1", "uid-1", "app"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	collector.waitFor(t, 1)
+
+	// a pod whose container hasn't started yet is followed once it started
+	pod := runningPod("web-2", "uid-2", "app", "sidecar")
+	pod.Status.ContainerStatuses[0].State = v1.ContainerState{Waiting: &v1.ContainerStateWaiting{}}
+	pod, err = client.CoreV1().Pods("ns").Create(ctx, pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+	pod.Status.ContainerStatuses[0].State = v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+	_, err = client.CoreV1().Pods("ns").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	lines := collector.waitFor(t, 2)
+	assert.ElementsMatch(t, []string{"web-1/app: first pod", "web-2/app: second pod"}, lines)
+	assert.Empty(t, logs.requestsFor("web-2/sidecar"))
+	assert.Empty(t, logs.requestsFor("other/app"))
+}
+
+func TestFollowerStopsForCompletedPods(t *testing.T) {
+	pod := runningPod("job", "uid-1", "app")
+	pod.Status.Phase = v1.PodSucceeded
+	client := fake.NewClientset(pod)
+	logs := newFakeLogs()
+	logs.add("job", "app", "2025-01-01T00:00:00Z done")
+
+	follower := NewFollower(client, "ns", labels.Everything(), Options{Backoff: wait.Backoff{Duration: time.Millisecond}})
+	follower.openStream = logs.open
+	collector := &lineCollector{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = follower.Run(ctx, collector.handle)
+	}()
+
+	collector.waitFor(t, 1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, logs.requestsFor("job/app"), 1, "the logs of a completed pod must not be followed again")
+}
+
+func TestReadLinesSkipsCutOffLines(t *testing.T) {
+	position := &position{}
+	var lines []string
+	deliver := func(_ time.Time, text string) { lines = append(lines, text) }
+
+	stream := io.MultiReader(strings.NewReader("2025-01-01T00:00:00Z complete\n2025-01-01T00:00:01Z cut"), &failingReader{})
+	read, err := readLines(stream, position, deliver)
+	assert.True(t, read)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"complete"}, lines)
+
+	// a stream which ends passes its last line without a newline
+	read, err = readLines(strings.NewReader("2025-01-01T00:00:00Z complete\n2025-01-01T00:00:01Z cut off"), position, deliver)
+	assert.True(t, read)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"complete", "cut off"}, lines)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	timestamp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	WriteTo(&buf, false)(Line{Pod: "p", Container: "c", Timestamp: timestamp, Text: "hello"})
+	WriteTo(&buf, true)(Line{Pod: "p", Container: "c", Timestamp: timestamp, Text: "hello"})
+	assert.Equal(t, "[pod/p/c] hello\n[pod/p/c] 2025-01-01T00:00:00Z hello\n", buf.String())
+}
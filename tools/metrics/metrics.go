@@ -79,6 +79,20 @@ type TransportCreateCallsMetric interface {
 	Increment(result string)
 }
 
+// ConnectionHealthMetric observes the health of the pooled HTTP/2 connections
+// of a transport, partitioned by host and by the index of the connection in
+// the pool of the host.
+type ConnectionHealthMetric interface {
+	Observe(host string, connection int, activeStreams int, pingRTT time.Duration)
+}
+
+// StreamBytesMetric counts the bytes transferred by streaming connections,
+// partitioned by the kind of connection (exec or portforward) and by the
+// direction (sent or received).
+type StreamBytesMetric interface {
+	Add(kind string, direction string, bytes int)
+}
+
 var (
 	// ClientCertExpiry is the expiry time of a client certificate
 	ClientCertExpiry ExpiryMetric = noopExpiry{}
@@ -108,22 +122,30 @@ var (
 	// TransportCreateCalls is the metric that counts the number of times a new transport
 	// is created
 	TransportCreateCalls TransportCreateCallsMetric = noopTransportCreateCalls{}
+	// TransportConnectionHealth is the metric that tracks the active streams and
+	// the ping round trip time of pooled HTTP/2 connections.
+	TransportConnectionHealth ConnectionHealthMetric = noopConnectionHealth{}
+	// StreamBytes is the metric that counts the bytes transferred by exec and
+	// port forwarding connections.
+	StreamBytes StreamBytesMetric = noopStreamBytes{}
 )
 
 // RegisterOpts contains all the metrics to register. Metrics may be nil.
 type RegisterOpts struct {
-	ClientCertExpiry      ExpiryMetric
-	ClientCertRotationAge DurationMetric
-	RequestLatency        LatencyMetric
-	ResolverLatency       ResolverLatencyMetric
-	RequestSize           SizeMetric
-	ResponseSize          SizeMetric
-	RateLimiterLatency    LatencyMetric
-	RequestResult         ResultMetric
-	ExecPluginCalls       CallsMetric
-	RequestRetry          RetryMetric
-	TransportCacheEntries TransportCacheMetric
-	TransportCreateCalls  TransportCreateCallsMetric
+	ClientCertExpiry          ExpiryMetric
+	ClientCertRotationAge     DurationMetric
+	RequestLatency            LatencyMetric
+	ResolverLatency           ResolverLatencyMetric
+	RequestSize               SizeMetric
+	ResponseSize              SizeMetric
+	RateLimiterLatency        LatencyMetric
+	RequestResult             ResultMetric
+	ExecPluginCalls           CallsMetric
+	RequestRetry              RetryMetric
+	TransportCacheEntries     TransportCacheMetric
+	TransportCreateCalls      TransportCreateCallsMetric
+	TransportConnectionHealth ConnectionHealthMetric
+	StreamBytes               StreamBytesMetric
 }
 
 // Register registers metrics for the rest client to use. This can
@@ -166,6 +188,12 @@ func Register(opts RegisterOpts) {
 		if opts.TransportCreateCalls != nil {
 			TransportCreateCalls = opts.TransportCreateCalls
 		}
+		if opts.TransportConnectionHealth != nil {
+			TransportConnectionHealth = opts.TransportConnectionHealth
+		}
+		if opts.StreamBytes != nil {
+			StreamBytes = opts.StreamBytes
+		}
 	})
 }
 
@@ -209,3 +237,11 @@ func (noopTransportCache) Observe(int) {}
 type noopTransportCreateCalls struct{}
 
 func (noopTransportCreateCalls) Increment(string) {}
+
+type noopConnectionHealth struct{}
+
+func (noopConnectionHealth) Observe(string, int, int, time.Duration) {}
+
+type noopStreamBytes struct{}
+
+func (noopStreamBytes) Add(string, string, int) {}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+var _ httpstream.Dialer = &LimitedDialer{}
+
+// BandwidthOptions limits the bandwidth of the connections of a dialer. A
+// limit which is zero doesn't limit anything.
+type BandwidthOptions struct {
+	// StreamBytesPerSecond limits each stream of a connection, in each
+	// direction.
+	StreamBytesPerSecond int64
+	// ConnectionBytesPerSecond limits the streams of a connection together,
+	// in both directions.
+	ConnectionBytesPerSecond int64
+}
+
+// LimitedDialer limits the bandwidth of the connections of another dialer
+// and counts the bytes they transfer, in total and in the StreamBytes
+// metric. Implements the httpstream.Dialer interface, so that it works with
+// the SPDY, the tunneling and the fallback dialers.
+type LimitedDialer struct {
+	dialer  httpstream.Dialer
+	options BandwidthOptions
+
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// NewLimitedDialer creates a dialer which limits the bandwidth of the
+// connections of dialer.
+func NewLimitedDialer(dialer httpstream.Dialer, options BandwidthOptions) *LimitedDialer {
+	return &LimitedDialer{dialer: dialer, options: options}
+}
+
+// Dial dials a connection with the wrapped dialer, and limits its streams.
+func (d *LimitedDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, protocol, err := d.dialer.Dial(protocols...)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &limitedConnection{
+		Connection: conn,
+		dialer:     d,
+		limiter:    flowcontrol.NewBandwidthLimiter(d.options.ConnectionBytesPerSecond),
+		ctx:        ctx,
+		cancel:     cancel,
+	}, protocol, nil
+}
+
+// BytesSent returns the bytes which were sent over all connections.
+func (d *LimitedDialer) BytesSent() int64 {
+	return d.sent.Load()
+}
+
+// BytesReceived returns the bytes which were received over all connections.
+func (d *LimitedDialer) BytesReceived() int64 {
+	return d.received.Load()
+}
+
+// limitedConnection limits the streams it creates. Streams created by the
+// server aren't limited, port forwarding doesn't use them.
+type limitedConnection struct {
+	httpstream.Connection
+	dialer  *LimitedDialer
+	limiter *flowcontrol.BandwidthLimiter
+	// ctx is done once the connection is closed, to stop waiting for the
+	// limiters.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (c *limitedConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	stream, err := c.Connection.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	// each direction of a stream has its own limit
+	return &limitedStream{
+		Stream: stream,
+		reader: flowcontrol.LimitReader(c.ctx, stream, flowcontrol.NewBandwidthLimiter(c.dialer.options.StreamBytesPerSecond), c.limiter),
+		writer: flowcontrol.LimitWriter(c.ctx, stream, flowcontrol.NewBandwidthLimiter(c.dialer.options.StreamBytesPerSecond), c.limiter),
+		dialer: c.dialer,
+	}, nil
+}
+
+// RemoveStreams removes the streams of the wrapped connection, which only
+// knows the streams it created.
+func (c *limitedConnection) RemoveStreams(streams ...httpstream.Stream) {
+	unwrapped := make([]httpstream.Stream, 0, len(streams))
+	for _, stream := range streams {
+		if limited, ok := stream.(*limitedStream); ok {
+			stream = limited.Stream
+		}
+		unwrapped = append(unwrapped, stream)
+	}
+	c.Connection.RemoveStreams(unwrapped...)
+}
+
+func (c *limitedConnection) Close() error {
+	c.cancel()
+	return c.Connection.Close()
+}
+
+// limitedStream limits and counts the bytes of a stream.
+type limitedStream struct {
+	httpstream.Stream
+	reader io.Reader
+	writer io.Writer
+	dialer *LimitedDialer
+}
+
+func (s *limitedStream) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	if n > 0 {
+		s.dialer.received.Add(int64(n))
+		metrics.StreamBytes.Add("portforward", "received", n)
+	}
+	return n, err
+}
+
+func (s *limitedStream) Write(p []byte) (int, error) {
+	n, err := s.writer.Write(p)
+	if n > 0 {
+		s.dialer.sent.Add(int64(n))
+		metrics.StreamBytes.Add("portforward", "sent", n)
+	}
+	return n, err
+}
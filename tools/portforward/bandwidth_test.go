@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestLimitedDialerHandleConnection(t *testing.T) {
+	remoteConnection := newFakeConnection()
+	remoteDataToSend := bytes.NewBufferString("test data from remote")
+	remoteDataReceived := bytes.NewBufferString("")
+	blockRemoteSend := make(chan struct{})
+	remoteConnection.dataStream.readFunc = func(p []byte) (int, error) {
+		<-blockRemoteSend // Wait for the expected data to be received before responding
+		return remoteDataToSend.Read(p)
+	}
+	remoteConnection.dataStream.writeFunc = func(p []byte) (int, error) {
+		n, err := remoteDataReceived.Write(p)
+		if remoteDataReceived.String() == "test data from local" {
+			close(blockRemoteSend)
+		}
+		return n, err
+	}
+	remoteConnection.errorStream.readFunc = bytes.NewBufferString("").Read
+
+	dialer := NewLimitedDialer(&fakeDialer{conn: remoteConnection}, BandwidthOptions{StreamBytesPerSecond: 1024 * 1024})
+	pf, err := New(dialer, []string{":2222"}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	pf.streamConn, _, err = dialer.Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+
+	localConnection := &fakeConn{
+		sendBuffer:    bytes.NewBufferString("test data from local"),
+		receiveBuffer: bytes.NewBufferString(""),
+	}
+	pf.handleConnection(localConnection, ForwardedPort{Local: 1111, Remote: 2222})
+
+	assert.Equal(t, 0, remoteConnection.streamCount, "the streams must be removed from the wrapped connection")
+	assert.Equal(t, "test data from local", remoteDataReceived.String())
+	assert.Equal(t, "test data from remote", localConnection.receiveBuffer.String())
+	// the local data is counted once its write returned, which may be after the connection is handled
+	assert.Eventually(t, func() bool {
+		return dialer.BytesSent() == int64(len("test data from local"))
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.Equal(t, int64(len("test data from remote")), dialer.BytesReceived())
+}
+
+func TestLimitedDialerConnectionLimit(t *testing.T) {
+	remoteConnection := newFakeConnection()
+	data := bytes.Repeat([]byte("x"), 48*1024)
+	remoteConnection.dataStream.readFunc = bytes.NewReader(data).Read
+	remoteConnection.dataStream.writeFunc = io.Discard.Write
+	dialer := NewLimitedDialer(&fakeDialer{conn: remoteConnection}, BandwidthOptions{ConnectionBytesPerSecond: 128 * 1024})
+	conn, _, err := dialer.Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+
+	headers := http.Header{}
+	headers.Set(v1.StreamType, v1.StreamTypeData)
+	stream, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+
+	// a burst of 32KiB, then 64KiB which are received and sent together at
+	// 128KiB per second
+	start := time.Now()
+	_, err = io.Copy(stream, stream)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 490*time.Millisecond)
+	assert.Equal(t, int64(len(data)), dialer.BytesReceived())
+	assert.Equal(t, int64(len(data)), dialer.BytesSent())
+
+	// closing the connection stops waiting for the limiter
+	require.NoError(t, conn.Close())
+	_, err = stream.Write(make([]byte, 1024*1024))
+	assert.Error(t, err)
+	assert.True(t, remoteConnection.closed)
+}
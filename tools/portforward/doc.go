@@ -15,5 +15,16 @@ limitations under the License.
 */
 
 // Package portforward adds support for SSH-like port forwarding from the client's
-// local host to remote containers.
+// local host to remote containers, and for reverse port forwarding from remote
+// containers to the client's local host.
+//
+// Reverse port forwarding runs a helper in the container, which calls
+// ServeReverse with a listener and its standard input and output. The
+// helper is run through the exec subresource with stdin and stdout, like
+//
+//	kubectl exec -i <pod> -- /reverse-port-forward -serve localhost:9090
+//
+// and the executor of the exec request is passed to NewReverse. See
+// examples/reverse-port-forward for a program which is both the helper and
+// the client.
 package portforward
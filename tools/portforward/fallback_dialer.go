@@ -42,6 +42,18 @@ func NewFallbackDialer(primary, secondary httpstream.Dialer, shouldFallback func
 	}
 }
 
+// NewFallbackDialerChain creates a dialer which tries dialers in order, and
+// falls back to the next one as long as shouldFallback returns true for the
+// error of a dialer, e.g. from the native WebSocket dialer to the SPDY over
+// WebSocket tunneling dialer to the SPDY dialer. dialers must not be empty.
+func NewFallbackDialerChain(shouldFallback func(error) bool, dialers ...httpstream.Dialer) httpstream.Dialer {
+	dialer := dialers[len(dialers)-1]
+	for i := len(dialers) - 2; i >= 0; i-- {
+		dialer = NewFallbackDialer(dialers[i], dialer, shouldFallback)
+	}
+	return dialer
+}
+
 // Dial is the single function necessary to implement the "httpstream.Dialer" interface.
 // It takes the protocol version strings to request, returning an the upgraded
 // httstream.Connection and the negotiated protocol version accepted. If the initial
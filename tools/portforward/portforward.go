@@ -50,6 +50,8 @@ var (
 type PortForwarder struct {
 	addresses []listenAddress
 	ports     []ForwardedPort
+	// protocols holds the protocol of each of ports.
+	protocols []v1.Protocol
 	stopChan  <-chan struct{}
 
 	dialer        httpstream.Dialer
@@ -60,6 +62,11 @@ type PortForwarder struct {
 	requestID     int
 	out           io.Writer
 	errOut        io.Writer
+
+	// reconnect is set for a PortForwarder created by NewReconnecting,
+	// whose streamConn is replaced on every connection.
+	reconnect      *reconnectState
+	streamConnLock sync.Mutex
 }
 
 // ForwardedPort contains a Local:Remote port pairing.
@@ -81,10 +88,18 @@ valid port specifications:
 :5000
   - selects a random available local port,
     forwards from localhost:<random port> to pod:5000
+
+5353:53/udp
+- forwards datagrams from localhost:5353 to pod:53 over UDP, which needs a
+  server supporting PortForwardProtocolV2Name
 */
 func parsePorts(ports []string) ([]ForwardedPort, error) {
 	var forwards []ForwardedPort
 	for _, portString := range ports {
+		portString, _, err := splitPortProtocol(portString)
+		if err != nil {
+			return nil, err
+		}
 		parts := strings.Split(portString, ":")
 		var localString, remoteString string
 		if len(parts) == 1 {
@@ -184,6 +199,7 @@ func NewOnAddresses(dialer httpstream.Dialer, addresses []string, ports []string
 		dialer:    dialer,
 		addresses: parsedAddresses,
 		ports:     parsedPorts,
+		protocols: parsePortProtocols(ports),
 		stopChan:  stopChan,
 		Ready:     readyChan,
 		out:       out,
@@ -192,20 +208,21 @@ func NewOnAddresses(dialer httpstream.Dialer, addresses []string, ports []string
 }
 
 // ForwardPorts formats and executes a port forwarding request. The connection will remain
-// open until stopChan is closed.
+// open until stopChan is closed. A PortForwarder created by NewReconnecting
+// reconnects when the connection is lost, and only returns once stopChan is
+// closed or none of the ports could be listened on.
 func (pf *PortForwarder) ForwardPorts() error {
+	if pf.reconnect != nil {
+		return pf.forwardReconnecting()
+	}
 	defer pf.Close()
 
 	var err error
-	var protocol string
-	pf.streamConn, protocol, err = pf.dialer.Dial(PortForwardProtocolV1Name)
+	pf.streamConn, err = pf.dial(pf.dialer)
 	if err != nil {
-		return fmt.Errorf("error upgrading connection: %s", err)
+		return err
 	}
 	defer pf.streamConn.Close()
-	if protocol != PortForwardProtocolV1Name {
-		return fmt.Errorf("unable to negotiate protocol: client supports %q, server returned %q", PortForwardProtocolV1Name, protocol)
-	}
 
 	return pf.forward()
 }
@@ -214,12 +231,33 @@ func (pf *PortForwarder) ForwardPorts() error {
 // listeners for each port specified in ports, and forwards local connections
 // to the remote host via streams.
 func (pf *PortForwarder) forward() error {
+	if err := pf.listen(); err != nil {
+		return err
+	}
+
+	if pf.Ready != nil {
+		close(pf.Ready)
+	}
+
+	// wait for interrupt or conn closure
+	select {
+	case <-pf.stopChan:
+	case <-pf.streamConn.CloseChan():
+		return ErrLostConnectionToPod
+	}
+
+	return nil
+}
+
+// listen starts listeners for each port specified in ports. It fails if
+// none of the ports could be listened on.
+func (pf *PortForwarder) listen() error {
 	var err error
 
 	listenSuccess := false
 	for i := range pf.ports {
 		port := &pf.ports[i]
-		err = pf.listenOnPort(port)
+		err = pf.listenOnPort(port, pf.portProtocol(i))
 		switch {
 		case err == nil:
 			listenSuccess = true
@@ -233,29 +271,17 @@ func (pf *PortForwarder) forward() error {
 	if !listenSuccess {
 		return fmt.Errorf("unable to listen on any of the requested ports: %v", pf.ports)
 	}
-
-	if pf.Ready != nil {
-		close(pf.Ready)
-	}
-
-	// wait for interrupt or conn closure
-	select {
-	case <-pf.stopChan:
-	case <-pf.streamConn.CloseChan():
-		return ErrLostConnectionToPod
-	}
-
 	return nil
 }
 
 // listenOnPort delegates listener creation and waits for connections on requested bind addresses.
 // An error is raised based on address groups (default and localhost) and their failure modes
-func (pf *PortForwarder) listenOnPort(port *ForwardedPort) error {
+func (pf *PortForwarder) listenOnPort(port *ForwardedPort, portProtocol v1.Protocol) error {
 	var errors []error
 	failCounters := make(map[string]int, 2)
 	successCounters := make(map[string]int, 2)
 	for _, addr := range pf.addresses {
-		err := pf.listenOnPortAndAddress(port, addr.protocol, addr.address)
+		err := pf.listenOnPortAndAddress(port, portProtocol, addr.protocol, addr.address)
 		if err != nil {
 			errors = append(errors, err)
 			failCounters[addr.failureMode]++
@@ -274,7 +300,16 @@ func (pf *PortForwarder) listenOnPort(port *ForwardedPort) error {
 
 // listenOnPortAndAddress delegates listener creation and waits for new connections
 // in the background f
-func (pf *PortForwarder) listenOnPortAndAddress(port *ForwardedPort, protocol string, address string) error {
+func (pf *PortForwarder) listenOnPortAndAddress(port *ForwardedPort, portProtocol v1.Protocol, protocol string, address string) error {
+	if portProtocol == v1.ProtocolUDP {
+		conn, err := pf.getPacketListener(strings.Replace(protocol, "tcp", "udp", 1), address, port)
+		if err != nil {
+			return err
+		}
+		pf.listeners = append(pf.listeners, conn)
+		go pf.waitForDatagrams(conn, *port)
+		return nil
+	}
 	listener, err := pf.getListener(protocol, address, port)
 	if err != nil {
 		return err
@@ -312,7 +347,7 @@ func (pf *PortForwarder) getListener(protocol string, hostname string, port *For
 func (pf *PortForwarder) waitForConnection(listener net.Listener, port ForwardedPort) {
 	for {
 		select {
-		case <-pf.streamConn.CloseChan():
+		case <-pf.listenersDone():
 			return
 		default:
 			conn, err := listener.Accept()
@@ -328,6 +363,38 @@ func (pf *PortForwarder) waitForConnection(listener net.Listener, port Forwarded
 	}
 }
 
+// listenersDone returns a channel which is closed once the listeners
+// should stop accepting connections. The listeners of a PortForwarder which
+// reconnects outlive its connections.
+func (pf *PortForwarder) listenersDone() <-chan bool {
+	if pf.reconnect != nil {
+		return nil
+	}
+	return pf.streamConn.CloseChan()
+}
+
+// connection returns the connection to forward new connections through. A
+// PortForwarder which reconnects waits for the next connection if it is not
+// connected, and returns nil once it is stopped.
+func (pf *PortForwarder) connection() httpstream.Connection {
+	if pf.reconnect == nil {
+		return pf.streamConn
+	}
+	for {
+		pf.streamConnLock.Lock()
+		streamConn, connected := pf.streamConn, pf.reconnect.connected
+		pf.streamConnLock.Unlock()
+		if streamConn != nil {
+			return streamConn
+		}
+		select {
+		case <-connected:
+		case <-pf.stopChan:
+			return nil
+		}
+	}
+}
+
 func (pf *PortForwarder) nextRequestID() int {
 	pf.requestIDLock.Lock()
 	defer pf.requestIDLock.Unlock()
@@ -345,6 +412,12 @@ func (pf *PortForwarder) handleConnection(conn net.Conn, port ForwardedPort) {
 		fmt.Fprintf(pf.out, "Handling connection for %d\n", port.Local)
 	}
 
+	streamConn := pf.connection()
+	if streamConn == nil {
+		// the PortForwarder was stopped while reconnecting
+		return
+	}
+
 	requestID := pf.nextRequestID()
 
 	// create error stream
@@ -352,14 +425,14 @@ func (pf *PortForwarder) handleConnection(conn net.Conn, port ForwardedPort) {
 	headers.Set(v1.StreamType, v1.StreamTypeError)
 	headers.Set(v1.PortHeader, fmt.Sprintf("%d", port.Remote))
 	headers.Set(v1.PortForwardRequestIDHeader, strconv.Itoa(requestID))
-	errorStream, err := pf.streamConn.CreateStream(headers)
+	errorStream, err := streamConn.CreateStream(headers)
 	if err != nil {
 		runtime.HandleError(fmt.Errorf("error creating error stream for port %d -> %d: %v", port.Local, port.Remote, err))
 		return
 	}
 	// we're not writing to this stream
 	errorStream.Close()
-	defer pf.streamConn.RemoveStreams(errorStream)
+	defer streamConn.RemoveStreams(errorStream)
 
 	errorChan := make(chan error)
 	go func() {
@@ -375,12 +448,12 @@ func (pf *PortForwarder) handleConnection(conn net.Conn, port ForwardedPort) {
 
 	// create data stream
 	headers.Set(v1.StreamType, v1.StreamTypeData)
-	dataStream, err := pf.streamConn.CreateStream(headers)
+	dataStream, err := streamConn.CreateStream(headers)
 	if err != nil {
 		runtime.HandleError(fmt.Errorf("error creating forwarding stream for port %d -> %d: %v", port.Local, port.Remote, err))
 		return
 	}
-	defer pf.streamConn.RemoveStreams(dataStream)
+	defer streamConn.RemoveStreams(dataStream)
 
 	localError := make(chan struct{})
 	remoteDone := make(chan struct{})
@@ -422,7 +495,7 @@ func (pf *PortForwarder) handleConnection(conn net.Conn, port ForwardedPort) {
 	err = <-errorChan
 	if err != nil {
 		runtime.HandleError(err)
-		pf.streamConn.Close()
+		streamConn.Close()
 	}
 }
 
@@ -0,0 +1,196 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DialerFunc returns the dialer for the next connection to the pod. It is
+// called for every connection, so that it can resolve the pod again when
+// the previous one was replaced.
+type DialerFunc func(ctx context.Context) (httpstream.Dialer, error)
+
+// ConnectionState is the state of the connection of a PortForwarder which
+// reconnects.
+type ConnectionState string
+
+const (
+	// StateConnecting means that the PortForwarder is connecting to the pod.
+	StateConnecting ConnectionState = "Connecting"
+	// StateConnected means that local connections are forwarded to the pod.
+	StateConnected ConnectionState = "Connected"
+	// StateDisconnected means that the connection to the pod failed or was
+	// lost, and that the PortForwarder waits before connecting again. Local
+	// connections wait for the next connection.
+	StateDisconnected ConnectionState = "Disconnected"
+	// StateStopped means that the PortForwarder was stopped.
+	StateStopped ConnectionState = "Stopped"
+)
+
+// ReconnectOptions configures a PortForwarder which reconnects.
+type ReconnectOptions struct {
+	// Backoff is the delay between the attempts to connect to the pod. It
+	// starts over once a connection succeeds. By default, the delay starts
+	// at one second and doubles up to 30 seconds.
+	Backoff wait.Backoff
+	// OnStateChange, if set, is called whenever the state of the connection
+	// changes, with the error which caused the PortForwarder to disconnect.
+	OnStateChange func(state ConnectionState, err error)
+}
+
+// DefaultReconnectBackoff is the default delay between the attempts to
+// connect to the pod.
+var DefaultReconnectBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      30 * time.Second,
+}
+
+// reconnectState is the state of a PortForwarder which reconnects.
+type reconnectState struct {
+	dialerFunc DialerFunc
+	options    ReconnectOptions
+	// connected is closed once streamConn is set.
+	connected chan struct{}
+}
+
+// NewReconnecting creates a new PortForwarder with custom listen addresses,
+// whose listeners stay open when the connection to the pod is lost.
+// ForwardPorts connects to the pod with the dialer returned by dialerFunc,
+// and connects again with backoff when the connection fails, until stopChan
+// is closed. Local connections which are accepted while the PortForwarder is
+// disconnected wait for the next connection.
+//
+// readyChan is closed once the listeners are open, which may be before the
+// first connection to the pod.
+func NewReconnecting(dialerFunc DialerFunc, addresses []string, ports []string, stopChan <-chan struct{}, readyChan chan struct{}, out, errOut io.Writer, options ReconnectOptions) (*PortForwarder, error) {
+	if dialerFunc == nil {
+		return nil, errors.New("a dialer func is required")
+	}
+	if stopChan == nil {
+		return nil, errors.New("a stop channel is required to stop reconnecting")
+	}
+	pf, err := NewOnAddresses(nil, addresses, ports, stopChan, readyChan, out, errOut)
+	if err != nil {
+		return nil, err
+	}
+	if options.Backoff == (wait.Backoff{}) {
+		options.Backoff = DefaultReconnectBackoff
+	}
+	pf.reconnect = &reconnectState{
+		dialerFunc: dialerFunc,
+		options:    options,
+		connected:  make(chan struct{}),
+	}
+	return pf, nil
+}
+
+// forwardReconnecting starts the listeners, and keeps connecting to the pod
+// until stopChan is closed.
+func (pf *PortForwarder) forwardReconnecting() error {
+	defer pf.Close()
+	defer pf.setState(StateStopped, nil)
+
+	if err := pf.listen(); err != nil {
+		return err
+	}
+	if pf.Ready != nil {
+		close(pf.Ready)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-pf.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := pf.reconnect.options.Backoff
+	for {
+		pf.setState(StateConnecting, nil)
+		streamConn, err := pf.dialReconnecting(ctx)
+		if err == nil {
+			pf.setConnection(streamConn)
+			pf.setState(StateConnected, nil)
+			backoff = pf.reconnect.options.Backoff
+			select {
+			case <-pf.stopChan:
+				streamConn.Close()
+				return nil
+			case <-streamConn.CloseChan():
+				err = ErrLostConnectionToPod
+			}
+			pf.setConnection(nil)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		pf.setState(StateDisconnected, err)
+		if pf.errOut != nil {
+			fmt.Fprintf(pf.errOut, "Reconnecting after error: %v\n", err)
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-pf.stopChan:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// dialReconnecting opens the next connection to the pod.
+func (pf *PortForwarder) dialReconnecting(ctx context.Context) (httpstream.Connection, error) {
+	dialer, err := pf.reconnect.dialerFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pf.dial(dialer)
+}
+
+// setConnection replaces the connection to forward new connections
+// through, nil while disconnected.
+func (pf *PortForwarder) setConnection(streamConn httpstream.Connection) {
+	pf.streamConnLock.Lock()
+	defer pf.streamConnLock.Unlock()
+	pf.streamConn = streamConn
+	if streamConn != nil {
+		close(pf.reconnect.connected)
+	} else {
+		pf.reconnect.connected = make(chan struct{})
+	}
+}
+
+func (pf *PortForwarder) setState(state ConnectionState, err error) {
+	if onStateChange := pf.reconnect.options.OnStateChange; onStateChange != nil {
+		onStateChange(state, err)
+	}
+}
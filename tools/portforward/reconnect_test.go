@@ -0,0 +1,227 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type stateChange struct {
+	state ConnectionState
+	err   error
+}
+
+func TestForwardPortsReconnects(t *testing.T) {
+	dialErr := errors.New("pod not found")
+	connections := make(chan *fakeConnection, 2)
+	dials := 0
+	dialerFunc := func(ctx context.Context) (httpstream.Dialer, error) {
+		dials++
+		if dials == 2 {
+			return nil, dialErr
+		}
+		conn := newFakeConnection()
+		// The pod closes forwarded connections right away.
+		for _, stream := range []*fakeStream{conn.dataStream, conn.errorStream} {
+			stream.readFunc = func([]byte) (int, error) { return 0, io.EOF }
+			stream.writeFunc = func(p []byte) (int, error) { return len(p), nil }
+		}
+		connections <- conn
+		return &fakeDialer{conn: conn, negotiatedProtocol: PortForwardProtocolV1Name}, nil
+	}
+	states := make(chan stateChange, 10)
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	pf, err := NewReconnecting(dialerFunc, []string{"127.0.0.1"}, []string{":5000"}, stopChan, readyChan, io.Discard, io.Discard, ReconnectOptions{
+		Backoff: wait.Backoff{Duration: time.Millisecond},
+		OnStateChange: func(state ConnectionState, err error) {
+			states <- stateChange{state, err}
+		},
+	})
+	require.NoError(t, err)
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- pf.ForwardPorts()
+	}()
+	expectStates := func(expected ...stateChange) {
+		t.Helper()
+		for _, e := range expected {
+			select {
+			case actual := <-states:
+				assert.Equal(t, e, actual)
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatalf("timeout waiting for state %s", e.state)
+			}
+		}
+	}
+	expectStates(stateChange{StateConnecting, nil}, stateChange{StateConnected, nil})
+	<-readyChan
+	ports, err := pf.GetPorts()
+	require.NoError(t, err)
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local)))
+
+	// Losing the connection and failing to dial make the forwarder connect
+	// again.
+	(<-connections).Close()
+	expectStates(
+		stateChange{StateDisconnected, ErrLostConnectionToPod},
+		stateChange{StateConnecting, nil},
+		stateChange{StateDisconnected, dialErr},
+		stateChange{StateConnecting, nil},
+		stateChange{StateConnected, nil},
+	)
+	assert.Equal(t, 3, dials)
+
+	// The listener stayed open.
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	close(stopChan)
+	require.NoError(t, <-errChan)
+	expectStates(stateChange{StateStopped, nil})
+	_, err = net.Dial("tcp", address)
+	require.Error(t, err, "expected the listener to be closed")
+}
+
+func TestReconnectingConnectionWaits(t *testing.T) {
+	stopChan := make(chan struct{})
+	pf, err := NewReconnecting(func(context.Context) (httpstream.Dialer, error) {
+		return nil, errors.New("not dialed")
+	}, []string{"127.0.0.1"}, []string{":5000"}, stopChan, nil, io.Discard, io.Discard, ReconnectOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultReconnectBackoff, pf.reconnect.options.Backoff)
+
+	// Local connections wait for the next connection to the pod.
+	result := make(chan httpstream.Connection)
+	go func() {
+		result <- pf.connection()
+	}()
+	streamConn := newFakeConnection()
+	pf.setConnection(streamConn)
+	assert.Equal(t, httpstream.Connection(streamConn), <-result)
+
+	// Or until the forwarder is stopped.
+	pf.setConnection(nil)
+	go func() {
+		result <- pf.connection()
+	}()
+	close(stopChan)
+	assert.Nil(t, <-result)
+}
+
+func newPod(name string, created time.Time, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{"app": "web"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPodResolver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Now()
+	client := fake.NewClientset(
+		newPod("old", now.Add(-2*time.Hour), true),
+		newPod("new", now.Add(-time.Hour), true),
+		newPod("newest", now, false),
+	)
+	resolver, err := NewPodResolverForService(client, &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	})
+	require.NoError(t, err)
+	go resolver.Run(ctx)
+
+	resolve := func() string {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(ctx, wait.ForeverTestTimeout)
+		defer cancel()
+		pod, err := resolver.Resolve(ctx)
+		require.NoError(t, err)
+		return pod.Name
+	}
+	// The newest ready pod is resolved.
+	assert.Equal(t, "new", resolve())
+
+	// The resolved pod is kept while it is ready, even if a newer one is.
+	pods := client.CoreV1().Pods("default")
+	_, err = pods.UpdateStatus(ctx, newPod("newest", now, true), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		obj, exists, err := resolver.informer.GetStore().GetByKey("default/newest")
+		return exists && isPodReady(obj.(*v1.Pod)), err
+	}))
+	assert.Equal(t, "new", resolve())
+
+	// When the pod is replaced, the next newest one is resolved.
+	require.NoError(t, pods.Delete(ctx, "new", metav1.DeleteOptions{}))
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return resolve() == "newest", nil
+	}))
+
+	// Without a ready pod, Resolve waits for one.
+	require.NoError(t, pods.Delete(ctx, "newest", metav1.DeleteOptions{}))
+	require.NoError(t, pods.Delete(ctx, "old", metav1.DeleteOptions{}))
+	require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(resolver.informer.GetStore().List()) == 0, nil
+	}))
+	resolved := make(chan string)
+	go func() {
+		resolved <- resolve()
+	}()
+	_, err = pods.Create(ctx, newPod("replacement", now, true), metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "replacement", <-resolved)
+
+	// Resolve fails if no pod becomes ready in time.
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	otherResolver := NewPodResolver(client, "default", labels.SelectorFromSet(labels.Set{"app": "db"}))
+	go otherResolver.Run(ctx)
+	_, err = otherResolver.Resolve(shortCtx)
+	require.Error(t, err)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/klog/v2"
+)
+
+// PodResolver resolves the pods matching a label selector, such as the
+// selector of a Service or a Deployment, to one ready pod. It watches the
+// pods with an informer, which must be started with Run.
+type PodResolver struct {
+	informer cache.SharedIndexInformer
+
+	lock sync.Mutex
+	// current is the name of the last resolved pod, which is resolved again
+	// as long as it is ready.
+	current string
+	// changed is closed when the pods change.
+	changed chan struct{}
+}
+
+// NewPodResolver returns a resolver for the pods in namespace which match
+// selector.
+func NewPodResolver(client kubernetes.Interface, namespace string, selector labels.Selector) *PodResolver {
+	r := &PodResolver{
+		informer: coreinformers.NewFilteredPodInformer(client, namespace, 0, cache.Indexers{}, func(options *metav1.ListOptions) {
+			options.LabelSelector = selector.String()
+		}),
+		changed: make(chan struct{}),
+	}
+	_, _ = r.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { r.notify() },
+		UpdateFunc: func(interface{}, interface{}) { r.notify() },
+		DeleteFunc: func(interface{}) { r.notify() },
+	})
+	return r
+}
+
+// NewPodResolverForService returns a resolver for the pods which back
+// service.
+func NewPodResolverForService(client kubernetes.Interface, service *v1.Service) (*PodResolver, error) {
+	if len(service.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s/%s has no selector", service.Namespace, service.Name)
+	}
+	return NewPodResolver(client, service.Namespace, labels.SelectorFromSet(service.Spec.Selector)), nil
+}
+
+// NewPodResolverForDeployment returns a resolver for the pods of
+// deployment.
+func NewPodResolverForDeployment(client kubernetes.Interface, deployment *appsv1.Deployment) (*PodResolver, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+	}
+	return NewPodResolver(client, deployment.Namespace, selector), nil
+}
+
+// Run watches the pods until ctx is done.
+func (r *PodResolver) Run(ctx context.Context) {
+	r.informer.RunWithContext(ctx)
+}
+
+func (r *PodResolver) notify() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Resolve returns a ready pod, and waits for one until ctx is done. The
+// same pod is returned as long as it is ready; otherwise the newest ready
+// pod is, since older pods are the first to go away in a rollout.
+func (r *PodResolver) Resolve(ctx context.Context) (*v1.Pod, error) {
+	if !cache.WaitForCacheSync(ctx.Done(), r.informer.HasSynced) {
+		return nil, fmt.Errorf("failed to sync the pods: %w", ctx.Err())
+	}
+	for {
+		r.lock.Lock()
+		changed := r.changed
+		r.lock.Unlock()
+
+		if pod := r.pick(); pod != nil {
+			return pod, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no ready pod found: %w", ctx.Err())
+		}
+	}
+}
+
+// pick returns the pod to resolve to, or nil if no pod is ready.
+func (r *PodResolver) pick() *v1.Pod {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var newest *v1.Pod
+	for _, obj := range r.informer.GetStore().List() {
+		pod := obj.(*v1.Pod)
+		if !isPodReady(pod) {
+			continue
+		}
+		if pod.Name == r.current {
+			return pod
+		}
+		if newest == nil || newest.CreationTimestamp.Before(&pod.CreationTimestamp) ||
+			(newest.CreationTimestamp.Equal(&pod.CreationTimestamp) && pod.Name > newest.Name) {
+			newest = pod
+		}
+	}
+	if newest != nil {
+		r.current = newest.Name
+	}
+	return newest
+}
+
+// isPodReady returns whether pod is running, ready and not being deleted.
+func isPodReady(pod *v1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// NewPodDialerFunc returns a DialerFunc for NewReconnecting which connects
+// to the pod returned by resolve, such as PodResolver.Resolve, with SPDY.
+func NewPodDialerFunc(config *restclient.Config, resolve func(ctx context.Context) (*v1.Pod, error)) (DialerFunc, error) {
+	client, err := corev1client.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (httpstream.Dialer, error) {
+		pod, err := resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		klog.V(4).Infof("Forwarding to pod %s/%s", pod.Namespace, pod.Name)
+		// A SPDY round tripper only holds a single connection.
+		transport, upgrader, err := spdy.RoundTripperFor(config)
+		if err != nil {
+			return nil, err
+		}
+		url := client.RESTClient().Post().
+			Resource("pods").
+			Namespace(pod.Namespace).
+			Name(pod.Name).
+			SubResource("portforward").
+			URL()
+		return spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url), nil
+	}, nil
+}
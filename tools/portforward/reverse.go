@@ -0,0 +1,523 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Reverse port forwarding exposes a local address in a pod. It needs no
+// support from the cluster beyond exec: a helper process, which calls
+// ServeReverse, listens in the pod and tunnels the connections it accepts
+// through its standard input and output.
+//
+// Both directions of the tunnel carry frames of a 9-byte header and a
+// payload. The header is the frame type, the 32-bit big-endian ID of the
+// connection, and the 32-bit big-endian size of the payload. The helper
+// sends reverseFrameReady once it listens, and reverseFrameOpen for every
+// connection it accepts. Both sides then send reverseFrameData with the
+// data of the connection, reverseFrameEOF once their end of the connection
+// was closed for writing, and reverseFrameClose to abort the connection.
+const (
+	// reverseFrameReady carries the address the helper listens on.
+	reverseFrameReady byte = iota + 1
+	// reverseFrameOpen announces a new connection to the helper.
+	reverseFrameOpen
+	// reverseFrameData carries data of a connection.
+	reverseFrameData
+	// reverseFrameEOF means that no more data follows for a connection.
+	reverseFrameEOF
+	// reverseFrameClose aborts a connection.
+	reverseFrameClose
+)
+
+const (
+	reverseFrameHeaderSize = 9
+	// maxReversePayload is the size of the largest payload of a frame.
+	maxReversePayload = 32 * 1024
+)
+
+// ReverseForwarder exposes a local address in a remote pod. It runs the
+// helper through an executor for the exec subresource of the pod, and dials
+// the local address for every connection to the helper.
+type ReverseForwarder struct {
+	executor     remotecommand.Executor
+	localAddress string
+	stopChan     <-chan struct{}
+	dialer       net.Dialer
+
+	Ready         chan struct{}
+	readyOnce     sync.Once
+	remoteAddress string
+	out           io.Writer
+	errOut        io.Writer
+}
+
+// NewReverse creates a new ReverseForwarder which forwards the connections
+// to the helper run by executor to localAddress, in host:port form. The
+// executor must run a command in the pod which calls ServeReverse, for
+// example one created by remotecommand.NewSPDYExecutor for a request to the
+// exec subresource with stdin and stdout.
+func NewReverse(executor remotecommand.Executor, localAddress string, stopChan <-chan struct{}, readyChan chan struct{}, out, errOut io.Writer) (*ReverseForwarder, error) {
+	if executor == nil {
+		return nil, errors.New("you must specify an executor")
+	}
+	if _, _, err := net.SplitHostPort(localAddress); err != nil {
+		return nil, fmt.Errorf("invalid local address %q: %v", localAddress, err)
+	}
+	return &ReverseForwarder{
+		executor:     executor,
+		localAddress: localAddress,
+		stopChan:     stopChan,
+		Ready:        readyChan,
+		out:          out,
+		errOut:       errOut,
+	}, nil
+}
+
+// ForwardPorts runs the helper in the pod and forwards its connections. It
+// returns once stopChan is closed, or with an error once the helper exits.
+func (rf *ReverseForwarder) ForwardPorts() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	streamErr := make(chan error, 1)
+	go func() {
+		err := rf.executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdin:  stdinReader,
+			Stdout: stdoutWriter,
+			Stderr: rf.errOut,
+		})
+		stdoutWriter.Close()
+		streamErr <- err
+	}()
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-rf.stopChan:
+			close(stopped)
+			// the helper exits once its standard input is closed
+			stdinWriter.Close()
+		case <-ctx.Done():
+		}
+	}()
+
+	tunnel := newReverseTunnel(stdinWriter)
+	err := tunnel.run(stdoutReader, func(kind byte, id uint32, payload []byte) error {
+		return rf.handleFrame(ctx, tunnel, kind, id, payload)
+	})
+	cancel()
+	stdinWriter.Close()
+	stdoutReader.Close()
+	if streamErr := <-streamErr; err == nil && streamErr != nil {
+		err = fmt.Errorf("error running reverse port forwarding helper: %v", streamErr)
+	}
+
+	select {
+	case <-stopped:
+		return nil
+	default:
+	}
+	if err == nil {
+		err = ErrLostConnectionToPod
+	}
+	return err
+}
+
+// handleFrame handles the frames of the helper which are not about the data
+// of a connection.
+func (rf *ReverseForwarder) handleFrame(ctx context.Context, tunnel *reverseTunnel, kind byte, id uint32, payload []byte) error {
+	switch kind {
+	case reverseFrameReady:
+		rf.readyOnce.Do(func() {
+			rf.remoteAddress = string(payload)
+			if rf.out != nil {
+				fmt.Fprintf(rf.out, "Forwarding from %s in pod -> %s\n", rf.remoteAddress, rf.localAddress)
+			}
+			if rf.Ready != nil {
+				close(rf.Ready)
+			}
+		})
+		return nil
+	case reverseFrameOpen:
+		c, err := tunnel.add(id)
+		if err != nil {
+			return err
+		}
+		go func() {
+			conn, err := rf.dialer.DialContext(ctx, "tcp", rf.localAddress)
+			if err != nil {
+				runtime.HandleError(fmt.Errorf("error dialing %s for reverse connection %d: %v", rf.localAddress, id, err))
+				_ = tunnel.writeFrame(reverseFrameClose, id, nil)
+				c.abort()
+				return
+			}
+			if rf.out != nil {
+				fmt.Fprintf(rf.out, "Handling reverse connection for %s\n", rf.localAddress)
+			}
+			c.serve(conn)
+		}()
+		return nil
+	default:
+		return fmt.Errorf("unexpected reverse port forwarding frame of type %d", kind)
+	}
+}
+
+// RemoteAddress returns the address the helper listens on in the pod. Like
+// PortForwarder.GetPorts, it fails if the Ready channel is nil or not closed
+// yet.
+func (rf *ReverseForwarder) RemoteAddress() (string, error) {
+	if rf.Ready == nil {
+		return "", fmt.Errorf("no Ready channel provided")
+	}
+	select {
+	case <-rf.Ready:
+		return rf.remoteAddress, nil
+	default:
+		return "", fmt.Errorf("helper not ready")
+	}
+}
+
+// ServeReverse is the helper side of reverse port forwarding, which runs in
+// the pod. It tunnels the connections accepted by listener through out, and
+// their data from in, until in is closed. It closes listener before it
+// returns.
+func ServeReverse(listener net.Listener, in io.Reader, out io.Writer) error {
+	defer listener.Close()
+
+	tunnel := newReverseTunnel(out)
+	if err := tunnel.writeFrame(reverseFrameReady, 0, []byte(listener.Addr().String())); err != nil {
+		return err
+	}
+	go func() {
+		for id := uint32(1); ; id++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !strings.Contains(strings.ToLower(err.Error()), networkClosedError) {
+					runtime.HandleError(fmt.Errorf("error accepting reverse connection: %v", err))
+				}
+				return
+			}
+			c, err := tunnel.add(id)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			if err := tunnel.writeFrame(reverseFrameOpen, id, nil); err != nil {
+				c.abort()
+				conn.Close()
+				return
+			}
+			go c.serve(conn)
+		}
+	}()
+
+	return tunnel.run(in, func(kind byte, id uint32, payload []byte) error {
+		return fmt.Errorf("unexpected reverse port forwarding frame of type %d", kind)
+	})
+}
+
+// reverseTunnel multiplexes connections over a pair of byte streams.
+type reverseTunnel struct {
+	writeLock sync.Mutex
+	w         io.Writer
+
+	lock   sync.Mutex
+	conns  map[uint32]*reverseConn
+	closed bool
+}
+
+func newReverseTunnel(w io.Writer) *reverseTunnel {
+	return &reverseTunnel{w: w, conns: map[uint32]*reverseConn{}}
+}
+
+// writeFrame writes a frame at once, so that it is never split by another
+// writer.
+func (t *reverseTunnel) writeFrame(kind byte, id uint32, payload []byte) error {
+	frame := make([]byte, reverseFrameHeaderSize+len(payload))
+	frame[0] = kind
+	binary.BigEndian.PutUint32(frame[1:], id)
+	binary.BigEndian.PutUint32(frame[5:], uint32(len(payload)))
+	copy(frame[reverseFrameHeaderSize:], payload)
+
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	_, err := t.w.Write(frame)
+	return err
+}
+
+// readReverseFrame reads a frame written by writeFrame into buf, which must
+// have room for maxReversePayload bytes.
+func readReverseFrame(r io.Reader, buf []byte) (byte, uint32, []byte, error) {
+	var header [reverseFrameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header[5:])
+	if n > maxReversePayload {
+		return 0, 0, nil, fmt.Errorf("reverse port forwarding frame of %d bytes is too large", n)
+	}
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, nil, err
+	}
+	return header[0], binary.BigEndian.Uint32(header[1:]), buf[:n], nil
+}
+
+// run delivers the frames read from r to their connections until r ends,
+// and aborts the remaining connections then. Frames which aren't about the
+// data of a connection are passed to handle.
+//
+// The data of a connection is delivered in order, so a connection which
+// doesn't keep up with its data holds up the others.
+func (t *reverseTunnel) run(r io.Reader, handle func(kind byte, id uint32, payload []byte) error) error {
+	defer t.closeAll()
+
+	buf := make([]byte, maxReversePayload)
+	for {
+		kind, id, payload, err := readReverseFrame(r, buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) {
+				return nil
+			}
+			return err
+		}
+		switch kind {
+		case reverseFrameData:
+			if c := t.get(id); c != nil {
+				c.receive(payload)
+			}
+		case reverseFrameEOF:
+			if c := t.get(id); c != nil {
+				c.receiveEOF()
+			}
+		case reverseFrameClose:
+			if c := t.get(id); c != nil {
+				c.abort()
+			}
+		default:
+			if err := handle(kind, id, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// add registers a new connection, which receives data as soon as it is
+// added.
+func (t *reverseTunnel) add(id uint32) (*reverseConn, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return nil, errors.New("reverse port forwarding tunnel is closed")
+	}
+	if _, ok := t.conns[id]; ok {
+		return nil, fmt.Errorf("duplicate reverse connection %d", id)
+	}
+	c := &reverseConn{
+		tunnel:   t,
+		id:       id,
+		incoming: make(chan []byte, 16),
+		aborted:  make(chan struct{}),
+	}
+	t.conns[id] = c
+	return c, nil
+}
+
+func (t *reverseTunnel) get(id uint32) *reverseConn {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.conns[id]
+}
+
+func (t *reverseTunnel) remove(c *reverseConn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.conns[c.id] == c {
+		delete(t.conns, c.id)
+	}
+}
+
+func (t *reverseTunnel) closeAll() {
+	t.lock.Lock()
+	t.closed = true
+	conns := make([]*reverseConn, 0, len(t.conns))
+	for _, c := range t.conns {
+		conns = append(conns, c)
+	}
+	t.lock.Unlock()
+	for _, c := range conns {
+		c.abort()
+	}
+}
+
+// reverseConn is a connection of a reverseTunnel.
+type reverseConn struct {
+	tunnel *reverseTunnel
+	id     uint32
+
+	// incoming carries the data of the peer, and is closed once the peer
+	// closed its end for writing. Only the run loop of the tunnel sends to
+	// it, and sets eof.
+	incoming chan []byte
+	eof      bool
+
+	abortOnce sync.Once
+	aborted   chan struct{}
+	connLock  sync.Mutex
+	conn      net.Conn
+}
+
+func (c *reverseConn) receive(payload []byte) {
+	if c.eof {
+		return
+	}
+	select {
+	case c.incoming <- append([]byte(nil), payload...):
+	case <-c.aborted:
+	}
+}
+
+func (c *reverseConn) receiveEOF() {
+	if !c.eof {
+		c.eof = true
+		close(c.incoming)
+	}
+}
+
+// abort closes the connection without waiting for its data.
+func (c *reverseConn) abort() {
+	c.abortOnce.Do(func() {
+		close(c.aborted)
+		c.tunnel.remove(c)
+		c.connLock.Lock()
+		defer c.connLock.Unlock()
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
+func (c *reverseConn) isAborted() bool {
+	select {
+	case <-c.aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// serve copies data between conn and the tunnel until both are done, or
+// the connection is aborted.
+func (c *reverseConn) serve(conn net.Conn) {
+	c.connLock.Lock()
+	c.conn = conn
+	c.connLock.Unlock()
+	if c.isAborted() {
+		conn.Close()
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.copyIncoming(conn)
+	}()
+	go func() {
+		defer wg.Done()
+		c.copyOutgoing(conn)
+	}()
+	wg.Wait()
+
+	c.tunnel.remove(c)
+	conn.Close()
+}
+
+// copyIncoming writes the data of the peer to conn, and closes conn for
+// writing once the peer is done.
+func (c *reverseConn) copyIncoming(conn net.Conn) {
+	for {
+		select {
+		case data, ok := <-c.incoming:
+			if !ok {
+				if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+					_ = cw.CloseWrite()
+				}
+				return
+			}
+			if _, err := conn.Write(data); err != nil {
+				c.fail(fmt.Errorf("error copying to reverse connection %d: %v", c.id, err))
+				return
+			}
+		case <-c.aborted:
+			return
+		}
+	}
+}
+
+// copyOutgoing sends the data read from conn to the peer.
+func (c *reverseConn) copyOutgoing(conn net.Conn) {
+	buf := make([]byte, maxReversePayload)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if err := c.tunnel.writeFrame(reverseFrameData, c.id, buf[:n]); err != nil {
+				c.abort()
+				return
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			if err := c.tunnel.writeFrame(reverseFrameEOF, c.id, nil); err != nil {
+				c.abort()
+			}
+			return
+		}
+		if err != nil {
+			c.fail(fmt.Errorf("error copying from reverse connection %d: %v", c.id, err))
+			return
+		}
+	}
+}
+
+// fail aborts the connection on both sides, and reports err unless the
+// connection was aborted already.
+func (c *reverseConn) fail(err error) {
+	if c.isAborted() {
+		return
+	}
+	if !strings.Contains(strings.ToLower(err.Error()), networkClosedError) {
+		runtime.HandleError(err)
+	}
+	_ = c.tunnel.writeFrame(reverseFrameClose, c.id, nil)
+	c.abort()
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// helperExecutor stands in for the exec of the helper in a pod: it serves
+// the streams with ServeReverse on a local listener.
+type helperExecutor struct{}
+
+func (e *helperExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *helperExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	return ServeReverse(listener, options.Stdin, options.Stdout)
+}
+
+// startEchoServer accepts connections which echo their data until the
+// client closes them for writing.
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func startReverseForwarder(t *testing.T, localAddress string) (*ReverseForwarder, chan struct{}, chan error) {
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	rf, err := NewReverse(&helperExecutor{}, localAddress, stopChan, readyChan, io.Discard, io.Discard)
+	require.NoError(t, err)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- rf.ForwardPorts()
+	}()
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		t.Fatalf("reverse forwarding failed before it was ready: %v", err)
+	case <-time.After(reverseTestTimeout):
+		t.Fatal("timed out waiting for the helper")
+	}
+	return rf, stopChan, errChan
+}
+
+const reverseTestTimeout = 10 * time.Second
+
+func TestReverseForwarding(t *testing.T) {
+	echo := startEchoServer(t)
+	rf, stopChan, errChan := startReverseForwarder(t, echo.Addr().String())
+
+	remoteAddress, err := rf.RemoteAddress()
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", remoteAddress)
+		require.NoError(t, err)
+		require.NoError(t, conn.SetDeadline(time.Now().Add(reverseTestTimeout)))
+
+		message := bytes.Repeat([]byte("hello "), maxReversePayload/3)
+		_, err = conn.Write(message)
+		require.NoError(t, err)
+		// the echo server closes the connection once it read everything
+		require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+		received, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, message, received)
+		conn.Close()
+	}
+
+	close(stopChan)
+	select {
+	case err := <-errChan:
+		assert.NoError(t, err)
+	case <-time.After(reverseTestTimeout):
+		t.Fatal("timed out waiting for ForwardPorts to return")
+	}
+}
+
+func TestReverseForwardingLocalDialFails(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddress := listener.Addr().String()
+	listener.Close()
+
+	rf, stopChan, errChan := startReverseForwarder(t, closedAddress)
+	defer func() {
+		close(stopChan)
+		<-errChan
+	}()
+
+	remoteAddress, err := rf.RemoteAddress()
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", remoteAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(reverseTestTimeout)))
+
+	// the helper closes the connection once the local dial failed
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+func TestReverseForwardingHelperExits(t *testing.T) {
+	stopChan := make(chan struct{})
+	executor := &failingExecutor{}
+	rf, err := NewReverse(executor, "localhost:8080", stopChan, nil, nil, nil)
+	require.NoError(t, err)
+
+	err = rf.ForwardPorts()
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "command terminated"), "unexpected error: %v", err)
+}
+
+type failingExecutor struct{}
+
+func (e *failingExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *failingExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	return errors.New("command terminated with exit code 1")
+}
+
+func TestNewReverseValidatesLocalAddress(t *testing.T) {
+	_, err := NewReverse(&failingExecutor{}, "8080", nil, nil, nil, nil)
+	assert.Error(t, err)
+	_, err = NewReverse(nil, "localhost:8080", nil, nil, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,389 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// PortForwardProtocolV2Name is the subprotocol used for port forwarding
+// with UDP ports. It is only requested when UDP ports are forwarded, and
+// they are only forwarded when the server returns it.
+//
+// A server must only accept it if it forwards the streams with the
+// ProtocolHeader "UDP" to the UDP port in the pod, as described there;
+// otherwise the datagrams would be written to the TCP port with the same
+// number. No released kubelet or CRI streaming server supports it yet, so
+// UDP ports fail with an error against them.
+const PortForwardProtocolV2Name = "portforward.k8s.io.v2"
+
+// ProtocolHeader is the header of the streams of a forwarded UDP port, with
+// the value "UDP". The streams of TCP ports don't have it. It is only sent
+// over connections which negotiated PortForwardProtocolV2Name.
+//
+// Every local peer of a UDP port gets its own pair of error and data
+// streams. The data stream carries the datagrams in both directions, each
+// prefixed with its length as a 16-bit big-endian integer.
+const ProtocolHeader = "protocol"
+
+const (
+	// maxDatagramSize is the size of the largest datagram which can be
+	// framed.
+	maxDatagramSize = 1<<16 - 1
+	// udpSessionTimeout is how long the streams of a local peer are kept
+	// without datagrams in either direction.
+	udpSessionTimeout = 2 * time.Minute
+)
+
+// splitPortProtocol splits the optional "/tcp" or "/udp" suffix off a port
+// specification.
+func splitPortProtocol(portString string) (string, v1.Protocol, error) {
+	i := strings.LastIndex(portString, "/")
+	if i < 0 {
+		return portString, v1.ProtocolTCP, nil
+	}
+	switch protocol := v1.Protocol(strings.ToUpper(portString[i+1:])); protocol {
+	case v1.ProtocolTCP, v1.ProtocolUDP:
+		return portString[:i], protocol, nil
+	default:
+		return "", "", fmt.Errorf("invalid protocol in port '%s'", portString)
+	}
+}
+
+// parsePortProtocols returns the protocol of each of the port specifications,
+// which have been validated by parsePorts.
+func parsePortProtocols(ports []string) []v1.Protocol {
+	protocols := make([]v1.Protocol, len(ports))
+	for i, portString := range ports {
+		_, protocols[i], _ = splitPortProtocol(portString)
+	}
+	return protocols
+}
+
+// portProtocol returns the protocol of the i-th port.
+func (pf *PortForwarder) portProtocol(i int) v1.Protocol {
+	if i < len(pf.protocols) {
+		return pf.protocols[i]
+	}
+	return v1.ProtocolTCP
+}
+
+// forwardsUDP returns whether any of the ports is a UDP port.
+func (pf *PortForwarder) forwardsUDP() bool {
+	for i := range pf.ports {
+		if pf.portProtocol(i) == v1.ProtocolUDP {
+			return true
+		}
+	}
+	return false
+}
+
+// dial upgrades a connection with dialer. PortForwardProtocolV2Name is
+// requested as well when UDP ports are forwarded, and then required, so
+// that datagrams are never sent to a server which doesn't handle them.
+func (pf *PortForwarder) dial(dialer httpstream.Dialer) (httpstream.Connection, error) {
+	if !pf.forwardsUDP() {
+		streamConn, protocol, err := dialer.Dial(PortForwardProtocolV1Name)
+		if err != nil {
+			return nil, fmt.Errorf("error upgrading connection: %s", err)
+		}
+		if protocol != PortForwardProtocolV1Name {
+			streamConn.Close()
+			return nil, fmt.Errorf("unable to negotiate protocol: client supports %q, server returned %q", PortForwardProtocolV1Name, protocol)
+		}
+		return streamConn, nil
+	}
+
+	streamConn, protocol, err := dialer.Dial(PortForwardProtocolV2Name, PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, fmt.Errorf("error upgrading connection: %s", err)
+	}
+	if protocol != PortForwardProtocolV2Name {
+		streamConn.Close()
+		return nil, fmt.Errorf("unable to forward UDP ports: server returned protocol %q, UDP requires %q", protocol, PortForwardProtocolV2Name)
+	}
+	return streamConn, nil
+}
+
+// getPacketListener creates a UDP socket on the interface targeted by the
+// given hostname on the given port. protocol is in net.ListenPacket style,
+// udp4 or udp6.
+func (pf *PortForwarder) getPacketListener(protocol string, hostname string, port *ForwardedPort) (net.PacketConn, error) {
+	conn, err := net.ListenPacket(protocol, net.JoinHostPort(hostname, strconv.Itoa(int(port.Local))))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create listener: Error %s", err)
+	}
+	port.Local = uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+	if pf.out != nil {
+		fmt.Fprintf(pf.out, "Forwarding from %s -> %d/udp\n", net.JoinHostPort(hostname, strconv.Itoa(int(port.Local))), port.Remote)
+	}
+	return conn, nil
+}
+
+// udpForwarder forwards the datagrams sent to a UDP socket to the remote
+// port, and the replies back to their local peers.
+type udpForwarder struct {
+	pf   *PortForwarder
+	conn net.PacketConn
+	port ForwardedPort
+
+	lock     sync.Mutex
+	sessions map[string]*udpSession
+}
+
+// waitForDatagrams forwards the datagrams sent to conn until it is closed.
+func (pf *PortForwarder) waitForDatagrams(conn net.PacketConn, port ForwardedPort) {
+	f := &udpForwarder{pf: pf, conn: conn, port: port, sessions: map[string]*udpSession{}}
+	defer f.closeSessions()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !strings.Contains(strings.ToLower(err.Error()), networkClosedError) {
+				runtime.HandleError(fmt.Errorf("error reading datagram on port %d: %v", port.Local, err))
+			}
+			return
+		}
+		session, err := f.session(peer)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		if err := session.send(buf[:n]); err != nil {
+			runtime.HandleError(fmt.Errorf("error forwarding datagram from %s to remote stream: %v", peer, err))
+			session.close()
+		}
+	}
+}
+
+// session returns the session of peer, and creates one for a new peer.
+func (f *udpForwarder) session(peer net.Addr) (*udpSession, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if session, ok := f.sessions[peer.String()]; ok {
+		return session, nil
+	}
+	session, err := f.newSession(peer)
+	if err != nil {
+		return nil, err
+	}
+	f.sessions[peer.String()] = session
+	return session, nil
+}
+
+func (f *udpForwarder) removeSession(session *udpSession) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.sessions[session.peer.String()] == session {
+		delete(f.sessions, session.peer.String())
+	}
+}
+
+func (f *udpForwarder) closeSessions() {
+	f.lock.Lock()
+	sessions := make([]*udpSession, 0, len(f.sessions))
+	for _, session := range f.sessions {
+		sessions = append(sessions, session)
+	}
+	f.lock.Unlock()
+	for _, session := range sessions {
+		session.close()
+	}
+}
+
+// udpSession forwards the datagrams of a local peer through a pair of
+// streams.
+type udpSession struct {
+	forwarder   *udpForwarder
+	streamConn  httpstream.Connection
+	peer        net.Addr
+	errorStream httpstream.Stream
+	dataStream  httpstream.Stream
+
+	// lastActive is the time of the last datagram in UnixNano.
+	lastActive atomic.Int64
+	idle       *time.Timer
+	closeOnce  sync.Once
+	closed     chan struct{}
+}
+
+func (f *udpForwarder) newSession(peer net.Addr) (*udpSession, error) {
+	pf, port := f.pf, f.port
+	streamConn := pf.connection()
+	if streamConn == nil {
+		return nil, fmt.Errorf("port forwarding of port %d -> %d/udp was stopped", port.Local, port.Remote)
+	}
+	if pf.out != nil {
+		fmt.Fprintf(pf.out, "Handling connection for %d/udp from %s\n", port.Local, peer)
+	}
+
+	headers := http.Header{}
+	headers.Set(v1.StreamType, v1.StreamTypeError)
+	headers.Set(v1.PortHeader, fmt.Sprintf("%d", port.Remote))
+	headers.Set(v1.PortForwardRequestIDHeader, strconv.Itoa(pf.nextRequestID()))
+	headers.Set(ProtocolHeader, string(v1.ProtocolUDP))
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return nil, fmt.Errorf("error creating error stream for port %d -> %d/udp: %v", port.Local, port.Remote, err)
+	}
+	// we're not writing to this stream
+	errorStream.Close()
+
+	headers.Set(v1.StreamType, v1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		streamConn.RemoveStreams(errorStream)
+		return nil, fmt.Errorf("error creating forwarding stream for port %d -> %d/udp: %v", port.Local, port.Remote, err)
+	}
+
+	s := &udpSession{
+		forwarder:   f,
+		streamConn:  streamConn,
+		peer:        peer,
+		errorStream: errorStream,
+		dataStream:  dataStream,
+		closed:      make(chan struct{}),
+	}
+	s.lastActive.Store(time.Now().UnixNano())
+	s.idle = time.AfterFunc(udpSessionTimeout, s.expire)
+	go s.readErrors()
+	go s.receive()
+	return s, nil
+}
+
+// send forwards a datagram of the local peer to the remote port.
+func (s *udpSession) send(datagram []byte) error {
+	s.lastActive.Store(time.Now().UnixNano())
+	return writeDatagram(s.dataStream, datagram)
+}
+
+// receive forwards the datagrams of the remote port to the local peer
+// until the data stream is closed.
+func (s *udpSession) receive() {
+	defer s.close()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := readDatagram(s.dataStream, buf)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !s.isClosed() {
+				runtime.HandleError(fmt.Errorf("error reading datagram from remote stream: %v", err))
+			}
+			return
+		}
+		s.lastActive.Store(time.Now().UnixNano())
+		if _, err := s.forwarder.conn.WriteTo(buf[:n], s.peer); err != nil {
+			if !strings.Contains(strings.ToLower(err.Error()), networkClosedError) {
+				runtime.HandleError(fmt.Errorf("error forwarding datagram to %s: %v", s.peer, err))
+			}
+			return
+		}
+	}
+}
+
+// readErrors reports the error of the remote port, if any. Unlike for a TCP
+// port, only the session of the peer is closed.
+func (s *udpSession) readErrors() {
+	port := s.forwarder.port
+	message, err := io.ReadAll(s.errorStream)
+	switch {
+	case s.isClosed():
+	case err != nil:
+		runtime.HandleError(fmt.Errorf("error reading from error stream for port %d -> %d/udp: %v", port.Local, port.Remote, err))
+	case len(message) > 0:
+		runtime.HandleError(fmt.Errorf("an error occurred forwarding %d -> %d/udp: %v", port.Local, port.Remote, string(message)))
+	default:
+		return
+	}
+	s.close()
+}
+
+// expire closes the session once it was idle for udpSessionTimeout.
+func (s *udpSession) expire() {
+	idle := time.Since(time.Unix(0, s.lastActive.Load()))
+	if idle < udpSessionTimeout {
+		s.idle.Reset(udpSessionTimeout - idle)
+		return
+	}
+	s.close()
+}
+
+func (s *udpSession) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// close discards the streams of the session, so that the next datagram of
+// the peer starts a new one.
+func (s *udpSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.idle.Stop()
+		s.forwarder.removeSession(s)
+		_ = s.dataStream.Reset()
+		_ = s.errorStream.Reset()
+		s.streamConn.RemoveStreams(s.errorStream, s.dataStream)
+	})
+}
+
+// writeDatagram writes a datagram prefixed with its length. The frame is
+// written at once, so that it is never split by another writer.
+func writeDatagram(w io.Writer, datagram []byte) error {
+	if len(datagram) > maxDatagramSize {
+		return fmt.Errorf("datagram of %d bytes is too large", len(datagram))
+	}
+	frame := make([]byte, 2+len(datagram))
+	binary.BigEndian.PutUint16(frame, uint16(len(datagram)))
+	copy(frame[2:], datagram)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readDatagram reads a datagram written by writeDatagram into buf, which
+// must have room for maxDatagramSize bytes, and returns its size.
+func readDatagram(r io.Reader, buf []byte) (int, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	n := int(binary.BigEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	gwebsocket "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	constants "k8s.io/apimachinery/pkg/util/portforward"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	clientspdy "k8s.io/client-go/transport/spdy"
+)
+
+// udpEchoStreams stands in for the port forwarding of the server: it
+// replies to every datagram of a UDP data stream.
+func udpEchoStreams(t *testing.T) httpstream.NewStreamHandler {
+	return func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		headers := stream.Headers()
+		if headers.Get(v1.StreamType) != v1.StreamTypeData {
+			return nil
+		}
+		if headers.Get(ProtocolHeader) != string(v1.ProtocolUDP) {
+			t.Errorf("expected a UDP stream, got headers %v", headers)
+			return nil
+		}
+		go func() {
+			<-replySent
+			buf := make([]byte, maxDatagramSize)
+			for {
+				n, err := readDatagram(stream, buf)
+				if err != nil {
+					return
+				}
+				reply := "reply to " + string(buf[:n]) + " on port " + headers.Get(v1.PortHeader)
+				if err := writeDatagram(stream, []byte(reply)); err != nil {
+					return
+				}
+			}
+		}()
+		return nil
+	}
+}
+
+// newSPDYUDPServer returns a dialer for a server which supports the given
+// port forwarding protocols.
+func newSPDYUDPServer(t *testing.T, protocols ...string) httpstream.Dialer {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, err := httpstream.Handshake(req, w, protocols); err != nil {
+			return
+		}
+		conn := spdy.NewResponseUpgrader().UpgradeResponse(w, req, udpEchoStreams(t))
+		if conn == nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		<-conn.CloseChan()
+	}))
+	t.Cleanup(server.Close)
+	location, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	transport, upgrader, err := clientspdy.RoundTripperFor(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return clientspdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, location)
+}
+
+// newWebSocketUDPServer returns a dialer for a server which supports the
+// given port forwarding protocols tunneled over WebSockets.
+func newWebSocketUDPServer(t *testing.T, protocols ...string) httpstream.Dialer {
+	var subprotocols []string
+	for _, protocol := range protocols {
+		subprotocols = append(subprotocols, constants.WebsocketsSPDYTunnelingPrefix+protocol)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upgrader := gwebsocket.Upgrader{
+			CheckOrigin:  func(r *http.Request) bool { return true },
+			Subprotocols: subprotocols,
+		}
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		spdyConn, err := spdy.NewServerConnection(NewTunnelingConnection("server", conn), udpEchoStreams(t))
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		defer spdyConn.Close() //nolint:errcheck
+		<-spdyConn.CloseChan()
+	}))
+	t.Cleanup(server.Close)
+	location, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	dialer, err := NewSPDYOverWebsocketDialer(location, &rest.Config{Host: location.Host})
+	require.NoError(t, err)
+	return dialer
+}
+
+func TestForwardPortsUDP(t *testing.T) {
+	testCases := map[string]func(t *testing.T, protocols ...string) httpstream.Dialer{
+		"spdy":                newSPDYUDPServer,
+		"spdy over websocket": newWebSocketUDPServer,
+	}
+	for name, newDialer := range testCases {
+		t.Run(name, func(t *testing.T) {
+			stopChan := make(chan struct{})
+			readyChan := make(chan struct{})
+			pf, err := NewOnAddresses(newDialer(t, PortForwardProtocolV2Name, PortForwardProtocolV1Name), []string{"127.0.0.1"}, []string{"0:53/udp"}, stopChan, readyChan, io.Discard, io.Discard)
+			require.NoError(t, err)
+			errChan := make(chan error)
+			go func() {
+				errChan <- pf.ForwardPorts()
+			}()
+			select {
+			case <-readyChan:
+			case err := <-errChan:
+				t.Fatalf("unexpected error %v", err)
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timeout waiting for the port forwarding to be ready")
+			}
+			ports, err := pf.GetPorts()
+			require.NoError(t, err)
+			address := net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local)))
+
+			// Every peer gets its own session, and the boundaries of the
+			// datagrams are kept.
+			for _, peer := range []string{"first", "second"} {
+				conn, err := net.Dial("udp", address)
+				require.NoError(t, err)
+				require.NoError(t, conn.SetDeadline(time.Now().Add(wait.ForeverTestTimeout)))
+				for _, query := range []string{peer + " query", peer + " retry"} {
+					_, err := conn.Write([]byte(query))
+					require.NoError(t, err)
+				}
+				buf := make([]byte, 1024)
+				for _, query := range []string{peer + " query", peer + " retry"} {
+					n, err := conn.Read(buf)
+					require.NoError(t, err)
+					assert.Equal(t, "reply to "+query+" on port 53", string(buf[:n]))
+				}
+				require.NoError(t, conn.Close())
+			}
+
+			close(stopChan)
+			require.NoError(t, <-errChan)
+		})
+	}
+}
+
+func TestForwardPortsUDPRequiresV2(t *testing.T) {
+	testCases := map[string]func(t *testing.T, protocols ...string) httpstream.Dialer{
+		"spdy":                newSPDYUDPServer,
+		"spdy over websocket": newWebSocketUDPServer,
+	}
+	for name, newDialer := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// The server would forward the datagrams to the TCP port.
+			stopChan := make(chan struct{})
+			defer close(stopChan)
+			pf, err := NewOnAddresses(newDialer(t, PortForwardProtocolV1Name), []string{"127.0.0.1"}, []string{"0:53/udp"}, stopChan, nil, io.Discard, io.Discard)
+			require.NoError(t, err)
+			err = pf.ForwardPorts()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unable to forward UDP ports")
+		})
+	}
+}
+
+func TestParsePortProtocols(t *testing.T) {
+	ports := []string{"5000", "5353:53/udp", "53/UDP", "8080:80/tcp"}
+	parsed, err := parsePorts(ports)
+	require.NoError(t, err)
+	assert.Equal(t, []ForwardedPort{{5000, 5000}, {5353, 53}, {53, 53}, {8080, 80}}, parsed)
+	assert.Equal(t, []v1.Protocol{v1.ProtocolTCP, v1.ProtocolUDP, v1.ProtocolUDP, v1.ProtocolTCP}, parsePortProtocols(ports))
+
+	_, err = parsePorts([]string{"5000/sctp"})
+	assert.Error(t, err)
+}
+
+func TestDatagramFraming(t *testing.T) {
+	var stream bytes.Buffer
+	require.NoError(t, writeDatagram(&stream, []byte("first")))
+	require.NoError(t, writeDatagram(&stream, nil))
+	require.NoError(t, writeDatagram(&stream, []byte("second")))
+	require.Error(t, writeDatagram(&stream, make([]byte, maxDatagramSize+1)))
+
+	buf := make([]byte, maxDatagramSize)
+	for _, expected := range []string{"first", "", "second"} {
+		n, err := readDatagram(&stream, buf)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(buf[:n]))
+	}
+	_, err := readDatagram(&stream, buf)
+	assert.Equal(t, io.EOF, err)
+
+	// A truncated datagram is an error.
+	stream.Write([]byte{0, 5, 'a'})
+	_, err = readDatagram(&stream, buf)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
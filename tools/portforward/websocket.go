@@ -0,0 +1,526 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gwebsocket "github.com/gorilla/websocket"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/remotecommand"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/transport/websocket"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// webSocketDataChannel and webSocketErrorChannel are the channels of
+	// the forwarded port of a WebSocket.
+	webSocketDataChannel  = 0
+	webSocketErrorChannel = 1
+
+	// webSocketPingDeadline is the time to wait for the "pong" of a
+	// heartbeat; several pings may be lost before it expires.
+	webSocketPingDeadline = PingPeriod*12 + time.Second
+	// webSocketWriteDeadline is the time a message may take to be written.
+	webSocketWriteDeadline = webSocketPingDeadline
+)
+
+// webSocketProtocols are the WebSocket protocols for port forwarding, in
+// order of preference. The WebSocket of both forwards a single port, with
+// a data and an error channel whose data starts with the port in little
+// endian. V5 adds the CLOSE signal to half-close the data channel.
+var webSocketProtocols = []string{remotecommand.StreamProtocolV5Name, remotecommand.StreamProtocolV4Name}
+
+var _ httpstream.Dialer = &webSocketDialer{}
+
+// webSocketDialer implements the "httpstream.Dialer" interface with the
+// native WebSocket protocol for port forwarding, without SPDY.
+type webSocketDialer struct {
+	url       *url.URL
+	transport http.RoundTripper
+	// ports are the forwarded ports, Dial probes the server with a
+	// WebSocket for the first one.
+	ports  []uint16
+	holder websocket.ConnectionHolder
+	// negotiateLock serializes the negotiations, the holder only keeps the
+	// WebSocket of the last one.
+	negotiateLock sync.Mutex
+
+	// heartbeatPeriod is how often a "ping" heartbeat message is sent.
+	heartbeatPeriod time.Duration
+	// heartbeatDeadline is the time before a "pong" must be received.
+	heartbeatDeadline time.Duration
+}
+
+// NewWebSocketDialer creates a dialer for port forwarding to url with the
+// native WebSocket protocol. Each forwarded connection uses a WebSocket of
+// its own, which forwards a single TCP port; UDP ports can't be forwarded.
+//
+// Dial negotiates a WebSocket for the first of the forwarded ports, or for
+// the first port of the "ports" query parameter of url if no ports are
+// given, so that it fails with an upgrade failure like the other dialers
+// when the server doesn't support the protocol, for use with
+// FallbackDialer. Without any port, Dial fails with an upgrade failure as
+// well. That WebSocket is closed right away: the server connects it to the
+// port in the pod, which must not be kept waiting for a local connection.
+func NewWebSocketDialer(url *url.URL, config *restclient.Config, ports ...uint16) (httpstream.Dialer, error) {
+	transport, holder, err := websocket.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &webSocketDialer{
+		url:               url,
+		transport:         transport,
+		ports:             ports,
+		holder:            holder,
+		heartbeatPeriod:   PingPeriod,
+		heartbeatDeadline: webSocketPingDeadline,
+	}, nil
+}
+
+// Dial returns a connection which creates the streams of the forwarded
+// connections over WebSockets. Only PortForwardProtocolV1Name is supported
+// and returned as the negotiated protocol, the WebSocket protocol has the
+// same semantics.
+func (d *webSocketDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	if !slices.Contains(protocols, PortForwardProtocolV1Name) {
+		return nil, "", fmt.Errorf("unable to negotiate protocol: client supports %q over WebSocket, requested %q", PortForwardProtocolV1Name, protocols)
+	}
+	port, err := d.firstPort()
+	if err != nil {
+		return nil, "", err
+	}
+	probe, err := d.negotiate(port)
+	if err != nil {
+		return nil, "", err
+	}
+	_ = probe.Close()
+	return newWebSocketConnection(d), PortForwardProtocolV1Name, nil
+}
+
+// firstPort returns the port which Dial probes the server with. An
+// unknown port is an upgrade failure, because the dialer can't tell
+// whether the server supports the protocol.
+func (d *webSocketDialer) firstPort() (uint16, error) {
+	if len(d.ports) > 0 {
+		return d.ports[0], nil
+	}
+	ports := strings.Split(d.url.Query().Get("ports"), ",")
+	if ports[0] == "" {
+		return 0, &httpstream.UpgradeFailureError{Cause: errors.New("no port to negotiate the WebSocket for")}
+	}
+	port, err := strconv.ParseUint(ports[0], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q: %w", ports[0], err)
+	}
+	return uint16(port), nil
+}
+
+// negotiate upgrades a request to forward port to a WebSocket.
+func (d *webSocketDialer) negotiate(port uint16) (*gwebsocket.Conn, error) {
+	u := *d.url
+	query := u.Query()
+	query.Set("ports", strconv.Itoa(int(port)))
+	u.RawQuery = query.Encode()
+	// Websockets requires "GET" method: RFC 6455 Sec. 4.1 (page 17).
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d.negotiateLock.Lock()
+	conn, err := websocket.Negotiate(d.transport, d.holder, req, webSocketProtocols...)
+	d.negotiateLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, fmt.Errorf("negotiated websocket connection is nil")
+	}
+	klog.V(4).Infof("negotiated protocol %s for port %d", conn.Subprotocol(), port)
+	return conn, nil
+}
+
+var _ httpstream.Connection = &webSocketConnection{}
+
+// webSocketConnection creates the streams of each forwarded connection,
+// identified by its request ID, over a WebSocket of its own.
+type webSocketConnection struct {
+	dialer *webSocketDialer
+
+	lock      sync.Mutex
+	sessions  map[string]*webSocketSession
+	closed    bool
+	closeChan chan bool
+}
+
+func newWebSocketConnection(dialer *webSocketDialer) *webSocketConnection {
+	return &webSocketConnection{
+		dialer:    dialer,
+		sessions:  map[string]*webSocketSession{},
+		closeChan: make(chan bool),
+	}
+}
+
+// CreateStream returns the data or error stream of the forwarded
+// connection with the request ID of headers.
+func (c *webSocketConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	if protocol := headers.Get(ProtocolHeader); protocol != "" && protocol != string(v1.ProtocolTCP) {
+		return nil, fmt.Errorf("unable to forward %s ports over WebSocket", protocol)
+	}
+	port, err := strconv.ParseUint(headers.Get(v1.PortHeader), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %w", headers.Get(v1.PortHeader), err)
+	}
+	var channel byte
+	switch streamType := headers.Get(v1.StreamType); streamType {
+	case v1.StreamTypeData:
+		channel = webSocketDataChannel
+	case v1.StreamTypeError:
+		channel = webSocketErrorChannel
+	default:
+		return nil, fmt.Errorf("unknown stream type: %s", streamType)
+	}
+
+	session, err := c.session(headers.Get(v1.PortForwardRequestIDHeader), uint16(port))
+	if err != nil {
+		return nil, err
+	}
+	return session.create(channel, headers)
+}
+
+// session returns the WebSocket of a forwarded connection, which is
+// negotiated unless it exists.
+func (c *webSocketConnection) session(requestID string, port uint16) (*webSocketSession, error) {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, errors.New("connection closed")
+	}
+	session, ok := c.sessions[requestID]
+	if ok {
+		c.lock.Unlock()
+		if session.port != port {
+			return nil, fmt.Errorf("request %s forwards port %d, not %d", requestID, session.port, port)
+		}
+		return session, nil
+	}
+	c.lock.Unlock()
+
+	conn, err := c.dialer.negotiate(port)
+	if err != nil {
+		return nil, err
+	}
+	session = newWebSocketSession(conn, port, c.dialer.heartbeatPeriod, c.dialer.heartbeatDeadline)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		session.close()
+		return nil, errors.New("connection closed")
+	}
+	c.sessions[requestID] = session
+	return session, nil
+}
+
+// RemoveStreams closes the WebSocket of a forwarded connection once all its
+// streams are removed.
+func (c *webSocketConnection) RemoveStreams(streams ...httpstream.Stream) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, stream := range streams {
+		s, ok := stream.(*webSocketStream)
+		if !ok {
+			continue
+		}
+		if s.session.remove() {
+			s.session.close()
+			for requestID, session := range c.sessions {
+				if session == s.session {
+					delete(c.sessions, requestID)
+				}
+			}
+		}
+	}
+}
+
+// Close closes the WebSockets of all forwarded connections.
+func (c *webSocketConnection) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for _, session := range c.sessions {
+		session.close()
+	}
+	c.sessions = nil
+	close(c.closeChan)
+	return nil
+}
+
+// CloseChan returns a channel which is closed once the connection is
+// closed. The WebSockets of forwarded connections close on their own.
+func (c *webSocketConnection) CloseChan() <-chan bool {
+	return c.closeChan
+}
+
+// SetIdleTimeout does nothing, the WebSockets are kept alive by their
+// heartbeat while they are used.
+func (c *webSocketConnection) SetIdleTimeout(timeout time.Duration) {}
+
+// webSocketSession is the WebSocket of a forwarded connection.
+type webSocketSession struct {
+	conn *gwebsocket.Conn
+	port uint16
+	// streamClose tells whether the protocol supports the CLOSE signal.
+	streamClose bool
+
+	// writeLock protects writing to conn; reading is done by readLoop only.
+	writeLock sync.Mutex
+	channels  [2]*webSocketStream
+	// created and removed count the streams of the connection, under the
+	// lock of the connection.
+	created, removed int
+}
+
+func newWebSocketSession(conn *gwebsocket.Conn, port uint16, period, deadline time.Duration) *webSocketSession {
+	s := &webSocketSession{
+		conn:        conn,
+		port:        port,
+		streamClose: conn.Subprotocol() == remotecommand.StreamProtocolV5Name,
+	}
+	for channel := range s.channels {
+		reader, writer := io.Pipe()
+		s.channels[channel] = &webSocketStream{
+			session:   s,
+			channel:   byte(channel),
+			readPipe:  reader,
+			writePipe: writer,
+		}
+	}
+	go s.readLoop(period, deadline)
+	return s
+}
+
+// create returns the stream of a channel, which is created once.
+func (s *webSocketSession) create(channel byte, headers http.Header) (httpstream.Stream, error) {
+	stream := s.channels[channel]
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if stream.headers != nil {
+		return nil, fmt.Errorf("duplicate stream for type %s", headers.Get(v1.StreamType))
+	}
+	stream.headers = headers
+	s.created++
+	return stream, nil
+}
+
+// remove counts a removed stream, and tells whether all streams which were
+// created are removed.
+func (s *webSocketSession) remove() bool {
+	s.removed++
+	return s.removed >= s.created
+}
+
+func (s *webSocketSession) close() {
+	_ = s.conn.Close()
+}
+
+// readLoop demultiplexes the messages of the WebSocket into the pipes of
+// the channels, after the port which starts the data of each channel. It
+// runs the heartbeat, whose "pong" messages are only received while
+// reading.
+func (s *webSocketSession) readLoop(period, deadline time.Duration) {
+	stopHeartbeat := make(chan struct{})
+	defer close(stopHeartbeat)
+	if err := startWebSocketHeartbeat(s.conn, period, deadline, stopHeartbeat); err != nil {
+		s.closeReaders(err)
+		return
+	}
+
+	// the bytes of the port which are still expected on each channel
+	var portBytes [2][]byte
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			var closeErr *gwebsocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == gwebsocket.CloseNormalClosure {
+				// readers get io.EOF as it's a normal closure
+				err = nil
+			}
+			s.closeReaders(err)
+			return
+		}
+		if messageType != gwebsocket.BinaryMessage || len(data) == 0 {
+			continue
+		}
+		channel, data := data[0], data[1:]
+		if int(channel) >= len(s.channels) {
+			klog.V(6).Infof("Unknown channel %d, discarding message", channel)
+			continue
+		}
+		if missing := 2 - len(portBytes[channel]); missing > 0 {
+			n := min(missing, len(data))
+			portBytes[channel] = append(portBytes[channel], data[:n]...)
+			data = data[n:]
+			if len(portBytes[channel]) == 2 {
+				if port := binary.LittleEndian.Uint16(portBytes[channel]); port != s.port {
+					s.closeReaders(fmt.Errorf("expected port %d on channel %d, got %d", s.port, channel, port))
+					return
+				}
+			}
+		}
+		if len(data) > 0 {
+			// a stream which was reset discards its data
+			_, _ = s.channels[channel].writePipe.Write(data)
+		}
+	}
+}
+
+// closeReaders ends the reads of all channels with err, or io.EOF if err
+// is nil.
+func (s *webSocketSession) closeReaders(err error) {
+	for _, stream := range s.channels {
+		_ = stream.writePipe.CloseWithError(err)
+	}
+}
+
+// startWebSocketHeartbeat sends a "ping" message every period until stop is
+// closed, and fails the reads of conn unless a "pong" is received within
+// deadline, like the heartbeat of remotecommand.
+func startWebSocketHeartbeat(conn *gwebsocket.Conn, period, deadline time.Duration, stop <-chan struct{}) error {
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+	if err := conn.SetReadDeadline(time.Now().Add(deadline)); err != nil {
+		return err
+	}
+	go func() {
+		t := time.NewTicker(period)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			// "WriteControl" can be called concurrently with all other methods.
+			err := conn.WriteControl(gwebsocket.PingMessage, nil, time.Now().Add(deadline))
+			var netErr net.Error
+			if err != nil && !errors.Is(err, gwebsocket.ErrCloseSent) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+				klog.V(4).Infof("Websocket ping failed: %v", err)
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+var _ httpstream.Stream = &webSocketStream{}
+
+// webSocketStream is a channel of the WebSocket of a forwarded connection.
+type webSocketStream struct {
+	session   *webSocketSession
+	channel   byte
+	headers   http.Header
+	readPipe  *io.PipeReader
+	writePipe *io.PipeWriter
+	// closed is set once the stream was half-closed, under the write lock
+	// of the session.
+	closed bool
+}
+
+func (s *webSocketStream) Read(p []byte) (int, error) {
+	return s.readPipe.Read(p)
+}
+
+// Write sends p as a message of the channel.
+func (s *webSocketStream) Write(p []byte) (int, error) {
+	s.session.writeLock.Lock()
+	defer s.session.writeLock.Unlock()
+	if s.closed {
+		return 0, fmt.Errorf("write on closed stream %d", s.channel)
+	}
+	if err := s.session.conn.SetWriteDeadline(time.Now().Add(webSocketWriteDeadline)); err != nil {
+		return 0, err
+	}
+	w, err := s.session.conn.NextWriter(gwebsocket.BinaryMessage)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write([]byte{s.channel}); err != nil {
+		_ = w.Close()
+		return 0, err
+	}
+	n, err := w.Write(p)
+	if err != nil {
+		_ = w.Close()
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// Close half-closes the stream with the CLOSE signal, if the protocol
+// supports it. Otherwise the server only notices once the WebSocket is
+// closed.
+func (s *webSocketStream) Close() error {
+	s.session.writeLock.Lock()
+	defer s.session.writeLock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if !s.session.streamClose {
+		return nil
+	}
+	if err := s.session.conn.SetWriteDeadline(time.Now().Add(webSocketWriteDeadline)); err != nil {
+		return err
+	}
+	return s.session.conn.WriteMessage(gwebsocket.BinaryMessage, []byte{remotecommand.StreamClose, s.channel})
+}
+
+// Reset closes the stream in both directions, and discards the data which
+// is received later.
+func (s *webSocketStream) Reset() error {
+	err := s.Close()
+	_ = s.readPipe.Close()
+	return err
+}
+
+func (s *webSocketStream) Headers() http.Header {
+	return s.headers
+}
+
+func (s *webSocketStream) Identifier() uint32 {
+	return uint32(s.channel)
+}
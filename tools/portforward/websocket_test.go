@@ -0,0 +1,325 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gwebsocket "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/wsstream"
+	"k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	restclient "k8s.io/client-go/rest"
+)
+
+// webSocketPortForwardServer serves port forwarding over WebSocket like the
+// kubelet: the WebSocket forwards the port of its "ports" query parameter
+// over a data and an error channel, whose data starts with the port.
+type webSocketPortForwardServer struct {
+	*httptest.Server
+	// sessions counts the WebSockets which were opened, and closed those
+	// whose forwarding has ended.
+	sessions, closed atomic.Int32
+}
+
+func newWebSocketPortForwardServer(t *testing.T, protocols []string, forward func(data io.ReadWriteCloser, errorChannel io.Writer)) *webSocketPortForwardServer {
+	server := &webSocketPortForwardServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !wsstream.IsWebSocketRequest(req) {
+			http.Error(w, "websocket required", http.StatusBadRequest)
+			return
+		}
+		port, err := strconv.ParseUint(req.URL.Query().Get("ports"), 10, 16)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		channelProtocols := map[string]wsstream.ChannelProtocolConfig{}
+		for _, protocol := range protocols {
+			channelProtocols[protocol] = wsstream.ChannelProtocolConfig{
+				Binary:   true,
+				Channels: []wsstream.ChannelType{wsstream.ReadWriteChannel, wsstream.WriteChannel},
+			}
+		}
+		conn := wsstream.NewConn(channelProtocols)
+		_, channels, err := conn.Open(w, req)
+		if err != nil {
+			t.Errorf("unexpected error opening websocket: %v", err)
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		server.sessions.Add(1)
+		defer server.closed.Add(1)
+		for _, channel := range channels {
+			if _, err := channel.Write(binary.LittleEndian.AppendUint16(nil, uint16(port))); err != nil {
+				t.Errorf("unexpected error writing port: %v", err)
+				return
+			}
+		}
+		forward(channels[0], channels[1])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// upperCaseLine answers a line with the line in upper case.
+func upperCaseLine(data io.ReadWriteCloser, _ io.Writer) {
+	line, err := bufio.NewReader(data).ReadString('\n')
+	if err != nil {
+		return
+	}
+	_, _ = data.Write(bytes.ToUpper([]byte(line)))
+}
+
+func newTestWebSocketDialer(t *testing.T, serverURL, query string, ports ...uint16) *webSocketDialer {
+	u, err := url.Parse(serverURL + "/api/v1/namespaces/default/pods/test/portforward" + query)
+	require.NoError(t, err)
+	dialer, err := NewWebSocketDialer(u, &restclient.Config{Host: serverURL}, ports...)
+	require.NoError(t, err)
+	return dialer.(*webSocketDialer)
+}
+
+func streamHeaders(streamType string, port, requestID int) http.Header {
+	headers := http.Header{}
+	headers.Set(v1.StreamType, streamType)
+	headers.Set(v1.PortHeader, strconv.Itoa(port))
+	headers.Set(v1.PortForwardRequestIDHeader, strconv.Itoa(requestID))
+	return headers
+}
+
+func TestWebSocketPortForward(t *testing.T) {
+	server := newWebSocketPortForwardServer(t, []string{remotecommand.StreamProtocolV4Name}, upperCaseLine)
+	dialer := newTestWebSocketDialer(t, server.URL, "?ports=8080")
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	pf, err := New(dialer, []string{":8080"}, stopChan, readyChan, io.Discard, io.Discard)
+	require.NoError(t, err)
+	errChan := make(chan error)
+	go func() {
+		errChan <- pf.ForwardPorts()
+	}()
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		t.Fatalf("unexpected error forwarding ports: %v", err)
+	}
+	// Dial probed the server with a WebSocket
+	assert.Equal(t, int32(1), server.sessions.Load())
+
+	ports, err := pf.GetPorts()
+	require.NoError(t, err)
+	for i := range 2 {
+		conn, err := net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(int(ports[0].Local))))
+		require.NoError(t, err)
+		_, err = fmt.Fprintf(conn, "hello %d\n", i)
+		require.NoError(t, err)
+		response, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("HELLO %d\n", i), string(response))
+		require.NoError(t, conn.Close())
+	}
+	assert.Equal(t, int32(3), server.sessions.Load(), "each connection has a WebSocket of its own")
+
+	close(stopChan)
+	require.NoError(t, <-errChan)
+}
+
+func TestWebSocketStreamHalfClose(t *testing.T) {
+	server := newWebSocketPortForwardServer(t, []string{remotecommand.StreamProtocolV5Name, remotecommand.StreamProtocolV4Name}, func(data io.ReadWriteCloser, _ io.Writer) {
+		// the client half-closes its side of the data channel
+		request, err := io.ReadAll(data)
+		if err != nil {
+			return
+		}
+		_, _ = data.Write(bytes.ToUpper(request))
+	})
+	conn, protocol, err := newTestWebSocketDialer(t, server.URL, "", 80).Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+	assert.Equal(t, PortForwardProtocolV1Name, protocol)
+	assert.Equal(t, int32(1), server.sessions.Load(), "Dial must negotiate a WebSocket for the first port")
+	assert.Eventually(t, func() bool {
+		return server.closed.Load() == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond, "Dial must close its WebSocket")
+
+	errorStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeError, 80, 0))
+	require.NoError(t, err)
+	dataStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeData, 80, 0))
+	require.NoError(t, err)
+	_, err = conn.CreateStream(streamHeaders(v1.StreamTypeData, 80, 0))
+	assert.Error(t, err, "a stream can't be created twice")
+
+	_, err = dataStream.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, dataStream.Close())
+	response, err := io.ReadAll(dataStream)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", string(response))
+	message, err := io.ReadAll(errorStream)
+	require.NoError(t, err)
+	assert.Empty(t, message)
+
+	conn.RemoveStreams(dataStream, errorStream)
+	assert.Empty(t, conn.(*webSocketConnection).sessions)
+}
+
+func TestWebSocketErrorStream(t *testing.T) {
+	server := newWebSocketPortForwardServer(t, []string{remotecommand.StreamProtocolV4Name}, func(_ io.ReadWriteCloser, errorChannel io.Writer) {
+		_, _ = errorChannel.Write([]byte("connection refused"))
+	})
+	conn, _, err := newTestWebSocketDialer(t, server.URL, "?ports=80").Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	errorStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeError, 80, 0))
+	require.NoError(t, err)
+	dataStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeData, 80, 0))
+	require.NoError(t, err)
+	// v4 has no CLOSE signal, closing only stops writing
+	require.NoError(t, errorStream.Close())
+	message, err := io.ReadAll(errorStream)
+	require.NoError(t, err)
+	assert.Equal(t, "connection refused", string(message))
+	data, err := io.ReadAll(dataStream)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	assert.Equal(t, int32(2), server.sessions.Load(), "the WebSocket of Dial must not be reused")
+}
+
+func TestWebSocketUnsupportedStreams(t *testing.T) {
+	conn := newWebSocketConnection(newTestWebSocketDialer(t, "http://localhost", ""))
+	defer conn.Close() //nolint:errcheck
+
+	headers := streamHeaders(v1.StreamTypeData, 53, 0)
+	headers.Set(ProtocolHeader, string(v1.ProtocolUDP))
+	_, err := conn.CreateStream(headers)
+	assert.ErrorContains(t, err, "UDP")
+	_, err = conn.CreateStream(streamHeaders("resize", 80, 0))
+	assert.Error(t, err)
+
+	_, _, err = newTestWebSocketDialer(t, "http://localhost", "").Dial("other.protocol")
+	assert.Error(t, err)
+}
+
+func TestWebSocketDialerFallback(t *testing.T) {
+	// a server which doesn't support the WebSocket protocol
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	for name, webSocket := range map[string]*webSocketDialer{
+		"ports query": newTestWebSocketDialer(t, server.URL, "?ports=80"),
+		"ports":       newTestWebSocketDialer(t, server.URL, "", 80),
+		"no ports":    newTestWebSocketDialer(t, server.URL, ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tunneling := &fakeDialer{err: &httpstream.UpgradeFailureError{Cause: errors.New("no tunneling")}}
+			spdy := &fakeDialer{negotiatedProtocol: PortForwardProtocolV1Name}
+			dialer := NewFallbackDialerChain(httpstream.IsUpgradeFailure, webSocket, tunneling, spdy)
+			_, protocol, err := dialer.Dial(PortForwardProtocolV1Name)
+			require.NoError(t, err)
+			assert.Equal(t, PortForwardProtocolV1Name, protocol)
+			assert.True(t, tunneling.dialed, "the tunneling dialer should have dialed")
+			assert.True(t, spdy.dialed, "the SPDY dialer should have dialed")
+		})
+	}
+
+	// no fallback for other errors
+	tunneling := &fakeDialer{err: errors.New("connection refused")}
+	spdy := &fakeDialer{}
+	dialer := NewFallbackDialerChain(httpstream.IsUpgradeFailure, tunneling, spdy)
+	_, _, err := dialer.Dial(PortForwardProtocolV1Name)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.False(t, spdy.dialed)
+}
+
+func TestWebSocketHeartbeat(t *testing.T) {
+	// the server answers the pings while it reads the WebSocket
+	server := newWebSocketPortForwardServer(t, []string{remotecommand.StreamProtocolV4Name}, upperCaseLine)
+	dialer := newTestWebSocketDialer(t, server.URL, "?ports=80")
+	dialer.heartbeatPeriod = 10 * time.Millisecond
+	dialer.heartbeatDeadline = 100 * time.Millisecond
+	conn, _, err := dialer.Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	time.Sleep(300 * time.Millisecond)
+	dataStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeData, 80, 0))
+	require.NoError(t, err)
+	_, err = dataStream.Write([]byte("alive\n"))
+	require.NoError(t, err)
+	response, err := io.ReadAll(dataStream)
+	require.NoError(t, err)
+	assert.Equal(t, "ALIVE\n", string(response))
+}
+
+func TestWebSocketHeartbeatTimeout(t *testing.T) {
+	// the server never reads the WebSocket, so the pings aren't answered
+	stop := make(chan struct{})
+	defer close(stop)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upgrader := gwebsocket.Upgrader{Subprotocols: []string{remotecommand.StreamProtocolV4Name}}
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		<-stop
+	}))
+	defer server.Close()
+
+	dialer := newTestWebSocketDialer(t, server.URL, "?ports=80")
+	dialer.heartbeatPeriod = 10 * time.Millisecond
+	dialer.heartbeatDeadline = 100 * time.Millisecond
+	conn, _, err := dialer.Dial(PortForwardProtocolV1Name)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+	dataStream, err := conn.CreateStream(streamHeaders(v1.StreamTypeData, 80, 0))
+	require.NoError(t, err)
+
+	readErr := make(chan error)
+	go func() {
+		_, err := io.ReadAll(dataStream)
+		readErr <- err
+	}()
+	select {
+	case err := <-readErr:
+		assert.Error(t, err)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the read didn't time out")
+	}
+}
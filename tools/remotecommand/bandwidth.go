@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+var _ Executor = &LimitedExecutor{}
+
+// BandwidthOptions limits the bandwidth of the sessions of an executor. A
+// limit which is zero doesn't limit anything.
+type BandwidthOptions struct {
+	// StreamBytesPerSecond limits each of stdin, stdout and stderr.
+	StreamBytesPerSecond int64
+	// ConnectionBytesPerSecond limits the streams of a session together.
+	ConnectionBytesPerSecond int64
+}
+
+// LimitedExecutor limits the bandwidth of the sessions of another Executor
+// and counts the bytes they transfer, in total and in the StreamBytes
+// metric. The streams of the session are limited, rather than the
+// connection, so that it works with every executor regardless of its
+// protocol.
+type LimitedExecutor struct {
+	executor Executor
+	options  BandwidthOptions
+
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// NewLimitedExecutor creates an Executor which limits the bandwidth of the
+// sessions of executor.
+func NewLimitedExecutor(executor Executor, options BandwidthOptions) *LimitedExecutor {
+	return &LimitedExecutor{executor: executor, options: options}
+}
+
+// Stream limits a session of the wrapped executor.
+// Deprecated: use StreamWithContext instead to avoid possible resource leaks.
+func (e *LimitedExecutor) Stream(options StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+// StreamWithContext limits a session of the wrapped executor.
+func (e *LimitedExecutor) StreamWithContext(ctx context.Context, options StreamOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	connection := flowcontrol.NewBandwidthLimiter(e.options.ConnectionBytesPerSecond)
+	if options.Stdin != nil {
+		options.Stdin = &countingReader{
+			r:       flowcontrol.LimitReader(ctx, options.Stdin, flowcontrol.NewBandwidthLimiter(e.options.StreamBytesPerSecond), connection),
+			counter: &e.sent,
+		}
+	}
+	if options.Stdout != nil {
+		options.Stdout = &countingWriter{
+			w:       flowcontrol.LimitWriter(ctx, options.Stdout, flowcontrol.NewBandwidthLimiter(e.options.StreamBytesPerSecond), connection),
+			counter: &e.received,
+		}
+	}
+	if options.Stderr != nil {
+		options.Stderr = &countingWriter{
+			w:       flowcontrol.LimitWriter(ctx, options.Stderr, flowcontrol.NewBandwidthLimiter(e.options.StreamBytesPerSecond), connection),
+			counter: &e.received,
+		}
+	}
+	return e.executor.StreamWithContext(ctx, options)
+}
+
+// BytesSent returns the bytes of stdin which were sent by all sessions.
+func (e *LimitedExecutor) BytesSent() int64 {
+	return e.sent.Load()
+}
+
+// BytesReceived returns the bytes of stdout and stderr which were received
+// by all sessions.
+func (e *LimitedExecutor) BytesReceived() int64 {
+	return e.received.Load()
+}
+
+// countingReader counts the bytes of stdin which are sent.
+type countingReader struct {
+	r       io.Reader
+	counter *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.counter.Add(int64(n))
+		metrics.StreamBytes.Add("exec", "sent", n)
+	}
+	return n, err
+}
+
+// countingWriter counts the bytes of stdout and stderr which are received.
+type countingWriter struct {
+	w       io.Writer
+	counter *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if n > 0 {
+		c.counter.Add(int64(n))
+		metrics.StreamBytes.Add("exec", "received", n)
+	}
+	return n, err
+}
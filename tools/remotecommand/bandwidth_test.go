@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoExecutor() *scriptedExecutor {
+	return &scriptedExecutor{script: func(options StreamOptions) error {
+		if _, err := io.Copy(options.Stdout, options.Stdin); err != nil {
+			return err
+		}
+		_, err := options.Stderr.Write([]byte("done"))
+		return err
+	}}
+}
+
+func TestLimitedExecutor(t *testing.T) {
+	executor := NewLimitedExecutor(echoExecutor(), BandwidthOptions{ConnectionBytesPerSecond: 128 * 1024})
+
+	input := bytes.Repeat([]byte("x"), 48*1024)
+	var stdout, stderr bytes.Buffer
+	start := time.Now()
+	err := executor.StreamWithContext(context.Background(), StreamOptions{
+		Stdin:  bytes.NewReader(input),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	// a burst of 32KiB, then 64KiB which are sent and received together at
+	// 128KiB per second
+	assert.GreaterOrEqual(t, time.Since(start), 490*time.Millisecond)
+	assert.Equal(t, input, stdout.Bytes())
+	assert.Equal(t, "done", stderr.String())
+	assert.Equal(t, int64(len(input)), executor.BytesSent())
+	assert.Equal(t, int64(len(input)+4), executor.BytesReceived())
+
+	// the counters add up over sessions
+	err = executor.StreamWithContext(context.Background(), StreamOptions{
+		Stdin:  bytes.NewReader([]byte("again")),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(input)+5), executor.BytesSent())
+}
+
+func TestLimitedExecutorStreamLimit(t *testing.T) {
+	executor := NewLimitedExecutor(echoExecutor(), BandwidthOptions{StreamBytesPerSecond: 64 * 1024})
+
+	// stdin and stdout are limited separately
+	input := bytes.Repeat([]byte("x"), 64*1024)
+	start := time.Now()
+	err := executor.StreamWithContext(context.Background(), StreamOptions{
+		Stdin:  bytes.NewReader(input),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	require.NoError(t, err)
+	duration := time.Since(start)
+	assert.GreaterOrEqual(t, duration, 490*time.Millisecond)
+	assert.Less(t, duration, 900*time.Millisecond)
+}
+
+func TestLimitedExecutorCanceled(t *testing.T) {
+	executor := NewLimitedExecutor(echoExecutor(), BandwidthOptions{StreamBytesPerSecond: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := executor.StreamWithContext(ctx, StreamOptions{
+		Stdin:  bytes.NewReader([]byte("slow")),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	// the limiter fails as soon as it can't pass the bytes before the deadline
+	assert.Error(t, err)
+}
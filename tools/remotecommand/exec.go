@@ -0,0 +1,198 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/exec"
+)
+
+// ErrOutputLimitExceeded is returned by Exec when the command writes more
+// output than ExecOptions.MaxOutputBytes.
+var ErrOutputLimitExceeded = errors.New("command output exceeds the limit")
+
+// ExecOptions configures a command run by Exec.
+type ExecOptions struct {
+	// Stdin, if set, is passed to the command.
+	Stdin io.Reader
+	// Stdout and Stderr, if set, receive the output of the command as it
+	// arrives, instead of ExecResult.Stdout and ExecResult.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+	// Tty allocates a terminal for the command, which writes both its
+	// output and errors to stdout then.
+	Tty bool
+	// TerminalSizeQueue, if set, resizes the terminal of the command.
+	TerminalSizeQueue TerminalSizeQueue
+	// Timeout, if set, limits the time the command may run.
+	Timeout time.Duration
+	// MaxOutputBytes, if set, is the size of the output captured for each
+	// of ExecResult.Stdout and ExecResult.Stderr. The command is aborted
+	// once it writes more.
+	MaxOutputBytes int64
+}
+
+// ExecResult is the result of a command run by Exec.
+type ExecResult struct {
+	// Stdout and Stderr are the captured output of the command.
+	Stdout []byte
+	Stderr []byte
+	// ExitCode is the exit code of the command.
+	ExitCode int
+}
+
+// Exec runs a command in a container of a pod through its exec
+// subresource, over WebSocket or, if the server doesn't support it, SPDY.
+// If the command exits with a non-zero exit code, the returned error is an
+// exec.ExitError. The result holds the output which was captured even if
+// an error is returned.
+func Exec(ctx context.Context, config *restclient.Config, pod types.NamespacedName, container string, command []string, options ExecOptions) (*ExecResult, error) {
+	if len(command) == 0 {
+		return nil, errors.New("you must specify a command")
+	}
+	executor, err := NewExecutorForPod(config, pod, container, command, options.Stdin != nil, options.Tty)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stdout := &limitedBuffer{limit: options.MaxOutputBytes, cancel: cancel}
+	stderr := &limitedBuffer{limit: options.MaxOutputBytes, cancel: cancel}
+	streamOptions := StreamOptions{
+		Stdin:             options.Stdin,
+		Stdout:            options.Stdout,
+		Stderr:            options.Stderr,
+		Tty:               options.Tty,
+		TerminalSizeQueue: options.TerminalSizeQueue,
+	}
+	if streamOptions.Stdout == nil {
+		streamOptions.Stdout = stdout
+	}
+	if streamOptions.Stderr == nil && !options.Tty {
+		streamOptions.Stderr = stderr
+	}
+
+	err = executor.StreamWithContext(ctx, streamOptions)
+	result := &ExecResult{Stdout: stdout.bytes(), Stderr: stderr.bytes()}
+	if errors.Is(context.Cause(ctx), ErrOutputLimitExceeded) {
+		return result, ErrOutputLimitExceeded
+	}
+	var exitErr exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitStatus()
+	}
+	return result, err
+}
+
+// NewExecutorForPod creates an executor for a command in a container of a
+// pod, which prefers WebSocket, and falls back to SPDY if the upgrade to
+// WebSocket fails. stdin tells whether the command reads stdin, and tty
+// whether it gets a terminal.
+func NewExecutorForPod(config *restclient.Config, pod types.NamespacedName, container string, command []string, stdin, tty bool) (Executor, error) {
+	execURL, err := podExecURL(config, pod, container, command, stdin, tty)
+	if err != nil {
+		return nil, err
+	}
+	spdyExecutor, err := NewSPDYExecutor(config, "POST", execURL)
+	if err != nil {
+		return nil, fmt.Errorf("error creating SPDY executor: %w", err)
+	}
+	websocketExecutor, err := NewWebSocketExecutor(config, "GET", execURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("error creating WebSocket executor: %w", err)
+	}
+	return NewFallbackExecutor(websocketExecutor, spdyExecutor, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+}
+
+// podExecURL returns the URL of the exec subresource of a pod for a
+// command.
+func podExecURL(config *restclient.Config, pod types.NamespacedName, container string, command []string, stdin, tty bool) (*url.URL, error) {
+	coreConfig := *config
+	coreConfig.APIPath = "/api"
+	coreConfig.GroupVersion = &v1.SchemeGroupVersion
+	baseURL, versionedAPIPath, err := restclient.DefaultServerUrlFor(&coreConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	execURL := *baseURL
+	execURL.Path = path.Join(baseURL.Path, versionedAPIPath, "namespaces", pod.Namespace, "pods", pod.Name, "exec")
+	query := url.Values{"command": command}
+	if container != "" {
+		query.Set("container", container)
+	}
+	if stdin {
+		query.Set("stdin", "true")
+	}
+	query.Set("stdout", "true")
+	if tty {
+		query.Set("tty", "true")
+	} else {
+		query.Set("stderr", "true")
+	}
+	execURL.RawQuery = query.Encode()
+	return &execURL, nil
+}
+
+// limitedBuffer captures output up to a limit, and cancels the command
+// once it is exceeded. The executor may still write to it when it returns
+// early.
+type limitedBuffer struct {
+	lock   sync.Mutex
+	buf    bytes.Buffer
+	limit  int64
+	cancel context.CancelCauseFunc
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.limit > 0 && int64(b.buf.Len()+len(p)) > b.limit {
+		b.buf.Write(p[:b.limit-int64(b.buf.Len())])
+		b.cancel(ErrOutputLimitExceeded)
+		return 0, ErrOutputLimitExceeded
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) bytes() []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
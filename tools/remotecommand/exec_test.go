@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/exec"
+)
+
+// newExecServer stands in for the exec subresource: it runs the command
+// of the request as "<stdout> <stderr> <exit code>", and echoes stdin to
+// stdout.
+func newExecServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/namespaces/ns/pods/pod/exec" || req.URL.Query().Get("container") != "c" {
+			t.Errorf("unexpected request %s", req.URL)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		streams, err := webSocketServerStreams(req, w, streamOptionsFromRequest(req))
+		if err != nil {
+			t.Errorf("error creating streams: %v", err)
+			return
+		}
+		defer streams.conn.Close()
+
+		command := req.URL.Query()["command"]
+		if len(command) != 3 {
+			t.Errorf("unexpected command %v", command)
+			return
+		}
+		if streams.stdinStream != nil {
+			_, _ = io.Copy(streams.stdoutStream, streams.stdinStream)
+		}
+		_, _ = streams.stdoutStream.Write([]byte(command[0]))
+		if streams.stderrStream != nil {
+			_, _ = streams.stderrStream.Write([]byte(command[1]))
+		}
+		if command[2] == "0" {
+			_ = streams.writeStatus(&apierrors.StatusError{ErrStatus: metav1.Status{Status: metav1.StatusSuccess}})
+			return
+		}
+		_ = streams.writeStatus(&apierrors.StatusError{ErrStatus: metav1.Status{
+			Status: metav1.StatusFailure,
+			Reason: remotecommand.NonZeroExitCodeReason,
+			Details: &metav1.StatusDetails{
+				Causes: []metav1.StatusCause{{Type: remotecommand.ExitCodeCauseType, Message: command[2]}},
+			},
+		}})
+	}))
+}
+
+var testPod = types.NamespacedName{Namespace: "ns", Name: "pod"}
+
+func TestExec(t *testing.T) {
+	server := newExecServer(t)
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+
+	result, err := Exec(context.Background(), config, testPod, "c", []string{"out", "err", "0"}, ExecOptions{
+		Stdin: strings.NewReader("in "),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "in out", string(result.Stdout))
+	assert.Equal(t, "err", string(result.Stderr))
+	assert.Equal(t, 0, result.ExitCode)
+
+	result, err = Exec(context.Background(), config, testPod, "c", []string{"out", "err", "3"}, ExecOptions{})
+	require.Error(t, err)
+	var exitErr exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "expected an exit error, got %v", err)
+	assert.Equal(t, 3, exitErr.ExitStatus())
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "out", string(result.Stdout))
+}
+
+func TestExecStreamsOutput(t *testing.T) {
+	server := newExecServer(t)
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	result, err := Exec(context.Background(), &rest.Config{Host: server.URL}, testPod, "c", []string{"out", "err", "0"}, ExecOptions{
+		Stdout: &stdout,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "out", stdout.String())
+	assert.Empty(t, result.Stdout)
+	assert.Equal(t, "err", string(result.Stderr))
+}
+
+func TestExecOutputLimit(t *testing.T) {
+	server := newExecServer(t)
+	defer server.Close()
+
+	output := strings.Repeat("x", 100)
+	result, err := Exec(context.Background(), &rest.Config{Host: server.URL}, testPod, "c", []string{output, "err", "0"}, ExecOptions{
+		MaxOutputBytes: 10,
+		Timeout:        time.Minute,
+	})
+	require.ErrorIs(t, err, ErrOutputLimitExceeded)
+	assert.Equal(t, output[:10], string(result.Stdout))
+}
+
+func TestExecRequiresCommand(t *testing.T) {
+	_, err := Exec(context.Background(), &rest.Config{}, testPod, "c", nil, ExecOptions{})
+	assert.Error(t, err)
+}
+
+func TestPodExecURL(t *testing.T) {
+	config := &rest.Config{Host: "https://example.com/prefix"}
+	u, err := podExecURL(config, testPod, "", []string{"ls", "-l"}, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/prefix/api/v1/namespaces/ns/pods/pod/exec?command=ls&command=-l&stdout=true&tty=true", u.String())
+	assert.Nil(t, config.GroupVersion, "the config must not be modified")
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/utils/clock"
+)
+
+var _ Executor = &RecordingExecutor{}
+
+// Event codes of asciicast v2 recordings.
+const (
+	asciicastOutput = "o"
+	asciicastInput  = "i"
+	asciicastResize = "r"
+)
+
+// RecordingOptions configures the recording of a session.
+type RecordingOptions struct {
+	// Width and Height are the initial size of the terminal, 80x24 if
+	// unset. Later sizes are recorded as resize events.
+	Width  uint16
+	Height uint16
+	// Title is the title of the recording, if set.
+	Title string
+	// Env is recorded in the header, typically with the SHELL and TERM of
+	// the session.
+	Env map[string]string
+	// OmitInput disables the recording of stdin, which may contain
+	// passwords typed into the session.
+	OmitInput bool
+}
+
+// RecordingExecutor records the sessions of another Executor in the
+// asciicast v2 format of asciinema. Stdout and stderr are both recorded as
+// output, stdin as input and the sizes of the TerminalSizeQueue as resize
+// events, so that it works with every executor regardless of its protocol.
+//
+// Every call to StreamWithContext writes a complete recording, so a writer
+// should only be used for one session. A session fails once its recording
+// can't be written.
+type RecordingExecutor struct {
+	executor Executor
+	w        io.Writer
+	options  RecordingOptions
+	clock    clock.PassiveClock
+}
+
+// NewRecordingExecutor creates an Executor which records the sessions of
+// executor to w.
+func NewRecordingExecutor(executor Executor, w io.Writer, options RecordingOptions) *RecordingExecutor {
+	return &RecordingExecutor{
+		executor: executor,
+		w:        w,
+		options:  options,
+		clock:    clock.RealClock{},
+	}
+}
+
+// Stream is deprecated. Please use "StreamWithContext".
+func (r *RecordingExecutor) Stream(options StreamOptions) error {
+	return r.StreamWithContext(context.Background(), options)
+}
+
+// StreamWithContext writes the header of the recording, and records the
+// streams of options while the wrapped executor transports them.
+func (r *RecordingExecutor) StreamWithContext(ctx context.Context, options StreamOptions) error {
+	rec := &recorder{w: r.w, clock: r.clock, start: r.clock.Now()}
+	if err := rec.writeHeader(r.options); err != nil {
+		return fmt.Errorf("error writing session recording: %w", err)
+	}
+
+	recorded := options
+	if options.Stdin != nil && !r.options.OmitInput {
+		recorded.Stdin = &recordingReader{rec: rec, r: options.Stdin, stream: rec.newStream(asciicastInput)}
+	}
+	if options.Stdout != nil {
+		recorded.Stdout = &recordingWriter{rec: rec, w: options.Stdout, stream: rec.newStream(asciicastOutput)}
+	}
+	if options.Stderr != nil {
+		recorded.Stderr = &recordingWriter{rec: rec, w: options.Stderr, stream: rec.newStream(asciicastOutput)}
+	}
+	if options.TerminalSizeQueue != nil {
+		recorded.TerminalSizeQueue = &recordingSizeQueue{rec: rec, queue: options.TerminalSizeQueue}
+	}
+
+	err := r.executor.StreamWithContext(ctx, recorded)
+	if flushErr := rec.flush(); err == nil && flushErr != nil {
+		err = fmt.Errorf("error writing session recording: %w", flushErr)
+	}
+	return err
+}
+
+// recorder writes the events of a session. Events are written as whole
+// lines, in the order they are recorded.
+type recorder struct {
+	lock    sync.Mutex
+	w       io.Writer
+	clock   clock.PassiveClock
+	start   time.Time
+	streams []*recordedStream
+	// err is the first error writing the recording, which fails all
+	// further events.
+	err error
+}
+
+// asciicastHeader is the first line of an asciicast v2 recording.
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+func (r *recorder) writeHeader(options RecordingOptions) error {
+	header := asciicastHeader{
+		Version:   2,
+		Width:     options.Width,
+		Height:    options.Height,
+		Timestamp: r.start.Unix(),
+		Title:     options.Title,
+		Env:       options.Env,
+	}
+	if header.Width == 0 {
+		header.Width = 80
+	}
+	if header.Height == 0 {
+		header.Height = 24
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return r.writeLine(line)
+}
+
+// recordedStream holds back the bytes of an incomplete UTF-8 sequence at
+// the end of the data of a stream, since events are recorded as strings.
+type recordedStream struct {
+	code    string
+	pending []byte
+}
+
+func (r *recorder) newStream(code string) *recordedStream {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	s := &recordedStream{code: code}
+	r.streams = append(r.streams, s)
+	return s
+}
+
+// record records data of a stream.
+func (r *recorder) record(s *recordedStream, data []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	data = append(s.pending, data...)
+	complete := len(data) - incompleteRuneSuffix(data)
+	s.pending = append([]byte(nil), data[complete:]...)
+	if complete == 0 {
+		return nil
+	}
+	return r.writeEventLocked(s.code, string(data[:complete]))
+}
+
+// recordEvent records an event which isn't data of a stream.
+func (r *recorder) recordEvent(code, data string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.writeEventLocked(code, data)
+}
+
+// flush records the data which was held back at the end of the streams.
+func (r *recorder) flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, s := range r.streams {
+		if len(s.pending) > 0 {
+			if err := r.writeEventLocked(s.code, string(s.pending)); err != nil {
+				return err
+			}
+			s.pending = nil
+		}
+	}
+	return nil
+}
+
+func (r *recorder) writeEventLocked(code, data string) error {
+	elapsed := r.clock.Since(r.start).Seconds()
+	line, err := json.Marshal([]interface{}{json.Number(fmt.Sprintf("%.6f", elapsed)), code, data})
+	if err != nil {
+		return err
+	}
+	return r.writeLineLocked(line)
+}
+
+func (r *recorder) writeLine(line []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.writeLineLocked(line)
+}
+
+func (r *recorder) writeLineLocked(line []byte) error {
+	if r.err != nil {
+		return r.err
+	}
+	_, r.err = r.w.Write(append(line, '\n'))
+	return r.err
+}
+
+// incompleteRuneSuffix returns the length of the start of a UTF-8 sequence
+// at the end of data, which may be completed by the next data.
+func incompleteRuneSuffix(data []byte) int {
+	for n := 1; n < utf8.UTFMax && n <= len(data); n++ {
+		b := data[len(data)-n]
+		if !utf8.RuneStart(b) {
+			continue
+		}
+		if !utf8.FullRune(data[len(data)-n:]) {
+			return n
+		}
+		return 0
+	}
+	return 0
+}
+
+// recordingWriter records the data written to an output stream after it
+// was written.
+type recordingWriter struct {
+	rec    *recorder
+	w      io.Writer
+	stream *recordedStream
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		if recordErr := w.rec.record(w.stream, p[:n]); recordErr != nil && err == nil {
+			err = fmt.Errorf("error writing session recording: %w", recordErr)
+		}
+	}
+	return n, err
+}
+
+// recordingReader records the data read from stdin.
+type recordingReader struct {
+	rec    *recorder
+	r      io.Reader
+	stream *recordedStream
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if recordErr := r.rec.record(r.stream, p[:n]); recordErr != nil {
+			return n, fmt.Errorf("error writing session recording: %w", recordErr)
+		}
+	}
+	return n, err
+}
+
+// recordingSizeQueue records the sizes of a TerminalSizeQueue as resize
+// events.
+type recordingSizeQueue struct {
+	rec   *recorder
+	queue TerminalSizeQueue
+}
+
+func (q *recordingSizeQueue) Next() *TerminalSize {
+	size := q.queue.Next()
+	if size != nil {
+		// a failed resize event fails the session with its next output
+		_ = q.rec.recordEvent(asciicastResize, fmt.Sprintf("%dx%d", size.Width, size.Height))
+	}
+	return size
+}
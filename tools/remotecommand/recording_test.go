@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// scriptedExecutor runs a session against the streams of its options.
+type scriptedExecutor struct {
+	script func(options StreamOptions) error
+}
+
+func (e *scriptedExecutor) Stream(options StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *scriptedExecutor) StreamWithContext(ctx context.Context, options StreamOptions) error {
+	return e.script(options)
+}
+
+type fixedSizeQueue struct {
+	sizes []TerminalSize
+}
+
+func (q *fixedSizeQueue) Next() *TerminalSize {
+	if len(q.sizes) == 0 {
+		return nil
+	}
+	size := q.sizes[0]
+	q.sizes = q.sizes[1:]
+	return &size
+}
+
+func TestRecordingExecutor(t *testing.T) {
+	clock := testingclock.NewFakeClock(time.Unix(1700000000, 0))
+	// "é" is split across two writes
+	accent := []byte("é")
+	executor := &scriptedExecutor{script: func(options StreamOptions) error {
+		input, err := io.ReadAll(options.Stdin)
+		if err != nil {
+			return err
+		}
+		clock.Step(time.Second)
+		if size := options.TerminalSizeQueue.Next(); size == nil {
+			return errors.New("expected a terminal size")
+		}
+		clock.Step(500 * time.Millisecond)
+		if _, err := options.Stdout.Write(append([]byte("you said "+string(input)+" caf"), accent[0])); err != nil {
+			return err
+		}
+		if _, err := options.Stdout.Write(accent[1:]); err != nil {
+			return err
+		}
+		_, err = options.Stderr.Write([]byte("warning"))
+		return err
+	}}
+
+	var recording, stdout, stderr bytes.Buffer
+	r := NewRecordingExecutor(executor, &recording, RecordingOptions{
+		Title: "session",
+		Env:   map[string]string{"TERM": "xterm"},
+	})
+	r.clock = clock
+	err := r.StreamWithContext(context.Background(), StreamOptions{
+		Stdin:             strings.NewReader("hi"),
+		Stdout:            &stdout,
+		Stderr:            &stderr,
+		TerminalSizeQueue: &fixedSizeQueue{sizes: []TerminalSize{{Width: 120, Height: 40}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "you said hi café", stdout.String())
+	assert.Equal(t, "warning", stderr.String())
+
+	lines := strings.Split(strings.TrimSuffix(recording.String(), "\n"), "\n")
+	require.Len(t, lines, 6)
+	var header map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, map[string]interface{}{
+		"version":   float64(2),
+		"width":     float64(80),
+		"height":    float64(24),
+		"timestamp": float64(1700000000),
+		"title":     "session",
+		"env":       map[string]interface{}{"TERM": "xterm"},
+	}, header)
+
+	var events [][]interface{}
+	for _, line := range lines[1:] {
+		var event []interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	assert.Equal(t, [][]interface{}{
+		{float64(0), "i", "hi"},
+		{float64(1), "r", "120x40"},
+		{1.5, "o", "you said hi caf"},
+		{1.5, "o", "é"},
+		{1.5, "o", "warning"},
+	}, events)
+}
+
+func TestRecordingExecutorOmitInput(t *testing.T) {
+	executor := &scriptedExecutor{script: func(options StreamOptions) error {
+		_, err := io.ReadAll(options.Stdin)
+		return err
+	}}
+	var recording bytes.Buffer
+	r := NewRecordingExecutor(executor, &recording, RecordingOptions{OmitInput: true})
+	require.NoError(t, r.StreamWithContext(context.Background(), StreamOptions{Stdin: strings.NewReader("secret")}))
+	assert.NotContains(t, recording.String(), "secret")
+	assert.Equal(t, 1, strings.Count(recording.String(), "\n"), "expected only the header")
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.writes == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.writes--
+	return len(p), nil
+}
+
+func TestRecordingExecutorFailsWithRecording(t *testing.T) {
+	executor := &scriptedExecutor{script: func(options StreamOptions) error {
+		_, err := options.Stdout.Write([]byte("output"))
+		return err
+	}}
+
+	// the header can't be written
+	r := NewRecordingExecutor(executor, &failingWriter{}, RecordingOptions{})
+	err := r.StreamWithContext(context.Background(), StreamOptions{Stdout: io.Discard})
+	assert.ErrorContains(t, err, "disk full")
+
+	// the output can't be recorded
+	r = NewRecordingExecutor(executor, &failingWriter{writes: 1}, RecordingOptions{})
+	err = r.StreamWithContext(context.Background(), StreamOptions{Stdout: io.Discard})
+	assert.ErrorContains(t, err, "disk full")
+}
+
+func TestIncompleteRuneSuffix(t *testing.T) {
+	euro := []byte("€")
+	for _, tc := range []struct {
+		data     []byte
+		expected int
+	}{
+		{data: nil, expected: 0},
+		{data: []byte("abc"), expected: 0},
+		{data: euro, expected: 0},
+		{data: euro[:1], expected: 1},
+		{data: append([]byte("a"), euro[:2]...), expected: 2},
+		{data: []byte{0xff}, expected: 0},
+	} {
+		assert.Equal(t, tc.expected, incompleteRuneSuffix(tc.data), "%q", tc.data)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/klog/v2"
+)
+
+// AuditConfig configures the auditing of requests.
+type AuditConfig struct {
+	// Sink receives an entry for every audited request.
+	Sink AuditSink
+	// AllVerbs also audits read-only requests. By default only requests
+	// which may mutate resources, that is POST, PUT, PATCH and DELETE
+	// requests, are audited.
+	AllVerbs bool
+}
+
+// AuditSink records audit entries. It must be safe for concurrent use.
+type AuditSink interface {
+	// Audit records entry. ctx is the context of the audited request.
+	Audit(ctx context.Context, entry *AuditEntry)
+}
+
+// AuditEntry describes a request sent to the server. Every attempt of a
+// request which is retried gets its own entry.
+type AuditEntry struct {
+	// Time is when the request was sent.
+	Time time.Time `json:"time"`
+	// User is the user name for basic authentication, if any.
+	User string `json:"user,omitempty"`
+	// Credential is the kind of credential which authenticated the
+	// request: "basic", "bearer" or "client-certificate". It is empty for
+	// anonymous requests.
+	Credential string `json:"credential,omitempty"`
+	// CredentialSHA256 is the hex encoded SHA-256 hash of the bearer
+	// token, so that requests can be attributed to a known token without
+	// recording the token itself. Tokens of exec plugins are bearer
+	// tokens as well.
+	CredentialSHA256 string `json:"credentialSHA256,omitempty"`
+	// ImpersonatedUser, ImpersonatedUID and ImpersonatedGroups are the
+	// identity which the request impersonates, if any.
+	ImpersonatedUser   string   `json:"impersonatedUser,omitempty"`
+	ImpersonatedUID    string   `json:"impersonatedUID,omitempty"`
+	ImpersonatedGroups []string `json:"impersonatedGroups,omitempty"`
+	// Verb is the HTTP method of the request.
+	Verb string `json:"verb"`
+	URL  string `json:"url"`
+	// RequestBodySHA256 is the hex encoded SHA-256 hash of the request
+	// body. It is empty for requests without a body and for requests
+	// whose body can only be read once, like streams.
+	RequestBodySHA256 string `json:"requestBodySHA256,omitempty"`
+	// StatusCode of the response, or zero if there was none.
+	StatusCode int `json:"code,omitempty"`
+	// Error is the reason why there was no response.
+	Error string `json:"error,omitempty"`
+	// Latency is the time until the response headers were received, in
+	// nanoseconds when encoded as JSON.
+	Latency time.Duration `json:"latency"`
+}
+
+type auditRoundTripper struct {
+	sink     AuditSink
+	allVerbs bool
+	// clientCertificate is set if the transport presents a client
+	// certificate, which requests without an Authorization header are
+	// authenticated with.
+	clientCertificate bool
+	rt                http.RoundTripper
+}
+
+var _ utilnet.RoundTripperWrapper = &auditRoundTripper{}
+
+// NewAuditRoundTripper records an AuditEntry with config.Sink for the
+// requests sent through rt.
+func NewAuditRoundTripper(config AuditConfig, rt http.RoundTripper) http.RoundTripper {
+	return &auditRoundTripper{sink: config.Sink, allVerbs: config.AllVerbs, rt: rt}
+}
+
+func (rt *auditRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !rt.allVerbs && !isMutatingMethod(req.Method) {
+		return rt.rt.RoundTrip(req)
+	}
+
+	entry := &AuditEntry{
+		Verb:               req.Method,
+		URL:                req.URL.String(),
+		ImpersonatedUser:   req.Header.Get(ImpersonateUserHeader),
+		ImpersonatedUID:    req.Header.Get(ImpersonateUIDHeader),
+		ImpersonatedGroups: req.Header.Values(ImpersonateGroupHeader),
+		RequestBodySHA256:  hashRequestBody(req),
+	}
+	rt.setCredential(req, entry)
+
+	entry.Time = time.Now()
+	resp, err := rt.rt.RoundTrip(req)
+	entry.Latency = time.Since(entry.Time)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.StatusCode = resp.StatusCode
+	}
+	rt.sink.Audit(req.Context(), entry)
+	return resp, err
+}
+
+// setCredential records the credential of req in entry.
+func (rt *auditRoundTripper) setCredential(req *http.Request, entry *AuditEntry) {
+	if user, _, ok := req.BasicAuth(); ok {
+		entry.User = user
+		entry.Credential = "basic"
+		return
+	}
+	if scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "bearer") {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+		entry.Credential = "bearer"
+		entry.CredentialSHA256 = hex.EncodeToString(sum[:])
+		return
+	}
+	if rt.clientCertificate {
+		entry.Credential = "client-certificate"
+	}
+}
+
+func (rt *auditRoundTripper) CancelRequest(req *http.Request) {
+	tryCancelRequest(rt.WrappedRoundTripper(), req)
+}
+
+func (rt *auditRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// hashRequestBody hashes a copy of the body of req, without consuming the
+// body itself.
+func hashRequestBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileAuditSink appends audit entries to a file as JSON lines.
+type FileAuditSink struct {
+	path string
+	lock sync.Mutex
+}
+
+var _ AuditSink = &FileAuditSink{}
+
+// NewFileAuditSink returns a sink which appends to the file at path. The
+// file is created with mode 0600 if it doesn't exist. It is opened for each
+// entry, so it may be rotated at any time.
+func NewFileAuditSink(path string) *FileAuditSink {
+	return &FileAuditSink{path: path}
+}
+
+// Audit appends entry to the file. Failures are logged.
+func (s *FileAuditSink) Audit(ctx context.Context, entry *AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to encode audit entry")
+		return
+	}
+	data = append(data, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to open audit log", "path", s.path)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to write audit log", "path", s.path)
+	}
+}
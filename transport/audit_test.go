@@ -0,0 +1,197 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingAuditSink struct {
+	lock    sync.Mutex
+	entries []*AuditEntry
+}
+
+func (s *recordingAuditSink) Audit(_ context.Context, entry *AuditEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+func TestAuditRoundTripper(t *testing.T) {
+	body := []byte(`{"kind":"Pod"}`)
+	sum := sha256.Sum256(body)
+
+	sink := &recordingAuditSink{}
+	rt := &testRoundTripper{Response: &http.Response{StatusCode: http.StatusCreated}}
+	wrapped, err := HTTPWrappersForConfig(&Config{
+		Username: "alice",
+		Password: "secret",
+		Impersonate: ImpersonationConfig{
+			UserName: "bob",
+			UID:      "1234",
+			Groups:   []string{"admins", "devs"},
+		},
+		Audit: &AuditConfig{Sink: sink},
+	}, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/api/v1/namespaces/default/pods", bytes.NewReader(body))
+	if _, err := wrapped.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://example.com/api/v1/namespaces/default/pods", nil)
+	if _, err := wrapped.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.entries) != 1 {
+		t.Fatalf("expected only the POST request to be audited, got %d entries", len(sink.entries))
+	}
+	entry := sink.entries[0]
+	if entry.Time.IsZero() {
+		t.Errorf("expected the time of the request")
+	}
+	entry.Latency = 0
+	expected := &AuditEntry{
+		Time:               entry.Time,
+		User:               "alice",
+		Credential:         "basic",
+		ImpersonatedUser:   "bob",
+		ImpersonatedUID:    "1234",
+		ImpersonatedGroups: []string{"admins", "devs"},
+		Verb:               http.MethodPost,
+		URL:                "https://example.com/api/v1/namespaces/default/pods",
+		RequestBodySHA256:  hex.EncodeToString(sum[:]),
+		StatusCode:         http.StatusCreated,
+	}
+	if !reflect.DeepEqual(expected, entry) {
+		t.Errorf("expected entry %#v, got %#v", expected, entry)
+	}
+}
+
+func TestAuditRoundTripperCredentials(t *testing.T) {
+	tokenSum := sha256.Sum256([]byte("the-token"))
+	testCases := map[string]struct {
+		config             Config
+		expectedCredential string
+		expectedSHA256     string
+	}{
+		"anonymous": {},
+		"bearer token": {
+			config:             Config{BearerToken: "the-token"},
+			expectedCredential: "bearer",
+			expectedSHA256:     hex.EncodeToString(tokenSum[:]),
+		},
+		"token of a wrapper": {
+			// Like the token of an exec plugin.
+			config: Config{WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+				return NewBearerAuthRoundTripper("the-token", rt)
+			}},
+			expectedCredential: "bearer",
+			expectedSHA256:     hex.EncodeToString(tokenSum[:]),
+		},
+		"client certificate": {
+			config:             Config{TLS: TLSConfig{CertData: []byte("cert"), KeyData: []byte("key")}},
+			expectedCredential: "client-certificate",
+		},
+		"client certificate callback": {
+			config:             Config{TLS: TLSConfig{GetCertHolder: &GetCertHolder{GetCert: func() (*tls.Certificate, error) { return nil, nil }}}},
+			expectedCredential: "client-certificate",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sink := &recordingAuditSink{}
+			tc.config.Audit = &AuditConfig{Sink: sink}
+			wrapped, err := HTTPWrappersForConfig(&tc.config, &testRoundTripper{Response: &http.Response{StatusCode: http.StatusOK}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest(http.MethodDelete, "https://example.com/api/v1/namespaces/default/pods/foo", nil)
+			if _, err := wrapped.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if len(sink.entries) != 1 {
+				t.Fatalf("expected one entry, got %d", len(sink.entries))
+			}
+			if entry := sink.entries[0]; entry.Credential != tc.expectedCredential || entry.CredentialSHA256 != tc.expectedSHA256 {
+				t.Errorf("expected credential %q with hash %q, got %q with hash %q", tc.expectedCredential, tc.expectedSHA256, entry.Credential, entry.CredentialSHA256)
+			}
+		})
+	}
+}
+
+func TestAuditRoundTripperAllVerbs(t *testing.T) {
+	sink := &recordingAuditSink{}
+	rt := NewAuditRoundTripper(AuditConfig{Sink: sink, AllVerbs: true}, &testRoundTripper{Err: errors.New("connection refused")})
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/api", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(sink.entries) != 1 {
+		t.Fatalf("expected the GET request to be audited, got %d entries", len(sink.entries))
+	}
+	if entry := sink.entries[0]; entry.Error != "connection refused" || entry.StatusCode != 0 || entry.RequestBodySHA256 != "" {
+		t.Errorf("unexpected entry %#v", entry)
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink := NewFileAuditSink(path)
+	sink.Audit(context.Background(), &AuditEntry{Verb: http.MethodPost, StatusCode: http.StatusCreated})
+	sink.Audit(context.Background(), &AuditEntry{Verb: http.MethodDelete, StatusCode: http.StatusOK})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines, got %q", data)
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Verb != http.MethodDelete || entry.StatusCode != http.StatusOK {
+		t.Errorf("unexpected entry %#v", entry)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
@@ -18,13 +18,17 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/net/http2"
+
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/metrics"
@@ -36,7 +40,7 @@ import (
 // the config has no custom TLS options, http.DefaultTransport is returned.
 type tlsTransportCache struct {
 	mu         sync.Mutex
-	transports map[tlsCacheKey]*http.Transport
+	transports map[tlsCacheKey]http.RoundTripper
 }
 
 // DialerStopCh is stop channel that is passed down to dynamic cert dialer.
@@ -46,7 +50,7 @@ var DialerStopCh = wait.NeverStop
 
 const idleConnsPerHost = 25
 
-var tlsCache = &tlsTransportCache{transports: make(map[tlsCacheKey]*http.Transport)}
+var tlsCache = &tlsTransportCache{transports: make(map[tlsCacheKey]http.RoundTripper)}
 
 type tlsCacheKey struct {
 	insecure           bool
@@ -58,6 +62,7 @@ type tlsCacheKey struct {
 	serverName         string
 	nextProtos         string
 	disableCompression bool
+	connectionsPerHost int
 	// these functions are wrapped to allow them to be used as map keys
 	getCert *GetCertHolder
 	dial    *DialHolder
@@ -68,8 +73,8 @@ func (t tlsCacheKey) String() string {
 	if len(t.keyData) > 0 {
 		keyText = "<redacted>"
 	}
-	return fmt.Sprintf("insecure:%v, caData:%#v, certData:%#v, keyData:%s, serverName:%s, disableCompression:%t, getCert:%p, dial:%p",
-		t.insecure, t.caData, t.certData, keyText, t.serverName, t.disableCompression, t.getCert, t.dial)
+	return fmt.Sprintf("insecure:%v, caData:%#v, certData:%#v, keyData:%s, serverName:%s, disableCompression:%t, connectionsPerHost:%d, getCert:%p, dial:%p",
+		t.insecure, t.caData, t.certData, keyText, t.serverName, t.disableCompression, t.connectionsPerHost, t.getCert, t.dial)
 }
 
 func (c *tlsTransportCache) get(config *Config) (http.RoundTripper, error) {
@@ -100,7 +105,8 @@ func (c *tlsTransportCache) get(config *Config) (http.RoundTripper, error) {
 		return nil, err
 	}
 	// The options didn't require a custom TLS config
-	if tlsConfig == nil && config.DialHolder == nil && config.Proxy == nil {
+	pooled := usesConnectionPool(config, tlsConfig)
+	if tlsConfig == nil && config.DialHolder == nil && config.Proxy == nil && !pooled {
 		return http.DefaultTransport, nil
 	}
 
@@ -140,12 +146,26 @@ func (c *tlsTransportCache) get(config *Config) (http.RoundTripper, error) {
 		DisableCompression:  config.DisableCompression,
 	})
 
+	var rt http.RoundTripper = transport
+	if pooled {
+		rt = newPooledTransport(transport, tlsConfig, dial, config.ConnectionsPerHost)
+	}
+
 	if canCache {
 		// Cache a single transport for these options
-		c.transports[key] = transport
+		c.transports[key] = rt
 	}
 
-	return transport, nil
+	return rt, nil
+}
+
+// usesConnectionPool returns true if config asks for more than one HTTP/2
+// connection per host, which requires that HTTP/2 isn't disabled.
+func usesConnectionPool(config *Config, tlsConfig *tls.Config) bool {
+	if config.ConnectionsPerHost <= 1 {
+		return false
+	}
+	return tlsConfig == nil || len(tlsConfig.NextProtos) == 0 || slices.Contains(tlsConfig.NextProtos, http2.NextProtoTLS)
 }
 
 // tlsConfigKey returns a unique key for tls.Config objects returned from TLSConfigFor
@@ -166,6 +186,7 @@ func tlsConfigKey(c *Config) (tlsCacheKey, bool, error) {
 		serverName:         c.TLS.ServerName,
 		nextProtos:         strings.Join(c.TLS.NextProtos, ","),
 		disableCompression: c.DisableCompression,
+		connectionsPerHost: c.ConnectionsPerHost,
 		getCert:            c.TLS.GetCertHolder,
 		dial:               c.DialHolder,
 	}
@@ -77,6 +77,16 @@ type Config struct {
 	//
 	// socks5 proxying does not currently support spdy streaming endpoints.
 	Proxy func(*http.Request) (*url.URL, error)
+
+	// Audit, if set, records an entry for requests sent by this transport.
+	Audit *AuditConfig
+
+	// ConnectionsPerHost, if greater than one, spreads the HTTP/2 requests
+	// to a host over up to that many connections, instead of multiplexing
+	// them over a single one which may hit the concurrent stream limit of
+	// the server. Each request uses the connection with the fewest streams.
+	// Requests through a proxy are not pooled.
+	ConnectionsPerHost int
 }
 
 // DialHolder is used to make the wrapped function comparable so that it can be used as a map key.
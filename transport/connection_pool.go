@@ -0,0 +1,368 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// connectionHealthInterval is how often the pooled connections get
+	// pinged to report their health.
+	connectionHealthInterval = 30 * time.Second
+	// connectionPingTimeout is how long a ping may take before the
+	// connection is considered broken and gets closed.
+	connectionPingTimeout = 15 * time.Second
+	// connectionDialTimeout bounds establishing a pooled connection.
+	connectionDialTimeout = 30 * time.Second
+)
+
+// errHTTP2NotNegotiated is returned when a server does not accept HTTP/2.
+var errHTTP2NotNegotiated = errors.New("the server did not negotiate HTTP/2")
+
+// pooledTransport spreads the HTTP/2 requests to each host over a pool of
+// connections, instead of multiplexing them over a single one. Requests
+// which can't use HTTP/2, like those going through a proxy or upgrading the
+// connection, are sent with the wrapped http.Transport.
+type pooledTransport struct {
+	rt    *http.Transport
+	proxy func(*http.Request) (*url.URL, error)
+	h2    *http2.Transport
+	pool  *http2ConnPool
+}
+
+var _ utilnet.RoundTripperWrapper = &pooledTransport{}
+
+// newPooledTransport returns a transport which pools up to size HTTP/2
+// connections per host. The connections are dialed with dial and tlsConfig;
+// everything else is sent with rt.
+func newPooledTransport(rt *http.Transport, tlsConfig *tls.Config, dial func(ctx context.Context, network, address string) (net.Conn, error), size int) *pooledTransport {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if len(tlsConfig.NextProtos) == 0 {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	h2 := &http2.Transport{
+		DisableCompression: rt.DisableCompression,
+		IdleConnTimeout:    rt.IdleConnTimeout,
+		ReadIdleTimeout:    connectionHealthInterval,
+		PingTimeout:        connectionPingTimeout,
+	}
+	pool := &http2ConnPool{
+		transport:      h2,
+		tlsConfig:      tlsConfig,
+		dial:           dial,
+		size:           size,
+		healthInterval: connectionHealthInterval,
+		hosts:          map[string]*http2HostConns{},
+	}
+	h2.ConnPool = pool
+	proxy := rt.Proxy
+	if proxy == nil {
+		proxy = func(*http.Request) (*url.URL, error) { return nil, nil }
+	}
+	return &pooledTransport{rt: rt, proxy: proxy, h2: h2, pool: pool}
+}
+
+func (t *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.usePool(req) {
+		return t.rt.RoundTrip(req)
+	}
+	resp, err := t.h2.RoundTripOpt(req, http2.RoundTripOpt{})
+	if errors.Is(err, errHTTP2NotNegotiated) {
+		return t.rt.RoundTrip(req)
+	}
+	return resp, err
+}
+
+func (t *pooledTransport) usePool(req *http.Request) bool {
+	if req.URL.Scheme != "https" || httpguts.HeaderValuesContainsToken(req.Header["Connection"], "Upgrade") {
+		return false
+	}
+	if proxyURL, err := t.proxy(req); err != nil || proxyURL != nil {
+		return false
+	}
+	return !t.pool.isHTTP1(authorityAddr(req.URL))
+}
+
+func (t *pooledTransport) CloseIdleConnections() {
+	t.rt.CloseIdleConnections()
+	t.pool.closeIdleConnections()
+}
+
+func (t *pooledTransport) WrappedRoundTripper() http.RoundTripper { return t.rt }
+
+// authorityAddr returns the host:port which an https URL connects to.
+func authorityAddr(u *url.URL) string {
+	port := u.Port()
+	if len(port) == 0 {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// http2ConnPool keeps a fixed number of connection slots per host. A
+// request gets the connection with the fewest streams, unless that one is
+// busy and a slot is free for another connection.
+type http2ConnPool struct {
+	transport      *http2.Transport
+	tlsConfig      *tls.Config
+	dial           func(ctx context.Context, network, address string) (net.Conn, error)
+	size           int
+	healthInterval time.Duration
+
+	lock       sync.Mutex
+	hosts      map[string]*http2HostConns
+	monitoring bool
+}
+
+var _ http2.ClientConnPool = &http2ConnPool{}
+
+type http2HostConns struct {
+	conns   []*http2.ClientConn
+	dialing []bool
+	// dialed is closed when a dial finished, for requests waiting for a
+	// connection while all slots are being dialed.
+	dialed chan struct{}
+	// http1 is set once the host did not negotiate HTTP/2.
+	http1 bool
+}
+
+func (p *http2ConnPool) host(addr string) *http2HostConns {
+	host, ok := p.hosts[addr]
+	if !ok {
+		host = &http2HostConns{
+			conns:   make([]*http2.ClientConn, p.size),
+			dialing: make([]bool, p.size),
+		}
+		p.hosts[addr] = host
+	}
+	return host
+}
+
+func (p *http2ConnPool) isHTTP1(addr string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	host, ok := p.hosts[addr]
+	return ok && host.http1
+}
+
+// streamLoad returns the number of streams which are using cc or waiting
+// for it.
+func streamLoad(cc *http2.ClientConn) int {
+	state := cc.State()
+	return state.StreamsActive + state.StreamsReserved + state.StreamsPending
+}
+
+func (p *http2ConnPool) GetClientConn(req *http.Request, addr string) (*http2.ClientConn, error) {
+	for {
+		p.lock.Lock()
+		host := p.host(addr)
+		best, bestLoad, free := -1, 0, -1
+		for i, cc := range host.conns {
+			switch {
+			case host.dialing[i]:
+			case cc == nil || !cc.CanTakeNewRequest():
+				if free < 0 {
+					free = i
+				}
+			default:
+				if load := streamLoad(cc); best < 0 || load < bestLoad {
+					best, bestLoad = i, load
+				}
+			}
+		}
+		if best >= 0 && (bestLoad == 0 || free < 0) {
+			cc := host.conns[best]
+			p.lock.Unlock()
+			return cc, nil
+		}
+		if free >= 0 {
+			host.dialing[free] = true
+			p.lock.Unlock()
+			return p.dialSlot(req.Context(), addr, free)
+		}
+		if host.dialed == nil {
+			host.dialed = make(chan struct{})
+		}
+		dialed := host.dialed
+		p.lock.Unlock()
+
+		select {
+		case <-dialed:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// dialSlot dials a connection to addr into the given slot, which the
+// caller reserved.
+func (p *http2ConnPool) dialSlot(ctx context.Context, addr string, slot int) (*http2.ClientConn, error) {
+	cc, err := p.dialConn(ctx, addr)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	host := p.host(addr)
+	host.dialing[slot] = false
+	if host.dialed != nil {
+		close(host.dialed)
+		host.dialed = nil
+	}
+	if errors.Is(err, errHTTP2NotNegotiated) {
+		host.http1 = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	host.conns[slot] = cc
+	if !p.monitoring {
+		p.monitoring = true
+		go p.monitor()
+	}
+	return cc, nil
+}
+
+func (p *http2ConnPool) dialConn(ctx context.Context, addr string) (*http2.ClientConn, error) {
+	// The connection outlives the request which caused it to be dialed.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), connectionDialTimeout)
+	defer cancel()
+
+	rawConn, err := p.dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := p.tlsConfig.Clone()
+	if len(tlsConfig.ServerName) == 0 {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	tlsConn := tls.Client(rawConn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	if tlsConn.ConnectionState().NegotiatedProtocol != http2.NextProtoTLS {
+		tlsConn.Close()
+		return nil, errHTTP2NotNegotiated
+	}
+	return p.transport.NewClientConn(tlsConn)
+}
+
+func (p *http2ConnPool) MarkDead(cc *http2.ClientConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, host := range p.hosts {
+		if i := slices.Index(host.conns, cc); i >= 0 {
+			host.conns[i] = nil
+		}
+	}
+}
+
+func (p *http2ConnPool) closeIdleConnections() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, host := range p.hosts {
+		for i, cc := range host.conns {
+			if cc != nil && streamLoad(cc) == 0 {
+				cc.Close()
+				host.conns[i] = nil
+			}
+		}
+	}
+}
+
+type pooledConn struct {
+	addr  string
+	index int
+	cc    *http2.ClientConn
+}
+
+// monitor pings the pooled connections and reports their health until
+// there are none left.
+func (p *http2ConnPool) monitor() {
+	ticker := time.NewTicker(p.healthInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		conns := p.openConns()
+		if len(conns) == 0 {
+			return
+		}
+		for _, conn := range conns {
+			ctx, cancel := context.WithTimeout(context.Background(), connectionPingTimeout)
+			start := time.Now()
+			err := conn.cc.Ping(ctx)
+			cancel()
+			if err != nil {
+				klog.Background().V(4).Info("Closing pooled HTTP/2 connection after failed ping", "host", conn.addr, "connection", conn.index, "err", err)
+				conn.cc.Close()
+				p.MarkDead(conn.cc)
+				continue
+			}
+			metrics.TransportConnectionHealth.Observe(conn.addr, conn.index, conn.cc.State().StreamsActive, time.Since(start))
+		}
+	}
+}
+
+// openConns returns the pooled connections which are not closed, and
+// forgets about the closed ones. If there are none, the monitoring stops.
+func (p *http2ConnPool) openConns() []pooledConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var conns []pooledConn
+	for addr, host := range p.hosts {
+		for i, cc := range host.conns {
+			if cc == nil {
+				continue
+			}
+			if cc.State().Closed {
+				host.conns[i] = nil
+				continue
+			}
+			conns = append(conns, pooledConn{addr: addr, index: i, cc: cc})
+		}
+	}
+	if len(conns) == 0 {
+		p.monitoring = false
+	}
+	return conns
+}
+
+// isMonitoring returns true while the health of the connections is being
+// monitored.
+func (p *http2ConnPool) isMonitoring() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.monitoring
+}
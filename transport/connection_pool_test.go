@@ -0,0 +1,187 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/client-go/util/cert"
+)
+
+// startTLSTestServer starts a TLS server and returns its URL. It doesn't
+// use httptest.Server, whose Close initializes http.DefaultTransport, which
+// TestNew expects to be untouched.
+func startTLSTestServer(t *testing.T, handler http.Handler, enableHTTP2 bool) string {
+	certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey("127.0.0.1", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:   handler,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{certificate}},
+	}
+	if !enableHTTP2 {
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	go func() { _ = server.ServeTLS(listener, "", "") }()
+	t.Cleanup(func() { _ = server.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+func TestPooledTransport(t *testing.T) {
+	const requests = 6
+
+	var lock sync.Mutex
+	remoteAddrs := map[string]int{}
+	arrived := make(chan struct{}, requests)
+	release := make(chan struct{})
+	serverURL := startTLSTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ProtoMajor != 2 {
+			t.Errorf("expected an HTTP/2 request, got %s", req.Proto)
+		}
+		lock.Lock()
+		remoteAddrs[req.RemoteAddr]++
+		lock.Unlock()
+		arrived <- struct{}{}
+		<-release
+	}), true)
+
+	rt, err := New(&Config{TLS: TLSConfig{Insecure: true}, ConnectionsPerHost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rt.(*pooledTransport); !ok {
+		t.Fatalf("expected a pooled transport, got %T", rt)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, serverURL, nil)
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		// Wait for each request to be in flight, so that the pool sees
+		// the busy connections.
+		select {
+		case <-arrived:
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("request did not arrive")
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if len(remoteAddrs) != 2 {
+		t.Fatalf("expected the requests to be spread over two connections, got %v", remoteAddrs)
+	}
+	for addr, count := range remoteAddrs {
+		if count != requests/2 {
+			t.Errorf("expected %d requests on connection %s, got %d", requests/2, addr, count)
+		}
+	}
+}
+
+type fakeConnectionHealthMetric struct {
+	observed chan int
+}
+
+func (m *fakeConnectionHealthMetric) Observe(host string, connection int, activeStreams int, pingRTT time.Duration) {
+	select {
+	case m.observed <- connection:
+	default:
+	}
+}
+
+func TestPooledTransportHealth(t *testing.T) {
+	metric := &fakeConnectionHealthMetric{observed: make(chan int, 1)}
+	original := metrics.TransportConnectionHealth
+	metrics.TransportConnectionHealth = metric
+	defer func() { metrics.TransportConnectionHealth = original }()
+
+	serverURL := startTLSTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), true)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	rt := newPooledTransport(&http.Transport{TLSClientConfig: tlsConfig}, tlsConfig, (&net.Dialer{}).DialContext, 2)
+	rt.pool.healthInterval = 10 * time.Millisecond
+	req, _ := http.NewRequest(http.MethodGet, serverURL, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case connection := <-metric.observed:
+		if connection != 0 {
+			t.Errorf("expected the health of the first connection, got %d", connection)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the connection health was not reported")
+	}
+
+	// The monitoring stops once the idle connections are closed.
+	rt.CloseIdleConnections()
+	err = wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return !rt.pool.isMonitoring(), nil
+	})
+	if err != nil {
+		t.Fatal("the monitoring did not stop after the connections were closed")
+	}
+}
+
+func TestPooledTransportHTTP1(t *testing.T) {
+	serverURL := startTLSTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), false)
+
+	rt, err := New(&Config{TLS: TLSConfig{Insecure: true}, ConnectionsPerHost: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, serverURL, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 1 {
+			t.Errorf("expected HTTP/1, got %s", resp.Proto)
+		}
+	}
+}
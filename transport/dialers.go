@@ -0,0 +1,267 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DialFunc is the signature of the dial functions used by Config.DialHolder
+// and rest.Config.Dial. The dialers returned by this package can be wrapped
+// with connrotation.NewDialer, so that their connections get closed when
+// credentials rotate.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// NewUnixSocketDialer returns a dial function which connects to the Unix
+// domain socket at path, for an API server which listens on a socket
+// instead of a TCP port. The address being dialed is ignored, so the host
+// of the server URL is only used for TLS server name verification.
+func NewUnixSocketDialer(path string) DialFunc {
+	dialer := &net.Dialer{}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
+
+// SSHTunnelConfig configures a dialer which reaches the API server through
+// an SSH bastion host.
+type SSHTunnelConfig struct {
+	// Host is the bastion to connect to, as [user@]host[:port].
+	Host string
+	// IdentityFile is the path of the private key to authenticate with.
+	// If empty, the keys of the SSH agent and the default identities of
+	// the ssh client are used.
+	IdentityFile string
+	// AgentSocket is the path of the socket of the SSH agent. If empty,
+	// the SSH_AUTH_SOCK environment variable is used.
+	AgentSocket string
+	// KnownHostsFile is the path of the file with the public keys of the
+	// trusted bastion hosts. If empty, the default files of the ssh client
+	// are used. Unknown hosts are always rejected.
+	KnownHostsFile string
+	// Command is the ssh client to run, "ssh" from the PATH by default.
+	Command string
+}
+
+// NewSSHTunnelDialer returns a dial function which connects to the address
+// through the SSH bastion host configured by config. Every connection runs
+// the OpenSSH client with stdio forwarding (ssh -W), which honors the
+// ssh_config of the user, and is closed by stopping the client.
+func NewSSHTunnelDialer(config SSHTunnelConfig) DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		switch network {
+		case "tcp", "tcp4", "tcp6":
+		default:
+			return nil, fmt.Errorf("unsupported network %q for an SSH tunnel", network)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return dialSSHTunnel(config, address)
+	}
+}
+
+// sshTunnelArgs returns the arguments of the ssh client which forward its
+// stdio to address.
+func sshTunnelArgs(config SSHTunnelConfig, address string) ([]string, error) {
+	host, port, err := net.SplitHostPort(config.Host)
+	if err != nil {
+		// There is no port.
+		host, port = config.Host, ""
+	}
+	if len(host) == 0 || strings.HasPrefix(host, "-") {
+		return nil, fmt.Errorf("invalid SSH tunnel host %q", config.Host)
+	}
+	args := []string{
+		"-W", address,
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "ExitOnForwardFailure=yes",
+	}
+	if len(port) > 0 {
+		args = append(args, "-p", port)
+	}
+	if len(config.IdentityFile) > 0 {
+		args = append(args, "-i", config.IdentityFile, "-o", "IdentitiesOnly=yes")
+	}
+	if len(config.KnownHostsFile) > 0 {
+		args = append(args, "-o", "UserKnownHostsFile="+config.KnownHostsFile)
+	}
+	return append(args, "--", host), nil
+}
+
+func dialSSHTunnel(config SSHTunnelConfig, address string) (net.Conn, error) {
+	args, err := sshTunnelArgs(config, address)
+	if err != nil {
+		return nil, err
+	}
+	command := config.Command
+	if len(command) == 0 {
+		command = "ssh"
+	}
+	// The connection outlives the context of the dial, so the client is
+	// not bound to it.
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	if len(config.AgentSocket) > 0 {
+		cmd.Env = append(cmd.Env, "SSH_AUTH_SOCK="+config.AgentSocket)
+	}
+
+	// Pipes created with os.Pipe support deadlines.
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
+		return nil, err
+	}
+	conn := &sshTunnelConn{
+		cmd:     cmd,
+		stdin:   stdinWriter,
+		stdout:  stdoutReader,
+		address: address,
+		exited:  make(chan struct{}),
+	}
+	cmd.Stdin = stdinReader
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = &conn.stderr
+	err = cmd.Start()
+	// The client has its own copies of its ends of the pipes.
+	stdinReader.Close()
+	stdoutWriter.Close()
+	if err != nil {
+		stdinWriter.Close()
+		stdoutReader.Close()
+		return nil, fmt.Errorf("failed to start the SSH tunnel to %s: %w", config.Host, err)
+	}
+	go func() {
+		conn.waitErr = cmd.Wait()
+		close(conn.exited)
+	}()
+	return conn, nil
+}
+
+// sshTunnelConn is a connection through the stdio of an ssh client.
+type sshTunnelConn struct {
+	cmd     *exec.Cmd
+	stdin   *os.File
+	stdout  *os.File
+	address string
+
+	stderr  lockedBuffer
+	exited  chan struct{}
+	waitErr error
+
+	closeOnce sync.Once
+}
+
+var _ net.Conn = &sshTunnelConn{}
+
+func (c *sshTunnelConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF {
+		if tunnelErr := c.tunnelError(); tunnelErr != nil {
+			return n, tunnelErr
+		}
+	}
+	return n, err
+}
+
+func (c *sshTunnelConn) Write(p []byte) (int, error) {
+	n, err := c.stdin.Write(p)
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		if tunnelErr := c.tunnelError(); tunnelErr != nil {
+			return n, tunnelErr
+		}
+	}
+	return n, err
+}
+
+// tunnelError returns why the ssh client failed, if it did.
+func (c *sshTunnelConn) tunnelError() error {
+	select {
+	case <-c.exited:
+	case <-time.After(time.Second):
+		return nil
+	}
+	if c.waitErr == nil {
+		return nil
+	}
+	if stderr := strings.TrimSpace(c.stderr.String()); len(stderr) > 0 {
+		return fmt.Errorf("SSH tunnel to %s failed: %w: %s", c.address, c.waitErr, stderr)
+	}
+	return fmt.Errorf("SSH tunnel to %s failed: %w", c.address, c.waitErr)
+}
+
+// Close stops the ssh client.
+func (c *sshTunnelConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		if c.cmd.Process != nil {
+			_ = c.cmd.Process.Kill()
+		}
+		<-c.exited
+	})
+	return nil
+}
+
+func (c *sshTunnelConn) LocalAddr() net.Addr  { return sshTunnelAddr("ssh") }
+func (c *sshTunnelConn) RemoteAddr() net.Addr { return sshTunnelAddr(c.address) }
+
+func (c *sshTunnelConn) SetDeadline(t time.Time) error {
+	return errors.Join(c.SetReadDeadline(t), c.SetWriteDeadline(t))
+}
+
+func (c *sshTunnelConn) SetReadDeadline(t time.Time) error  { return c.stdout.SetReadDeadline(t) }
+func (c *sshTunnelConn) SetWriteDeadline(t time.Time) error { return c.stdin.SetWriteDeadline(t) }
+
+type sshTunnelAddr string
+
+func (a sshTunnelAddr) Network() string { return "ssh" }
+func (a sshTunnelAddr) String() string  { return string(a) }
+
+// lockedBuffer is a bytes.Buffer which is safe for concurrent use.
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUnixSocketDialer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apiserver.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(req.Host))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{DialContext: NewUnixSocketDialer(path)}}
+	resp, err := client.Get("http://kubernetes.default.svc/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "kubernetes.default.svc" {
+		t.Errorf("expected the request for the server host, got %q", body)
+	}
+}
+
+// writeFakeSSH writes a shell script which stands in for the ssh client.
+func writeFakeSSH(t *testing.T, script string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh client is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSSHTunnelDialer(t *testing.T) {
+	dir := t.TempDir()
+	// The fake client records its arguments and agent socket, and echoes
+	// what is sent through the tunnel.
+	command := writeFakeSSH(t, `printf '%s\n' "$@" > `+dir+`/args
+echo "$SSH_AUTH_SOCK" > `+dir+`/agent
+exec cat
+`)
+	dial := NewSSHTunnelDialer(SSHTunnelConfig{
+		Host:           "admin@bastion.example.com:2222",
+		IdentityFile:   "/keys/id_ed25519",
+		AgentSocket:    "/run/agent.sock",
+		KnownHostsFile: "/keys/known_hosts",
+		Command:        command,
+	})
+
+	conn, err := dial(context.Background(), "tcp", "10.0.0.1:6443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("expected the data to be forwarded, got %q", buf)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedArgs := []string{
+		"-W", "10.0.0.1:6443",
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "ExitOnForwardFailure=yes",
+		"-p", "2222",
+		"-i", "/keys/id_ed25519", "-o", "IdentitiesOnly=yes",
+		"-o", "UserKnownHostsFile=/keys/known_hosts",
+		"--", "admin@bastion.example.com",
+	}
+	if actual := strings.Split(strings.TrimSpace(string(args)), "\n"); !reflect.DeepEqual(expectedArgs, actual) {
+		t.Errorf("expected arguments %q, got %q", expectedArgs, actual)
+	}
+	agent, err := os.ReadFile(filepath.Join(dir, "agent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(agent)) != "/run/agent.sock" {
+		t.Errorf("expected the agent socket to be passed, got %q", agent)
+	}
+}
+
+func TestSSHTunnelDialerFailure(t *testing.T) {
+	command := writeFakeSSH(t, `echo "admin@bastion: Permission denied (publickey)." >&2
+exit 255
+`)
+	dial := NewSSHTunnelDialer(SSHTunnelConfig{Host: "admin@bastion", Command: command})
+
+	conn, err := dial(context.Background(), "tcp", "10.0.0.1:6443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = conn.Read(make([]byte, 1))
+	if err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("expected the error of the ssh client, got %v", err)
+	}
+
+	if _, err := dial(context.Background(), "udp", "10.0.0.1:6443"); err == nil {
+		t.Error("expected an error for a UDP address")
+	}
+	if _, err := NewSSHTunnelDialer(SSHTunnelConfig{Host: "-oProxyCommand=evil"})(context.Background(), "tcp", "10.0.0.1:6443"); err == nil {
+		t.Error("expected an error for a host which looks like an option")
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxytest provides proxies for the tests of the transports.
+package proxytest
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// SOCKS5Proxy is a SOCKS5 proxy which requires username and password
+// authentication, for tests of clients behind a proxy. Only use it in
+// tests; it has not been security reviewed.
+type SOCKS5Proxy struct {
+	t        testing.TB
+	listener net.Listener
+	username string
+	password string
+
+	lock    sync.Mutex
+	targets []string
+	open    map[net.Conn]struct{}
+	conns   sync.WaitGroup
+}
+
+// NewSOCKS5Proxy starts a SOCKS5 proxy on the loopback interface which
+// accepts the given credentials. It is stopped when the test ends.
+func NewSOCKS5Proxy(t testing.TB, username, password string) *SOCKS5Proxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &SOCKS5Proxy{t: t, listener: listener, username: username, password: password, open: map[net.Conn]struct{}{}}
+	p.conns.Add(1)
+	go func() {
+		defer p.conns.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			p.lock.Lock()
+			p.open[conn] = struct{}{}
+			p.lock.Unlock()
+			p.conns.Add(1)
+			go func() {
+				defer p.conns.Done()
+				p.serve(conn)
+				p.lock.Lock()
+				delete(p.open, conn)
+				p.lock.Unlock()
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		p.lock.Lock()
+		for conn := range p.open {
+			conn.Close()
+		}
+		p.lock.Unlock()
+		p.conns.Wait()
+	})
+	return p
+}
+
+// URL returns the URL of the proxy with the given credentials.
+func (p *SOCKS5Proxy) URL(username, password string) *url.URL {
+	return &url.URL{Scheme: "socks5", User: url.UserPassword(username, password), Host: p.listener.Addr().String()}
+}
+
+// Targets returns the addresses which clients connected to through the
+// proxy.
+func (p *SOCKS5Proxy) Targets() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+func (p *SOCKS5Proxy) serve(conn net.Conn) {
+	defer conn.Close()
+	target, err := p.handshake(conn)
+	if err != nil {
+		p.t.Logf("SOCKS5 handshake failed: %v", err)
+		return
+	}
+	backend, err := net.Dial("tcp", target)
+	if err != nil {
+		p.t.Logf("Failed to dial proxy backend %s: %v", target, err)
+		// General failure.
+		_, _ = conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer backend.Close()
+	p.lock.Lock()
+	p.targets = append(p.targets, target)
+	p.lock.Unlock()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	// The tunnel is closed when either side closes its connection.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(backend, conn)
+		backend.Close()
+	}()
+	_, _ = io.Copy(conn, backend)
+	conn.Close()
+	<-done
+}
+
+// handshake authenticates the client and returns the address of its
+// CONNECT request.
+func (p *SOCKS5Proxy) handshake(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != 5 {
+		return "", errors.New("unsupported SOCKS version " + strconv.Itoa(int(header[0])))
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	// Only the username and password method is accepted.
+	if !slices.Contains(methods, 2) {
+		_, _ = conn.Write([]byte{5, 0xff})
+		return "", errors.New("the client doesn't support username and password authentication")
+	}
+	if _, err := conn.Write([]byte{5, 2}); err != nil {
+		return "", err
+	}
+	username, password, err := readCredentials(conn)
+	if err != nil {
+		return "", err
+	}
+	if username != p.username || password != p.password {
+		_, _ = conn.Write([]byte{1, 1})
+		return "", errors.New("invalid credentials for user " + username)
+	}
+	if _, err := conn.Write([]byte{1, 0}); err != nil {
+		return "", err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != 1 {
+		// Command not supported.
+		_, _ = conn.Write([]byte{5, 7, 0, 1, 0, 0, 0, 0, 0, 0})
+		return "", errors.New("unsupported SOCKS command " + strconv.Itoa(int(request[1])))
+	}
+	var host string
+	switch request[3] {
+	case 1, 4:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == 4 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		name, err := readString(conn)
+		if err != nil {
+			return "", err
+		}
+		host = name
+	default:
+		return "", errors.New("unsupported SOCKS address type " + strconv.Itoa(int(request[3])))
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// readCredentials reads the username and password subnegotiation of RFC
+// 1929.
+func readCredentials(r io.Reader) (string, string, error) {
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(r, version); err != nil {
+		return "", "", err
+	}
+	if version[0] != 1 {
+		return "", "", errors.New("unsupported authentication version " + strconv.Itoa(int(version[0])))
+	}
+	username, err := readString(r)
+	if err != nil {
+		return "", "", err
+	}
+	password, err := readString(r)
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// readString reads a string prefixed with its length as a single byte.
+func readString(r io.Reader) (string, error) {
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(r, length); err != nil {
+		return "", err
+	}
+	s := make([]byte, length[0])
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
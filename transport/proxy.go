@@ -0,0 +1,198 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// NewProxyDialer returns a dial function which connects to the addresses
+// of URLs with the given scheme through the proxy that proxier returns for
+// them, for connections which are upgraded to streams and so can't be sent
+// through an http.Transport.
+//
+// http and https proxies are asked to tunnel the connection with a CONNECT
+// request, and socks5 proxies with the SOCKS5 protocol. The user info of the
+// proxy URL, if any, authenticates with the proxy: with basic authentication
+// in the Proxy-Authorization header of a CONNECT request, or with the
+// username and password method of SOCKS5. https proxies are verified with
+// tlsConfig, the TLS configuration of the server.
+//
+// dial opens the connections to the proxies, and to the addresses which
+// don't use a proxy. If it is nil, a net.Dialer is used.
+func NewProxyDialer(scheme string, proxier func(*http.Request) (*url.URL, error), tlsConfig *tls.Config, dial DialFunc) DialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		var proxyURL *url.URL
+		if proxier != nil {
+			var err error
+			proxyURL, err = proxier(&http.Request{URL: &url.URL{Scheme: scheme, Host: address}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if proxyURL == nil {
+			return dial(ctx, network, address)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https":
+			return dialHTTPProxy(ctx, proxyURL, address, tlsConfig, dial)
+		case "socks5", "socks5h":
+			return dialSOCKS5Proxy(ctx, proxyURL, address, dial)
+		}
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+}
+
+// proxyAddress returns the address of the proxy at proxyURL, with the
+// default port of its scheme if it has none.
+func proxyAddress(proxyURL *url.URL) string {
+	if port := proxyURL.Port(); len(port) > 0 {
+		return proxyURL.Host
+	}
+	port := "80"
+	switch proxyURL.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// dialHTTPProxy connects to address through a CONNECT tunnel of the http
+// or https proxy at proxyURL.
+func dialHTTPProxy(ctx context.Context, proxyURL *url.URL, address string, tlsConfig *tls.Config, dial DialFunc) (net.Conn, error) {
+	conn, err := dial(ctx, "tcp", proxyAddress(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	// The handshake with the proxy is bound to the context of the dial, but
+	// the tunnel outlives it.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+	tunnel, err := connectHTTPProxy(ctx, conn, proxyURL, address, tlsConfig)
+	if !stop() || err != nil {
+		conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return tunnel, nil
+}
+
+func connectHTTPProxy(ctx context.Context, conn net.Conn, proxyURL *url.URL, address string, tlsConfig *tls.Config) (net.Conn, error) {
+	if proxyURL.Scheme == "https" {
+		config := &tls.Config{}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		// The proxy speaks HTTP/1.1 with its own host name.
+		config.ServerName = proxyURL.Hostname()
+		config.NextProtos = nil
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake with proxy %s failed: %w", proxyURL.Redacted(), err)
+		}
+		conn = tlsConn
+	}
+
+	connectReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connectReq.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connectReq.Write(conn); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, connectReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response of proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// The body of a successful response is the tunnel, so only the
+		// body of an error is read.
+		resp.Body.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyURL.Redacted(), address, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		// The proxy already forwarded data from the server.
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read into a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// dialSOCKS5Proxy connects to address through the SOCKS5 proxy at
+// proxyURL. The proxy resolves the host name of address.
+func dialSOCKS5Proxy(ctx context.Context, proxyURL *url.URL, address string, dial DialFunc) (net.Conn, error) {
+	var auth *proxy.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", proxyAddress(proxyURL), auth, forwardDialer(dial))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s failed to connect to %s: %w", proxyURL.Redacted(), address, err)
+	}
+	return conn, nil
+}
+
+// forwardDialer adapts a DialFunc to the dialers of golang.org/x/net/proxy.
+type forwardDialer DialFunc
+
+func (d forwardDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d forwardDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	utilnettesting "k8s.io/apimachinery/pkg/util/net/testing"
+	"k8s.io/client-go/transport/internal/proxytest"
+)
+
+// newEchoServer starts a server which echoes a ping of every connection.
+func newEchoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4)
+				if _, err := io.ReadFull(conn, buf); err == nil {
+					_, _ = conn.Write(buf)
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// newHTTPProxy starts a CONNECT proxy which requires basic authentication
+// with credentials.
+func newHTTPProxy(t *testing.T, credentials string) string {
+	handler := utilnettesting.NewHTTPProxyHandler(t, func(req *http.Request) bool {
+		return req.Header.Get("Proxy-Authorization") == "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials))
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func ping(t *testing.T, dial DialFunc, address string) error {
+	t.Helper()
+	conn, err := dial(context.Background(), "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		return err
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if string(buf) != "ping" {
+		t.Errorf("expected the data to be forwarded, got %q", buf)
+	}
+	return nil
+}
+
+func proxyFor(proxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	return func(*http.Request) (*url.URL, error) {
+		return proxyURL, nil
+	}
+}
+
+func TestProxyDialerHTTP(t *testing.T) {
+	server := newEchoServer(t)
+	proxy := newHTTPProxy(t, "admin:secret")
+	tokenProxy := newHTTPProxy(t, "token:")
+
+	testCases := []struct {
+		name        string
+		proxyURL    *url.URL
+		expectError bool
+	}{
+		{
+			name:     "password",
+			proxyURL: &url.URL{Scheme: "http", User: url.UserPassword("admin", "secret"), Host: proxy},
+		},
+		{
+			name:     "username only",
+			proxyURL: &url.URL{Scheme: "http", User: url.User("token"), Host: tokenProxy},
+		},
+		{
+			name:        "invalid password",
+			proxyURL:    &url.URL{Scheme: "http", User: url.UserPassword("admin", "guess"), Host: proxy},
+			expectError: true,
+		},
+		{
+			name:        "no credentials",
+			proxyURL:    &url.URL{Scheme: "http", Host: proxy},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ping(t, NewProxyDialer("https", proxyFor(tc.proxyURL), nil, nil), server)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestProxyDialerSOCKS5(t *testing.T) {
+	server := newEchoServer(t)
+	proxy := proxytest.NewSOCKS5Proxy(t, "admin", "secret")
+
+	// The proxy is dialed with the dial function.
+	var dials atomic.Int32
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	}
+	if err := ping(t, NewProxyDialer("https", proxyFor(proxy.URL("admin", "secret")), nil, dial), server); err != nil {
+		t.Fatal(err)
+	}
+	if dials.Load() != 1 {
+		t.Errorf("expected the proxy to be dialed with the dial function, got %d dials", dials.Load())
+	}
+	if targets := proxy.Targets(); len(targets) != 1 || targets[0] != server {
+		t.Errorf("expected a connection to %s through the proxy, got %v", server, targets)
+	}
+
+	if err := ping(t, NewProxyDialer("https", proxyFor(proxy.URL("admin", "guess")), nil, nil), server); err == nil {
+		t.Error("expected an error for invalid credentials")
+	}
+}
+
+func TestProxyDialerNoProxy(t *testing.T) {
+	server := newEchoServer(t)
+
+	var requested *url.URL
+	proxier := func(req *http.Request) (*url.URL, error) {
+		requested = req.URL
+		return nil, nil
+	}
+	if err := ping(t, NewProxyDialer("https", proxier, nil, nil), server); err != nil {
+		t.Fatal(err)
+	}
+	if requested == nil || requested.String() != "https://"+server {
+		t.Errorf("expected the proxy to be chosen for https://%s, got %v", server, requested)
+	}
+
+	unsupported := proxyFor(&url.URL{Scheme: "ftp", Host: "proxy.example.com"})
+	if err := ping(t, NewProxyDialer("https", unsupported, nil, nil), server); err == nil {
+		t.Error("expected an error for an unsupported proxy scheme")
+	}
+}
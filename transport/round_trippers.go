@@ -39,6 +39,17 @@ import (
 // HTTP2 clients). Pure HTTP clients should use the RoundTripper returned from
 // New.
 func HTTPWrappersForConfig(config *Config, rt http.RoundTripper) (http.RoundTripper, error) {
+	// Audit below all wrappers which set the headers that identify the user,
+	// including those of WrapTransport like the one of exec plugins.
+	if config.Audit != nil && config.Audit.Sink != nil {
+		rt = &auditRoundTripper{
+			sink:              config.Audit.Sink,
+			allVerbs:          config.Audit.AllVerbs,
+			clientCertificate: config.HasCertAuth() || config.HasCertCallback(),
+			rt:                rt,
+		}
+	}
+
 	if config.WrapTransport != nil {
 		rt = config.WrapTransport(rt)
 	}
@@ -17,6 +17,7 @@ limitations under the License.
 package spdy
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -24,7 +25,9 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 )
 
 // Upgrader validates a response from the server after a SPDY upgrade.
@@ -34,20 +37,34 @@ type Upgrader interface {
 }
 
 // RoundTripperFor returns a round tripper and upgrader to use with SPDY.
+// The connections are opened with the dialer of the config, through the
+// proxy of the config or the environment.
 func RoundTripperFor(config *restclient.Config) (http.RoundTripper, Upgrader, error) {
 	tlsConfig, err := restclient.TLSConfigFor(config)
 	if err != nil {
 		return nil, nil, err
 	}
-	proxy := http.ProxyFromEnvironment
-	if config.Proxy != nil {
-		proxy = config.Proxy
+	if tlsConfig == nil {
+		// Without a TLS config the upgrade transport would skip the
+		// verification of the server.
+		tlsConfig = &tls.Config{}
+	}
+	proxy := config.Proxy
+	if proxy == nil {
+		proxy = utilnet.NewProxierWithNoProxyCIDR(http.ProxyFromEnvironment)
+	}
+	// The scheme of the server decides which proxy of the environment is
+	// used. Invalid hosts fail on the request instead.
+	scheme := "https"
+	if host, _, err := restclient.DefaultServerUrlFor(config); err == nil {
+		scheme = host.Scheme
 	}
 	upgradeRoundTripper, err := spdy.NewRoundTripperWithConfig(spdy.RoundTripperConfig{
-		TLS:              tlsConfig,
-		Proxier:          proxy,
-		PingPeriod:       time.Second * 5,
-		UpgradeTransport: nil,
+		PingPeriod: time.Second * 5,
+		UpgradeTransport: &http.Transport{
+			DialContext:     transport.NewProxyDialer(scheme, proxy, tlsConfig, config.Dial),
+			TLSClientConfig: tlsConfig,
+		},
 	})
 	if err != nil {
 		return nil, nil, err
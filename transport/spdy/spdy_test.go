@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spdy
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	utilnettesting "k8s.io/apimachinery/pkg/util/net/testing"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/transport/internal/proxytest"
+)
+
+func TestRoundTripperForProxy(t *testing.T) {
+	// The server upgrades to SPDY, and closes the connection once the client
+	// is done.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn := spdy.NewResponseUpgrader().UpgradeResponse(w, req, httpstream.NoOpNewStreamHandler)
+		if conn == nil {
+			return
+		}
+		defer conn.Close()
+		<-conn.CloseChan()
+	}))
+	defer server.Close()
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// An HTTP proxy which requires basic authentication.
+	var httpProxyCalled atomic.Int64
+	httpProxyHandler := utilnettesting.NewHTTPProxyHandler(t, func(req *http.Request) bool {
+		httpProxyCalled.Add(1)
+		return req.Header.Get("Proxy-Authorization") == "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+	})
+	httpProxyServer := httptest.NewServer(httpProxyHandler)
+	defer httpProxyServer.Close()
+	httpProxyLocation, err := url.Parse(httpProxyServer.URL)
+	require.NoError(t, err)
+
+	socks5Proxy := proxytest.NewSOCKS5Proxy(t, "admin", "secret")
+
+	testCases := map[string]struct {
+		proxyLocation *url.URL
+		proxyCalled   func() int
+		expectError   bool
+	}{
+		"http proxy with basic authentication": {
+			proxyLocation: &url.URL{Scheme: "http", User: url.UserPassword("admin", "secret"), Host: httpProxyLocation.Host},
+			proxyCalled:   func() int { return int(httpProxyCalled.Load()) },
+		},
+		"http proxy with invalid credentials": {
+			proxyLocation: &url.URL{Scheme: "http", User: url.UserPassword("admin", "guess"), Host: httpProxyLocation.Host},
+			proxyCalled:   func() int { return int(httpProxyCalled.Load()) },
+			expectError:   true,
+		},
+		"socks5 proxy with username and password": {
+			proxyLocation: socks5Proxy.URL("admin", "secret"),
+			proxyCalled:   func() int { return len(socks5Proxy.Targets()) },
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			calls := tc.proxyCalled()
+			config := &restclient.Config{
+				Host:            server.URL,
+				TLSClientConfig: restclient.TLSClientConfig{CAData: caData},
+				Proxy: func(*http.Request) (*url.URL, error) {
+					return tc.proxyLocation, nil
+				},
+			}
+			rt, upgrader, err := RoundTripperFor(config)
+			require.NoError(t, err)
+			serverLocation, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			conn, _, err := NewDialer(upgrader, &http.Client{Transport: rt}, http.MethodPost, serverLocation).Dial()
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+			assert.Equal(t, calls+1, tc.proxyCalled(), "expected the connection to go through the proxy")
+		})
+	}
+}
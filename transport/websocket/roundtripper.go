@@ -17,10 +17,12 @@ limitations under the License.
 package websocket
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -72,8 +74,16 @@ type RoundTripper struct {
 	// Request. If the function returns a non-nil error, the
 	// request is aborted with the provided error.
 	// If Proxy is nil or returns a nil *URL, no proxy is used.
+	// http and https proxies may require basic authentication, and socks5
+	// proxies username and password authentication, with the user info of
+	// the proxy URL.
 	Proxier func(req *http.Request) (*url.URL, error)
 
+	// Dial specifies the dial function for creating unencrypted TCP
+	// connections to the server or to the proxy. If Dial is nil, a
+	// net.Dialer is used.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+
 	// Conn holds the WebSocket connection after a round trip.
 	Conn *gwebsocket.Conn
 }
@@ -110,8 +120,10 @@ func (rt *RoundTripper) RoundTrip(request *http.Request) (retResp *http.Response
 	protocolVersions := request.Header[wsstream.WebSocketProtocolHeader]
 	delete(request.Header, wsstream.WebSocketProtocolHeader)
 
+	// The proxy is dialed here rather than by the websocket dialer, which
+	// only supports a subset of the proxy authentication methods.
 	dialer := gwebsocket.Dialer{
-		Proxy:           rt.Proxier,
+		NetDialContext:  transport.NewProxyDialer(request.URL.Scheme, rt.Proxier, rt.TLSConfig, rt.Dial),
 		TLSClientConfig: rt.TLSConfig,
 		Subprotocols:    protocolVersions,
 		ReadBufferSize:  rt.DataBufferSize() + 1024, // add space for the protocol byte indicating which channel the data is for
@@ -197,6 +209,7 @@ func RoundTripperFor(config *restclient.Config) (http.RoundTripper, ConnectionHo
 	upgradeRoundTripper := &RoundTripper{
 		TLSConfig: tlsConfig,
 		Proxier:   proxy,
+		Dial:      config.Dial,
 	}
 	wrapper, err := transport.HTTPWrappersForConfig(transportCfg, upgradeRoundTripper)
 	if err != nil {
@@ -18,11 +18,13 @@ package websocket
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -34,8 +36,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/apimachinery/pkg/util/httpstream/wsstream"
+	utilnettesting "k8s.io/apimachinery/pkg/util/net/testing"
 	"k8s.io/apimachinery/pkg/util/remotecommand"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/transport/internal/proxytest"
 )
 
 func TestWebSocketRoundTripper_RoundTripperSucceeds(t *testing.T) {
@@ -67,6 +71,68 @@ func TestWebSocketRoundTripper_RoundTripperSucceeds(t *testing.T) {
 
 }
 
+func TestWebSocketRoundTripper_RoundTripperProxy(t *testing.T) {
+	websocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conns, err := webSocketServerStreams(req, w)
+		if err != nil {
+			t.Errorf("error on webSocketServerStreams: %v", err)
+			return
+		}
+		defer conns.conn.Close()
+	}))
+	defer websocketServer.Close()
+	websocketLocation, err := url.Parse(websocketServer.URL)
+	require.NoError(t, err)
+
+	// An HTTP proxy which requires basic authentication.
+	var httpProxyCalled atomic.Int64
+	httpProxyHandler := utilnettesting.NewHTTPProxyHandler(t, func(req *http.Request) bool {
+		httpProxyCalled.Add(1)
+		return req.Header.Get("Proxy-Authorization") == "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+	})
+	httpProxyServer := httptest.NewServer(httpProxyHandler)
+	defer httpProxyServer.Close()
+	httpProxyLocation, err := url.Parse(httpProxyServer.URL)
+	require.NoError(t, err)
+	httpProxyLocation.User = url.UserPassword("admin", "secret")
+
+	socks5Proxy := proxytest.NewSOCKS5Proxy(t, "admin", "secret")
+
+	testCases := map[string]struct {
+		proxyLocation *url.URL
+		proxyCalled   func() int
+	}{
+		"http proxy with basic authentication": {
+			proxyLocation: httpProxyLocation,
+			proxyCalled:   func() int { return int(httpProxyCalled.Load()) },
+		},
+		"socks5 proxy with username and password": {
+			proxyLocation: socks5Proxy.URL("admin", "secret"),
+			proxyCalled:   func() int { return len(socks5Proxy.Targets()) },
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			calls := tc.proxyCalled()
+			req, err := http.NewRequestWithContext(context.Background(), "GET", websocketServer.URL, nil)
+			require.NoError(t, err)
+			rt, wsRt, err := RoundTripperFor(&restclient.Config{
+				Host: websocketLocation.Host,
+				Proxy: func(*http.Request) (*url.URL, error) {
+					return tc.proxyLocation, nil
+				},
+			})
+			require.NoError(t, err)
+			req.Header[wsstream.WebSocketProtocolHeader] = []string{remotecommand.StreamProtocolV5Name}
+			_, err = rt.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, remotecommand.StreamProtocolV5Name, wsRt.Connection().Subprotocol())
+			assert.Equal(t, calls+1, tc.proxyCalled(), "expected the connection to go through the proxy")
+			require.NoError(t, wsRt.Connection().Close())
+		})
+	}
+}
+
 func TestWebSocketRoundTripper_RoundTripperFails(t *testing.T) {
 	testCases := map[string]struct {
 		statusCode    int
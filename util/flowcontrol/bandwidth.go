@@ -0,0 +1,139 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthBurst is the most bytes a BandwidthLimiter passes at once.
+const maxBandwidthBurst = 32 * 1024
+
+// BandwidthLimiter limits the rate at which bytes are transferred. It is a
+// token bucket of bytes, which may be shared by several readers and writers
+// to limit them together.
+type BandwidthLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewBandwidthLimiter creates a BandwidthLimiter which passes bytesPerSecond
+// bytes per second, in bursts of up to 32KiB. It returns nil, which doesn't
+// limit anything, if bytesPerSecond isn't positive.
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := maxBandwidthBurst
+	if bytesPerSecond < maxBandwidthBurst {
+		burst = int(bytesPerSecond)
+	}
+	return &BandwidthLimiter{limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst)}
+}
+
+// WaitN returns nil once n bytes may be transferred, or the error of ctx
+// if it is done before. A nil BandwidthLimiter returns immediately.
+func (l *BandwidthLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := min(n, l.limiter.Burst())
+		if err := l.limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// chunkSize returns the most bytes all limiters pass at once.
+func chunkSize(limiters []*BandwidthLimiter, n int) int {
+	for _, l := range limiters {
+		if l != nil {
+			n = min(n, l.limiter.Burst())
+		}
+	}
+	return n
+}
+
+// waitAll waits until all limiters pass n bytes.
+func waitAll(ctx context.Context, limiters []*BandwidthLimiter, n int) error {
+	for _, l := range limiters {
+		if err := l.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LimitReader returns a Reader which reads from r no faster than all
+// limiters allow; nil limiters are ignored. A read which waits for the
+// limiters after ctx is done fails with the error of ctx.
+func LimitReader(ctx context.Context, r io.Reader, limiters ...*BandwidthLimiter) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, limiters: limiters}
+}
+
+type limitedReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*BandwidthLimiter
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return l.r.Read(p)
+	}
+	n, err := l.r.Read(p[:chunkSize(l.limiters, len(p))])
+	// the bytes which were read are passed even if waiting fails, the
+	// limiters can only slow down the next read
+	if waitErr := waitAll(l.ctx, l.limiters, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
+
+// LimitWriter returns a Writer which writes to w no faster than all
+// limiters allow; nil limiters are ignored. A write which waits for the
+// limiters after ctx is done fails with the error of ctx.
+func LimitWriter(ctx context.Context, w io.Writer, limiters ...*BandwidthLimiter) io.Writer {
+	return &limitedWriter{ctx: ctx, w: w, limiters: limiters}
+}
+
+type limitedWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	limiters []*BandwidthLimiter
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written : written+chunkSize(l.limiters, len(p)-written)]
+		if err := waitAll(l.ctx, l.limiters, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := l.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLimitWriter(t *testing.T) {
+	// a burst of 32KiB, then 64KiB at 128KiB per second
+	limiter := NewBandwidthLimiter(128 * 1024)
+	var buf bytes.Buffer
+	w := LimitWriter(context.Background(), &buf, limiter, nil)
+
+	data := bytes.Repeat([]byte("x"), 96*1024)
+	start := time.Now()
+	n, err := w.Write(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != len(data) || !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("expected %d bytes to be written, got %d", len(data), n)
+	}
+	if duration := time.Since(start); duration < 490*time.Millisecond {
+		t.Errorf("expected the write to take at least 500ms, took %v", duration)
+	}
+}
+
+func TestLimitReaderSharedLimiter(t *testing.T) {
+	shared := NewBandwidthLimiter(64 * 1024)
+	data := bytes.Repeat([]byte("x"), 32*1024)
+	r1 := LimitReader(context.Background(), bytes.NewReader(data), NewBandwidthLimiter(1024*1024), shared)
+	r2 := LimitReader(context.Background(), bytes.NewReader(data), shared)
+
+	// the readers take the burst of the shared limiter, and then have to
+	// wait for it together
+	start := time.Now()
+	for _, r := range []io.Reader{r1, r2} {
+		read, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(read) != len(data) {
+			t.Errorf("expected %d bytes to be read, got %d", len(data), len(read))
+		}
+	}
+	if duration := time.Since(start); duration < 490*time.Millisecond {
+		t.Errorf("expected the reads to take at least 500ms, took %v", duration)
+	}
+}
+
+func TestLimitWriterContextDone(t *testing.T) {
+	limiter := NewBandwidthLimiter(1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := LimitWriter(ctx, io.Discard, limiter)
+	n, err := w.Write(make([]byte, 4096))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the write to be canceled, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected no bytes to be written, got %d", n)
+	}
+}
+
+func TestNewBandwidthLimiterUnlimited(t *testing.T) {
+	if limiter := NewBandwidthLimiter(0); limiter != nil {
+		t.Fatalf("expected no limiter, got %v", limiter)
+	}
+	var limiter *BandwidthLimiter
+	if err := limiter.WaitN(context.Background(), 1<<30); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}